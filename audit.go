@@ -0,0 +1,265 @@
+// Copyright (c) 2026, AT&T Intellectual Property. All rights reserved.
+//
+// SPDX-License-Identifier: MPL-2.0
+
+package vci
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/syslog"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// An AuditRecord describes a change to or check of a component's
+// configuration, or a call to one of its RPCs.
+type AuditRecord struct {
+	Time time.Time
+	// Caller describes who made the call, as far as the transport
+	// knows.
+	Caller RPCMetadata
+	// ModelName is the model whose handler was called.
+	ModelName string
+	// Kind is "config" or "rpc".
+	Kind string
+	// ModuleName is the YANG module of an RPC.
+	ModuleName string
+	// Method is the YANG name of the RPC or the configuration method:
+	// set, check, prepare, commit or abort.
+	Method string
+	// Input is the encoded input, with sensitive leaves redacted.
+	Input string
+	// Output is the encoded output of an RPC, with sensitive leaves
+	// redacted.
+	Output string
+	// Err is the error returned by the handler, usually a mgmterror.
+	Err error
+}
+
+// An AuditSink records the audit trail of a component. Records are
+// passed to the sink as each call completes, so a sink used by a
+// component with concurrent handlers must be safe for concurrent use.
+type AuditSink interface {
+	Record(record *AuditRecord) error
+}
+
+const redactedValue = "********"
+
+// auditInterceptor records each configuration change or check and each
+// RPC invocation. Reads of configuration and state are not recorded.
+// Errors from the sink are not returned to the caller as the call has
+// already been handled.
+func auditInterceptor(sink AuditSink, redact []string) Interceptor {
+	return func(
+		ctx context.Context,
+		inv *Invocation,
+		invoke Invoker,
+	) (string, error) {
+		if inv.Kind == "state" ||
+			inv.Method == "get" || inv.Method == "get-path" {
+			return invoke(ctx, inv)
+		}
+		record := &AuditRecord{
+			Time:       time.Now(),
+			Caller:     inv.Metadata,
+			ModelName:  inv.ModelName,
+			Kind:       inv.Kind,
+			ModuleName: inv.ModuleName,
+			Method:     inv.Method,
+			Input:      redactLeaves(inv.Input, redact),
+		}
+		out, err := invoke(ctx, inv)
+		if err == nil && inv.Kind == "rpc" {
+			record.Output = redactLeaves(out, redact)
+		}
+		record.Err = err
+		_ = sink.Record(record)
+		return out, err
+	}
+}
+
+// redactLeaves replaces the values of the named leaves in the encoded
+// data. A leaf may be named with or without its module prefix. Data that
+// is not JSON encoded cannot be searched so is redacted entirely.
+func redactLeaves(encodedData string, leaves []string) string {
+	if len(leaves) == 0 || encodedData == "" {
+		return encodedData
+	}
+	// Numbers are kept as they were written, so that large integers
+	// such as uint64 leaves are not rounded.
+	dec := json.NewDecoder(strings.NewReader(encodedData))
+	dec.UseNumber()
+	var data interface{}
+	err := dec.Decode(&data)
+	if err != nil || dec.Decode(new(json.RawMessage)) != io.EOF {
+		return redactedValue
+	}
+	redacted, err := json.Marshal(redactValue(data, leaves))
+	if err != nil {
+		return redactedValue
+	}
+	return string(redacted)
+}
+
+func redactValue(data interface{}, leaves []string) interface{} {
+	switch v := data.(type) {
+	case map[string]interface{}:
+		for name, value := range v {
+			if isRedactedLeaf(name, leaves) {
+				v[name] = redactedValue
+				continue
+			}
+			v[name] = redactValue(value, leaves)
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = redactValue(value, leaves)
+		}
+	}
+	return data
+}
+
+func isRedactedLeaf(name string, leaves []string) bool {
+	unqualified := name
+	if i := strings.IndexByte(name, ':'); i >= 0 {
+		unqualified = name[i+1:]
+	}
+	for _, leaf := range leaves {
+		if leaf == name || leaf == unqualified {
+			return true
+		}
+	}
+	return false
+}
+
+// errorTag returns the NETCONF error-tag of a mgmterror, or
+// "operation-failed" for any other error.
+func errorTag(err error) string {
+	v := reflect.ValueOf(err)
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	if v.Kind() == reflect.Struct {
+		tag := v.FieldByName("Tag")
+		if tag.Kind() == reflect.String && tag.String() != "" {
+			return tag.String()
+		}
+	}
+	return "operation-failed"
+}
+
+// fields returns the record as it is written by the sinks provided by
+// this package.
+func (r *AuditRecord) fields() map[string]interface{} {
+	fields := map[string]interface{}{
+		"time":   r.Time.UTC().Format(time.RFC3339Nano),
+		"user":   r.Caller.User,
+		"uid":    r.Caller.Uid,
+		"pid":    r.Caller.Pid,
+		"model":  r.ModelName,
+		"kind":   r.Kind,
+		"method": r.Method,
+		"input":  r.Input,
+		"result": "ok",
+	}
+	if len(r.Caller.Groups) != 0 {
+		fields["groups"] = r.Caller.Groups
+	}
+	if r.ModuleName != "" {
+		fields["module"] = r.ModuleName
+	}
+	if r.Output != "" {
+		fields["output"] = r.Output
+	}
+	if r.Err != nil {
+		fields["result"] = errorTag(r.Err)
+		fields["error"] = r.Err.Error()
+	}
+	return fields
+}
+
+type auditFileSink struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewAuditFileSink returns a sink that appends each record to the file
+// as a line of JSON, creating the file if needed. The file is only
+// readable by its owner.
+func NewAuditFileSink(path string) (AuditSink, error) {
+	file, err := os.OpenFile(path,
+		os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &auditFileSink{file: file}, nil
+}
+
+func (s *auditFileSink) Record(record *AuditRecord) error {
+	line, err := json.Marshal(record.fields())
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.file.Write(append(line, '\n'))
+	return err
+}
+
+type auditSyslogSink struct {
+	writer *syslog.Writer
+}
+
+// NewAuditSyslogSink returns a sink that sends each record to the local
+// syslog daemon as JSON, with the given tag, at the authpriv facility.
+func NewAuditSyslogSink(tag string) (AuditSink, error) {
+	writer, err := syslog.New(syslog.LOG_AUTHPRIV|syslog.LOG_INFO, tag)
+	if err != nil {
+		return nil, err
+	}
+	return &auditSyslogSink{writer: writer}, nil
+}
+
+func (s *auditSyslogSink) Record(record *AuditRecord) error {
+	line, err := json.Marshal(record.fields())
+	if err != nil {
+		return err
+	}
+	return s.writer.Info(string(line))
+}
+
+type auditNotificationSink struct {
+	client                       *Client
+	moduleName, notificationName string
+}
+
+// NewAuditNotificationSink returns a sink that emits each record as a
+// VCI notification using the client, usually the component's own. The
+// notification's YANG definition must have leaves for the fields of the
+// record: time, user, uid, pid, groups, model, kind, module, method,
+// input, output, result and error. As RFC7951 requires, each is
+// qualified by the notification's module name, as in "audit-v1:user".
+func NewAuditNotificationSink(
+	client *Client,
+	moduleName, notificationName string,
+) AuditSink {
+	return &auditNotificationSink{
+		client:           client,
+		moduleName:       moduleName,
+		notificationName: notificationName,
+	}
+}
+
+func (s *auditNotificationSink) Record(record *AuditRecord) error {
+	fields := record.fields()
+	qualified := make(map[string]interface{}, len(fields))
+	for name, value := range fields {
+		qualified[s.moduleName+":"+name] = value
+	}
+	return s.client.Emit(s.moduleName, s.notificationName, qualified)
+}
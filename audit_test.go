@@ -0,0 +1,272 @@
+// Copyright (c) 2026, AT&T Intellectual Property. All rights reserved.
+//
+// SPDX-License-Identifier: MPL-2.0
+
+package vci
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/danos/mgmterror"
+)
+
+type testAuditSink struct {
+	mu      sync.Mutex
+	records []*AuditRecord
+}
+
+func (s *testAuditSink) Record(record *AuditRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = append(s.records, record)
+	return nil
+}
+
+func (s *testAuditSink) take() []*AuditRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	records := s.records
+	s.records = nil
+	return records
+}
+
+func TestAudit(t *testing.T) {
+	const model = "com.vyatta.test.foo.v1"
+	setup := func(t *testing.T, opts ...Option) *Client {
+		resetTestBus()
+		comp := NewComponentWithOptions("com.vyatta.test.foo",
+			append([]Option{WithTransport(newTestTransport())},
+				opts...)...)
+		comp.Model(model).
+			Config(&testRunningConfigWithValue{testConfig{Value: "foo"}}).
+			RPC("foo-v1", map[string]interface{}{
+				"call-me": func(in *testConfig) (*testConfig, error) {
+					if in.Value == "fail" {
+						return nil, mgmterror.NewInvalidValueApplicationError()
+					}
+					return &testConfig{Value: "secret"}, nil
+				},
+			})
+		err := comp.Run()
+		if err != nil {
+			t.Fatal(err)
+		}
+		client, err := Dial()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return client
+	}
+
+	t.Run("config", func(t *testing.T) {
+		sink := &testAuditSink{}
+		client := setup(t, WithAudit(sink))
+		err := client.CheckConfigForModel(model, &testConfig{Value: "bar"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = client.SetConfigForModel(model, &testConfig{Value: "bar"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var cfg testConfig
+		err = client.StoreConfigByModelInto(model, &cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		records := sink.take()
+		if len(records) != 2 {
+			t.Fatalf("expected 2 records, got %d", len(records))
+		}
+		for i, method := range []string{"check", "set"} {
+			rec := records[i]
+			if rec.ModelName != model || rec.Kind != "config" ||
+				rec.Method != method || rec.Input != `{"value":"bar"}` ||
+				rec.Err != nil || rec.Time.IsZero() {
+				t.Fatalf("unexpected record %+v", rec)
+			}
+		}
+	})
+	t.Run("rpc", func(t *testing.T) {
+		sink := &testAuditSink{}
+		client := setup(t, WithAudit(sink))
+		var out testConfig
+		err := client.CallWithMetadata("foo-v1", "call-me",
+			RPCMetadata{User: "alice"}, &testConfig{Value: "foo"}).
+			StoreOutputInto(&out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = client.Call("foo-v1", "call-me", &testConfig{Value: "fail"}).
+			StoreOutputInto(&out)
+		if err == nil {
+			t.Fatal("expected error did not occur")
+		}
+		records := sink.take()
+		if len(records) != 2 {
+			t.Fatalf("expected 2 records, got %d", len(records))
+		}
+		ok, failed := records[0], records[1]
+		if ok.Caller.User != "alice" || ok.ModuleName != "foo-v1" ||
+			ok.Method != "call-me" || ok.Output != `{"value":"secret"}` {
+			t.Fatalf("unexpected record %+v", ok)
+		}
+		if failed.Err == nil || failed.Output != "" {
+			t.Fatalf("unexpected record %+v", failed)
+		}
+		if tag := failed.fields()["result"]; tag != "invalid-value" {
+			t.Fatalf("expected invalid-value, got %v", tag)
+		}
+	})
+	t.Run("redact", func(t *testing.T) {
+		sink := &testAuditSink{}
+		client := setup(t, WithAudit(sink, "value"))
+		var out testConfig
+		err := client.Call("foo-v1", "call-me", &testConfig{Value: "foo"}).
+			StoreOutputInto(&out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out.Value != "secret" {
+			t.Fatalf("redaction changed the output: %+v", out)
+		}
+		rec := sink.take()[0]
+		exp := `{"value":"********"}`
+		if rec.Input != exp || rec.Output != exp {
+			t.Fatalf("unexpected record %+v", rec)
+		}
+	})
+}
+
+func TestRedactLeaves(t *testing.T) {
+	tests := []struct {
+		desc, in, exp string
+		leaves        []string
+	}{
+		{"nested", `{"mod-v1:user":[{"name":"bob","password":"x"}]}`,
+			`{"mod-v1:user":[{"name":"bob","password":"********"}]}`,
+			[]string{"password"}},
+		{"qualified", `{"mod-v1:password":"x","other-v1:password":"y"}`,
+			`{"mod-v1:password":"********","other-v1:password":"y"}`,
+			[]string{"mod-v1:password"}},
+		{"container", `{"keys":{"a":"b"},"name":"bob"}`,
+			`{"keys":"********","name":"bob"}`,
+			[]string{"keys"}},
+		{"numbers", `{"counter":18446744073709551615,"ratio":0.1,"key":1}`,
+			`{"counter":18446744073709551615,"key":"********","ratio":0.1}`,
+			[]string{"key"}},
+		{"trailing", `{"key":1} {"key":2}`, `********`, []string{"key"}},
+		{"unbalanced", `{"key":1}]`, `********`, []string{"key"}},
+		{"not-json", `<config/>`, `********`, []string{"password"}},
+		{"none", `<config/>`, `<config/>`, nil},
+	}
+	for _, test := range tests {
+		got := redactLeaves(test.in, test.leaves)
+		if got != test.exp {
+			t.Errorf("%s: expected %s, got %s", test.desc, test.exp, got)
+		}
+	}
+}
+
+func TestAuditFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	sink, err := NewAuditFileSink(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records := []*AuditRecord{
+		{ModelName: "a", Kind: "config", Method: "set"},
+		{ModelName: "b", Kind: "rpc", ModuleName: "b-v1", Method: "go",
+			Err: errors.New("failed")},
+	}
+	for _, rec := range records {
+		err = sink.Record(rec)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var lines []map[string]interface{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var line map[string]interface{}
+		err = json.Unmarshal(scanner.Bytes(), &line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d", len(lines))
+	}
+	if lines[0]["model"] != "a" || lines[0]["result"] != "ok" {
+		t.Fatalf("unexpected line %v", lines[0])
+	}
+	if lines[1]["module"] != "b-v1" ||
+		lines[1]["result"] != "operation-failed" ||
+		lines[1]["error"] != "failed" {
+		t.Fatalf("unexpected line %v", lines[1])
+	}
+}
+
+func TestAuditNotificationSink(t *testing.T) {
+	resetTestBus()
+	// As RFC7951 requires, the top-level members must be qualified by
+	// the module name.
+	tYangd.validateNotification = func(module, name, encodedData string) error {
+		var members map[string]interface{}
+		err := json.Unmarshal([]byte(encodedData), &members)
+		if err != nil {
+			return err
+		}
+		for member := range members {
+			if !strings.HasPrefix(member, module+":") {
+				return errors.New("unqualified member " + member)
+			}
+		}
+		return nil
+	}
+	defer func() { tYangd.validateNotification = nil }()
+	client, err := Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	ch := make(chan map[string]interface{}, 1)
+	err = client.Subscribe("audit-v1", "operation", ch).Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink := NewAuditNotificationSink(client, "audit-v1", "operation")
+	err = sink.Record(&AuditRecord{
+		ModelName: "a",
+		Kind:      "config",
+		Method:    "set",
+		Caller:    RPCMetadata{User: "bob"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case notif := <-ch:
+		if notif["audit-v1:user"] != "bob" ||
+			notif["audit-v1:method"] != "set" {
+			t.Fatalf("unexpected notification %v", notif)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("notification was not delivered")
+	}
+}
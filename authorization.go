@@ -0,0 +1,63 @@
+// Copyright (c) 2026, AT&T Intellectual Property. All rights reserved.
+//
+// SPDX-License-Identifier: MPL-2.0
+
+package vci
+
+import (
+	"context"
+
+	"github.com/danos/mgmterror"
+	"github.com/danos/vci/conf"
+)
+
+// authorizationInterceptor rejects the invocations that the policy does
+// not allow the caller to make. State is not covered by the policy.
+func authorizationInterceptor(policy *conf.Policy) Interceptor {
+	return func(
+		ctx context.Context,
+		inv *Invocation,
+		invoke Invoker,
+	) (string, error) {
+		if !authorized(policy, inv) {
+			return "", accessDenied(inv)
+		}
+		return invoke(ctx, inv)
+	}
+}
+
+func authorized(policy *conf.Policy, inv *Invocation) bool {
+	user, groups := inv.Metadata.User, inv.Metadata.Groups
+	switch inv.Kind {
+	case "rpc":
+		return policy.AllowsRPC(user, groups, inv.ModuleName, inv.Method)
+	case "config":
+		op := inv.Method
+		if op == "get-path" {
+			op = "get"
+		}
+		return policy.AllowsConfig(user, groups, op)
+	case introspectionType:
+		return policy.AllowsIntrospection(user, groups)
+	}
+	return true
+}
+
+func accessDenied(inv *Invocation) error {
+	user := inv.Metadata.User
+	if user == "" {
+		user = "unknown user"
+	}
+	err := mgmterror.NewAccessDeniedApplicationError()
+	switch inv.Kind {
+	case "rpc":
+		err.Message = user + " may not call " +
+			inv.ModuleName + ":" + inv.Method
+	case introspectionType:
+		err.Message = user + " may not introspect " + inv.ModelName
+	default:
+		err.Message = user + " may not " + inv.Method +
+			" the configuration of " + inv.ModelName
+	}
+	return err
+}
@@ -0,0 +1,104 @@
+// Copyright (c) 2026, AT&T Intellectual Property. All rights reserved.
+//
+// SPDX-License-Identifier: MPL-2.0
+
+package vci
+
+import (
+	"testing"
+
+	"github.com/danos/mgmterror"
+	"github.com/danos/vci/conf"
+)
+
+func TestAuthorizationPolicy(t *testing.T) {
+	const model = "com.vyatta.test.foo.v1"
+	policy, err := conf.ParsePolicy([]byte(
+		"[Policy admins]\n" +
+			"Groups=admin\n" +
+			"Modules=foo-v1\n" +
+			"\n" +
+			"[Policy operators]\n" +
+			"Users=bob\n" +
+			"RPCs=foo-v1:call-me\n" +
+			"\n" +
+			"[Policy anyone]\n" +
+			"Users=*\n" +
+			"Config=get\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resetTestBus()
+	comp := NewComponentWithOptions("com.vyatta.test.foo",
+		WithTransport(newTestTransport()),
+		WithAuthorizationPolicy(policy))
+	comp.Model(model).
+		Config(&testRunningConfigWithValue{testConfig{Value: "foo"}}).
+		State(&testState{Value: "bar"}).
+		RPC("foo-v1", &testRPCs{})
+	err = comp.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	call := func(meta RPCMetadata) error {
+		var out testConfig
+		return client.CallWithMetadata("foo-v1", "call-me", meta,
+			&testConfig{Value: "foo"}).StoreOutputInto(&out)
+	}
+	checkDenied := func(t *testing.T, err error, msg string) {
+		denied, ok := err.(*mgmterror.AccessDeniedApplicationError)
+		if !ok {
+			t.Fatalf("expected access denied, got %v", err)
+		}
+		if denied.Message != msg {
+			t.Fatalf("expected %q, got %q", msg, denied.Message)
+		}
+	}
+
+	t.Run("rpc-by-user", func(t *testing.T) {
+		err := call(RPCMetadata{User: "bob"})
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+	t.Run("rpc-by-group", func(t *testing.T) {
+		err := call(RPCMetadata{User: "alice", Groups: []string{"admin"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+	t.Run("rpc-denied", func(t *testing.T) {
+		err := call(RPCMetadata{User: "carol", Groups: []string{"users"}})
+		checkDenied(t, err, "carol may not call foo-v1:call-me")
+	})
+	t.Run("config-get", func(t *testing.T) {
+		var cfg testConfig
+		err := client.StoreConfigByModelInto(model, &cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+	t.Run("config-set-denied", func(t *testing.T) {
+		err := client.SetConfigForModel(model, &testConfig{Value: "baz"})
+		checkDenied(t, err,
+			"unknown user may not set the configuration of "+model)
+	})
+	t.Run("state", func(t *testing.T) {
+		var state testState
+		err := client.StoreStateByModelInto(model, &state)
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+	t.Run("introspection-denied", func(t *testing.T) {
+		_, err := client.Introspect("com.vyatta.test.foo")
+		checkDenied(t, err,
+			"unknown user may not introspect com.vyatta.test.foo")
+	})
+}
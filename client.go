@@ -7,20 +7,46 @@
 package vci
 
 import (
+	"context"
 	"errors"
 	"reflect"
+	"strings"
 
 	"github.com/danos/mgmterror"
 )
 
 var errNotImplemented = errors.New("not implemented")
 
+// ErrDisconnected is reported in the first ReconnectEvent after the
+// connection to the bus was lost.
+var ErrDisconnected = errors.New("disconnected from the VCI bus")
+
+// A ReconnectEvent describes the progress of re-establishing a lost
+// connection to the bus. An event with an Attempt of 0 reports the loss
+// of the connection; each subsequent event reports the outcome of a
+// numbered attempt to reconnect. A nil Err means the connection has been
+// restored, along with the identities, objects and subscriptions that
+// were active on the old connection.
+type ReconnectEvent struct {
+	Attempt int
+	Err     error
+}
+
+// reconnecter is implemented by transports that can re-establish a lost
+// connection to the bus.
+type reconnecter interface {
+	OnReconnect(fn func(ReconnectEvent))
+}
+
 // The Client supplies an encapsulated mechanism for
 // performing operations on the VCI bus.
 type Client struct {
-	transport  transporter
-	marshaller marshaller
-	err        error
+	transport    Transport
+	marshaller   Marshaller
+	interceptors []Interceptor
+	metrics      *Metrics
+	tracer       *tracer
+	err          error
 }
 
 // Dial is the constructor for the default VCI client.
@@ -33,6 +59,20 @@ func Dial() (*Client, error) {
 	return c, c.checkConnection()
 }
 
+// DialWithOptions is the same as Dial but the supplied options control
+// how the client attaches to the bus.
+func DialWithOptions(opts ...Option) (*Client, error) {
+	o := newOptions(opts)
+	c := newClient().
+		withTransport(o.transport()).
+		withMarshaller(o.marshaller).
+		withInterceptors(o.callInterceptors).
+		withMetrics(o.metrics).
+		withTracer(o.tracer).
+		dial()
+	return c, c.checkConnection()
+}
+
 // NewClient will create a VCI client that is not connected to a Bus.
 func newClient() *Client {
 	return &Client{
@@ -43,11 +83,37 @@ func newClient() *Client {
 // withTransport provides a mechanism for the client to attach to the Bus,
 // this allows a transport to be shared between a component and a client.
 // it is only used internally.
-func (c *Client) withTransport(t transporter) *Client {
+func (c *Client) withTransport(t Transport) *Client {
 	c.transport = t
 	return c
 }
 
+// withMarshaller replaces the marshaller used to encode and decode
+// objects.
+func (c *Client) withMarshaller(m Marshaller) *Client {
+	c.marshaller = m
+	return c
+}
+
+// withInterceptors sets the interceptors that RPC calls pass through.
+func (c *Client) withInterceptors(interceptors []Interceptor) *Client {
+	c.interceptors = interceptors
+	return c
+}
+
+// withMetrics sets the metrics that subscriptions are reported to.
+func (c *Client) withMetrics(m *Metrics) *Client {
+	c.metrics = m
+	return c
+}
+
+// withTracer sets the tracer that records spans for configuration calls.
+// RPCs are traced by the client interceptors.
+func (c *Client) withTracer(t *tracer) *Client {
+	c.tracer = t
+	return c
+}
+
 // dial establishes a connection to the bus.
 func (c *Client) dial() *Client {
 	if c.transport == nil {
@@ -69,12 +135,35 @@ func (c *Client) Close() error {
 	return c.transport.Close()
 }
 
+// OnReconnect registers fn to be called as the client attempts to
+// re-establish a lost connection to the bus, for instance so that the
+// application can log the events. Only one function may be registered,
+// a later call replaces the earlier one. When the client is shared with
+// a component the reconnection also covers the component.
+func (c *Client) OnReconnect(fn func(ReconnectEvent)) {
+	if r, ok := c.transport.(reconnecter); ok {
+		r.OnReconnect(fn)
+	}
+}
+
 // Call will initiate a call to an RPC specified by the
 // YANG module name and the RPC name. This will return
 // a promise that can be fulfilled when the result is
 // needed. The input object is marshalled using the RFC7951 encoder.
 func (c *Client) Call(moduleName, rpcName string, input interface{}) *RPCCall {
-	return c.CallWithMetadata(moduleName, rpcName, RPCMetadata{}, input)
+	return c.CallContext(context.Background(), moduleName, rpcName, input)
+}
+
+// CallContext is the same as Call but the supplied context bounds the
+// lifetime of the call. If the context expires before the call completes
+// the context's error is returned from the RPCCall.
+func (c *Client) CallContext(
+	ctx context.Context,
+	moduleName, rpcName string,
+	input interface{},
+) *RPCCall {
+	return c.CallWithMetadataContext(ctx, moduleName, rpcName,
+		RPCMetadata{}, input)
 }
 
 // CallWithMetadata will initiate a call to an RPC specified by the YANG module
@@ -85,15 +174,38 @@ func (c *Client) Call(moduleName, rpcName string, input interface{}) *RPCCall {
 func (c *Client) CallWithMetadata(
 	moduleName, rpcName string, metadata RPCMetadata, input interface{},
 ) *RPCCall {
-	encodedMetadata, err := c.marshalObject(metadata)
+	return c.CallWithMetadataContext(context.Background(),
+		moduleName, rpcName, metadata, input)
+}
+
+// CallWithMetadataContext is the same as CallWithMetadata but the supplied
+// context bounds the lifetime of the call. If the context expires before
+// the call completes the context's error (context.DeadlineExceeded or
+// context.Canceled) is returned from the RPCCall.
+func (c *Client) CallWithMetadataContext(
+	ctx context.Context,
+	moduleName, rpcName string, metadata RPCMetadata, input interface{},
+) *RPCCall {
+	metadata = withTraceContext(ctx, metadata)
+	encodedMetadata, err := encodeMetadata(metadata)
 	if err != nil {
-		return &RPCCall{err: err}
+		return &RPCCall{err: mgmterror.NewMalformedMessageError()}
 	}
 	encodedData, err := c.marshalObject(input)
 	if err != nil {
 		return &RPCCall{err: err}
 	}
-	promise, err := c.transport.Call(
+	if len(c.interceptors) != 0 {
+		inv := &Invocation{
+			Kind:       "rpc",
+			ModuleName: moduleName,
+			Method:     rpcName,
+			Metadata:   metadata,
+			Input:      encodedData,
+		}
+		return &RPCCall{client: c, promise: c.interceptCall(ctx, inv)}
+	}
+	promise, err := c.transport.Call(ctx,
 		moduleName, rpcName, encodedMetadata, encodedData)
 	if err != nil {
 		return &RPCCall{err: err}
@@ -115,31 +227,88 @@ func (c *Client) Subscribe(
 	moduleName, notificationName string,
 	subscriber interface{},
 ) *Subscription {
-	wrapped, inputType, err := func(
-		subscriber interface{},
-	) (func(interface{}), reflect.Type, error) {
-		val := reflect.ValueOf(subscriber)
-		switch val.Kind() {
-		case reflect.Func:
-			if val.Type().NumIn() != 1 {
-				return nil, nil,
-					errors.New("Invalid subscriber type")
-			}
-			inputType := val.Type().In(0)
-			return func(value interface{}) {
+	wrapped, inputType, err := wrapSubscriber(subscriber, false)
+	return newSubscription(c, moduleName, notificationName,
+		wrapped, inputType, err)
+}
+
+// SubscribeModule will allow one to subscribe to all of the
+// notifications of a YANG module. The subscriber is as for Subscribe,
+// except that a function subscriber may also take the name of the
+// notification before its value. Transports derive the name from how
+// the notification is sent, so it is only reliable for the usual
+// lower case YANG names.
+func (c *Client) SubscribeModule(
+	moduleName string,
+	subscriber interface{},
+) *Subscription {
+	wrapped, inputType, err := wrapSubscriber(subscriber, true)
+	s := newSubscription(c, moduleName, "",
+		wrapped, inputType, err)
+	s.watchesModule = true
+	return s
+}
+
+// WatchState will allow one to receive the changes to the
+// operational state of a model as they are published by the
+// model's component with Model.StateChanged. Each change carries
+// the full state found at its path when it was published, not a
+// delta from the previous state. This takes a subscriber which
+// may be one of:
+//     (1) a function that takes any type as its input.
+//         The changed state will be unmarshalled into the type
+//         using the RFC7951 decoder.
+//     (2) a function that takes the instance-identifier path of
+//         the change, empty for the whole tree, and any type for
+//         the changed state.
+//     (3) a send channel of any type. The changed state will be
+//         unmarshalled into the type by the RFC7951 decoder.
+// The returned Subscription must be Run, and its queueing policies
+// apply to state changes as they do to notifications.
+func (c *Client) WatchState(
+	modelName string,
+	subscriber interface{},
+) *Subscription {
+	wrapped, inputType, err := wrapSubscriber(subscriber, true)
+	s := newSubscription(c, modelName, stateChangeName,
+		wrapped, inputType, err)
+	s.watchesState = true
+	return s
+}
+
+// wrapSubscriber converts a subscriber function or channel into a
+// function called for each value received, returning the type that
+// received values are to be decoded into. A function subscriber may
+// also take the path of the value if withPath is set.
+func wrapSubscriber(
+	subscriber interface{},
+	withPath bool,
+) (func(string, interface{}), reflect.Type, error) {
+	val := reflect.ValueOf(subscriber)
+	switch val.Kind() {
+	case reflect.Func:
+		typ := val.Type()
+		switch {
+		case typ.NumIn() == 1:
+			return func(_ string, value interface{}) {
+				val.Call([]reflect.Value{
+					reflect.ValueOf(value)})
+			}, typ.In(0), nil
+		case typ.NumIn() == 2 && withPath &&
+			typ.In(0) == reflectStringType:
+			return func(path string, value interface{}) {
 				val.Call([]reflect.Value{
+					reflect.ValueOf(path),
 					reflect.ValueOf(value)})
-			}, inputType, nil
-		case reflect.Chan:
-			inputType := val.Type().Elem()
-			return func(value interface{}) {
-				val.Send(reflect.ValueOf(value))
-			}, inputType, nil
+			}, typ.In(1), nil
 		}
-		return nil, nil, errors.New("Invalid subscriber type")
-	}(subscriber)
-	return newSubscription(c, moduleName, notificationName,
-		wrapped, inputType, err)
+	case reflect.Chan:
+		inputType := val.Type().Elem()
+		return func(_ string, value interface{}) {
+			val.Send(reflect.ValueOf(value))
+		}, inputType, nil
+	}
+	return nil, nil, errors.New("Invalid subscriber type")
 }
 
 // Emit will allow one to send to a notification
@@ -150,12 +319,23 @@ func (c *Client) Subscribe(
 func (c *Client) Emit(
 	moduleName, notificationName string,
 	object interface{},
+) error {
+	return c.EmitContext(context.Background(),
+		moduleName, notificationName, object)
+}
+
+// EmitContext is the same as Emit but the notification is not sent if
+// the supplied context has already expired.
+func (c *Client) EmitContext(
+	ctx context.Context,
+	moduleName, notificationName string,
+	object interface{},
 ) error {
 	encodedData, err := c.marshalObject(object)
 	if err != nil {
 		return err
 	}
-	return c.transport.Emit(moduleName, notificationName, encodedData)
+	return c.transport.Emit(ctx, moduleName, notificationName, encodedData)
 }
 
 // SetConfigForModel will set the configuration for the given model, using
@@ -164,12 +344,30 @@ func (c *Client) Emit(
 func (c *Client) SetConfigForModel(
 	modelName string,
 	object interface{},
+) error {
+	return c.SetConfigForModelContext(context.Background(),
+		modelName, object)
+}
+
+// SetConfigForModelContext is the same as SetConfigForModel but the
+// supplied context bounds the lifetime of the call.
+// The trace context carried by ctx is passed on to the component.
+func (c *Client) SetConfigForModelContext(
+	ctx context.Context,
+	modelName string,
+	object interface{},
 ) error {
 	encodedData, err := c.marshalObject(object)
 	if err != nil {
 		return err
 	}
-	return c.transport.SetConfigForModel(modelName, encodedData)
+	return c.timeCall("config", modelName, "set", func() error {
+		return c.traceConfig(ctx, modelName, "set",
+			func(ctx context.Context) error {
+				return c.transport.SetConfigForModel(ctx, modelName,
+					encodedData)
+			})
+	})
 }
 
 // CheckConfigForModel will validate the configuration for the given model,
@@ -178,12 +376,30 @@ func (c *Client) SetConfigForModel(
 func (c *Client) CheckConfigForModel(
 	modelName string,
 	object interface{},
+) error {
+	return c.CheckConfigForModelContext(context.Background(),
+		modelName, object)
+}
+
+// CheckConfigForModelContext is the same as CheckConfigForModel but the
+// supplied context bounds the lifetime of the call.
+// The trace context carried by ctx is passed on to the component.
+func (c *Client) CheckConfigForModelContext(
+	ctx context.Context,
+	modelName string,
+	object interface{},
 ) error {
 	encodedData, err := c.marshalObject(object)
 	if err != nil {
 		return err
 	}
-	return c.transport.CheckConfigForModel(modelName, encodedData)
+	return c.timeCall("config", modelName, "check", func() error {
+		return c.traceConfig(ctx, modelName, "check",
+			func(ctx context.Context) error {
+				return c.transport.CheckConfigForModel(ctx, modelName,
+					encodedData)
+			})
+	})
 }
 
 // StoreConfigByModelInto will retrieve the configuration
@@ -192,9 +408,23 @@ func (c *Client) CheckConfigForModel(
 func (c *Client) StoreConfigByModelInto(
 	modelName string,
 	object interface{},
+) error {
+	return c.StoreConfigByModelIntoContext(context.Background(),
+		modelName, object)
+}
+
+// StoreConfigByModelIntoContext is the same as StoreConfigByModelInto but
+// the supplied context bounds the lifetime of the call.
+func (c *Client) StoreConfigByModelIntoContext(
+	ctx context.Context,
+	modelName string,
+	object interface{},
 ) error {
 	var encodedData string
-	err := c.transport.StoreConfigByModelInto(modelName, &encodedData)
+	err := c.timeCall("config", modelName, "get", func() error {
+		return c.transport.StoreConfigByModelInto(ctx, modelName,
+			&encodedData)
+	})
 	if err != nil {
 		return err
 	}
@@ -208,14 +438,138 @@ func (c *Client) StoreStateByModelInto(
 	modelName string,
 	object interface{},
 ) error {
+	return c.StoreStateByModelIntoContext(context.Background(),
+		modelName, object)
+}
+
+// StoreStateByModelIntoContext is the same as StoreStateByModelInto but
+// the supplied context bounds the lifetime of the call.
+func (c *Client) StoreStateByModelIntoContext(
+	ctx context.Context,
+	modelName string,
+	object interface{},
+) error {
+	var encodedData string
+	err := c.timeCall("state", modelName, "get", func() error {
+		return c.transport.StoreStateByModelInto(ctx, modelName,
+			&encodedData)
+	})
+	if err != nil {
+		return err
+	}
+	return c.unmarshalObject(encodedData, object)
+}
+
+// pathReader is implemented by transports that can read the
+// configuration and state of a model at a path.
+type pathReader interface {
+	// StoreConfigByPathInto will cause the configuration at the given
+	// instance-identifier path for a model to be queried and stored
+	// into the passed in pointer. Components whose configuration
+	// object does not accept a path return an OperationNotSupported
+	// error.
+	StoreConfigByPathInto(ctx context.Context,
+		modelName, path string, encodedData *string) error
+	// StoreStateByPathInto will cause the operational data at the
+	// given instance-identifier path for a model to be queried and
+	// stored into the passed in pointer. Components whose state object
+	// does not accept a path return an OperationNotSupported error.
+	StoreStateByPathInto(ctx context.Context,
+		modelName, path string, encodedData *string) error
+}
+
+// StoreConfigByPathInto will retrieve the configuration at the
+// supplied path for a model and unmarshal it into the supplied object
+// using the RFC7951 decoder. The path is an RFC7951 instance-identifier,
+// such as "/example-v1:interfaces/interface[name='dp0s1']". The model's
+// configuration object must accept a path in its Get method, and the
+// transport must support reading by path, otherwise an
+// OperationNotSupported error is returned.
+func (c *Client) StoreConfigByPathInto(
+	modelName, path string,
+	object interface{},
+) error {
+	return c.StoreConfigByPathIntoContext(context.Background(),
+		modelName, path, object)
+}
+
+// StoreConfigByPathIntoContext is the same as StoreConfigByPathInto but
+// the supplied context bounds the lifetime of the call.
+func (c *Client) StoreConfigByPathIntoContext(
+	ctx context.Context,
+	modelName, path string,
+	object interface{},
+) error {
+	if err := checkInstancePath(path); err != nil {
+		return err
+	}
+	reader, ok := c.transport.(pathReader)
+	if !ok {
+		return mgmterror.NewOperationNotSupportedApplicationError()
+	}
+	var encodedData string
+	err := c.timeCall("config", modelName, "get-path", func() error {
+		return reader.StoreConfigByPathInto(ctx, modelName, path,
+			&encodedData)
+	})
+	if err != nil {
+		return err
+	}
+	return c.unmarshalObject(encodedData, object)
+}
+
+// StoreStateByPathInto will retrieve the operational state at the
+// supplied path for a model and unmarshal it into the supplied object
+// using the RFC7951 decoder. The path is an RFC7951 instance-identifier,
+// such as "/example-v1:routes/route[prefix='10.0.0.0/8']". The model's
+// state object must accept a path in its Get method, and the transport
+// must support reading by path, otherwise an OperationNotSupported error
+// is returned.
+func (c *Client) StoreStateByPathInto(
+	modelName, path string,
+	object interface{},
+) error {
+	return c.StoreStateByPathIntoContext(context.Background(),
+		modelName, path, object)
+}
+
+// StoreStateByPathIntoContext is the same as StoreStateByPathInto but
+// the supplied context bounds the lifetime of the call.
+func (c *Client) StoreStateByPathIntoContext(
+	ctx context.Context,
+	modelName, path string,
+	object interface{},
+) error {
+	if err := checkInstancePath(path); err != nil {
+		return err
+	}
+	reader, ok := c.transport.(pathReader)
+	if !ok {
+		return mgmterror.NewOperationNotSupportedApplicationError()
+	}
 	var encodedData string
-	err := c.transport.StoreStateByModelInto(modelName, &encodedData)
+	err := c.timeCall("state", modelName, "get-path", func() error {
+		return reader.StoreStateByPathInto(ctx, modelName, path,
+			&encodedData)
+	})
 	if err != nil {
 		return err
 	}
 	return c.unmarshalObject(encodedData, object)
 }
 
+// checkInstancePath rejects paths that cannot be instance-identifiers.
+// The empty path refers to the whole tree. Anything further is left to
+// the component, which knows its schema.
+func checkInstancePath(path string) error {
+	if path == "" || strings.HasPrefix(path, "/") {
+		return nil
+	}
+	err := mgmterror.NewInvalidValueApplicationError()
+	err.Message = "path must be an instance-identifier starting with '/'"
+	return err
+}
+
 func (c *Client) marshalObject(object interface{}) (string, error) {
 	if s, ok := object.(string); ok {
 		return s, nil
@@ -245,18 +599,28 @@ func (c *Client) unmarshalObject(encodedData string, object interface{}) error {
 type RPCCall struct {
 	client  *Client
 	err     error
-	promise transportRPCPromise
+	promise TransportRPCPromise
 }
 
 // StoreOutputInto will unmarshal the output tree
 // of the RPC into the supplied object using the RFC7951 decoder
 // or an error if an error occurred during the call.
 func (c *RPCCall) StoreOutputInto(object interface{}) error {
+	return c.StoreOutputIntoContext(context.Background(), object)
+}
+
+// StoreOutputIntoContext is the same as StoreOutputInto but gives up
+// waiting for the result when the supplied context expires, returning
+// the context's error.
+func (c *RPCCall) StoreOutputIntoContext(
+	ctx context.Context,
+	object interface{},
+) error {
 	if c.err != nil {
 		return c.err
 	}
 	var encodedData string
-	err := c.promise.StoreOutputInto(&encodedData)
+	err := c.promise.StoreOutputInto(ctx, &encodedData)
 	if err != nil {
 		return err
 	}
@@ -267,9 +631,25 @@ func (c *RPCCall) StoreOutputInto(object interface{}) error {
 // Since the VCI client library is to be used only from trusted sources
 // to make calls we can provide components with some additional trusted context
 // such as the user making the call.
+//
+// On the VCI bus the metadata a component receives is checked against
+// the credentials of the caller's connection. Only a caller running as
+// root, such as configd acting for a user, may supply metadata for
+// another user; metadata from any other caller is replaced with its
+// own credentials. When root names a User, the User, Uid and Groups it
+// supplies are passed on as given, and so are what authorization
+// policies and audit records see: processes running as root are
+// trusted to describe the user they act for. The Pid is always that of
+// the caller.
+//
+// Traceparent and Tracestate carry the W3C trace-context of the call, if
+// it is part of a trace. They are filled in from the span context of the
+// context the call is made with, unless already set.
 type RPCMetadata struct {
-	Pid    int32
-	Uid    uint32
-	User   string
-	Groups []string
+	Pid         int32
+	Uid         uint32
+	User        string
+	Groups      []string
+	Traceparent string
+	Tracestate  string
 }
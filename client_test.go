@@ -6,8 +6,12 @@
 package vci
 
 import (
+	"context"
+	"errors"
 	"testing"
 	"time"
+
+	"github.com/danos/mgmterror"
 )
 
 func TestClientDial(t *testing.T) {
@@ -455,3 +459,356 @@ func TestClientStoreStateByModelInto(t *testing.T) {
 		}
 	})
 }
+
+type testPathState struct {
+	routes map[string]*testState
+}
+
+func (s *testPathState) Get(path string) (interface{}, error) {
+	if path == "" {
+		return s.routes, nil
+	}
+	route, ok := s.routes[path]
+	if !ok {
+		return nil, errors.New("unknown path " + path)
+	}
+	return route, nil
+}
+
+func TestClientStoreByPathInto(t *testing.T) {
+	const (
+		model = "com.vyatta.test.foo.v1"
+		path  = "/foo-v1:routes/route[prefix='10.0.0.0/8']"
+	)
+	setup := func(t *testing.T) *Client {
+		resetTestBus()
+		comp := NewComponent("com.vyatta.test.foo")
+		comp.Model(model).
+			Config(&testRunningConfigWithValue{testConfig{Value: "cfg"}}).
+			State(&testPathState{routes: map[string]*testState{
+				path: {Value: "foo bar"},
+			}})
+		err := comp.Run()
+		if err != nil {
+			t.Fatal(err)
+		}
+		client, err := Dial()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return client
+	}
+	t.Run("path", func(t *testing.T) {
+		client := setup(t)
+		var out testState
+		err := client.StoreStateByPathInto(model, path, &out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out.Value != "foo bar" {
+			t.Fatalf("unexpected state returned %v", out)
+		}
+	})
+	t.Run("whole-tree", func(t *testing.T) {
+		client := setup(t)
+		var out map[string]testState
+		err := client.StoreStateByModelInto(model, &out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out[path].Value != "foo bar" {
+			t.Fatalf("unexpected state returned %v", out)
+		}
+		out = nil
+		err = client.StoreStateByPathInto(model, "", &out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out[path].Value != "foo bar" {
+			t.Fatalf("unexpected state returned %v", out)
+		}
+	})
+	t.Run("unknown-path", func(t *testing.T) {
+		client := setup(t)
+		var out testState
+		err := client.StoreStateByPathInto(model, "/foo-v1:bar", &out)
+		if err == nil {
+			t.Fatal("expected error did not occur")
+		}
+	})
+	t.Run("invalid-path", func(t *testing.T) {
+		client := setup(t)
+		var out testState
+		err := client.StoreStateByPathInto(model, "foo-v1:bar", &out)
+		if _, ok := err.(*mgmterror.InvalidValueApplicationError); !ok {
+			t.Fatalf("unexpected error %v", err)
+		}
+	})
+	t.Run("path-not-supported", func(t *testing.T) {
+		client := setup(t)
+		var out testConfig
+		err := client.StoreConfigByPathInto(model, path, &out)
+		_, ok := err.(*mgmterror.OperationNotSupportedApplicationError)
+		if !ok {
+			t.Fatalf("unexpected error %v", err)
+		}
+	})
+	t.Run("transport-not-supported", func(t *testing.T) {
+		setup(t)
+		// Embedding the interface hides the path methods.
+		transport := struct{ Transport }{newTestTransport()}
+		client, err := DialWithOptions(WithTransport(transport))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer client.Close()
+		var out testState
+		err = client.StoreStateByPathInto(model, path, &out)
+		_, ok := err.(*mgmterror.OperationNotSupportedApplicationError)
+		if !ok {
+			t.Fatalf("unexpected error %v", err)
+		}
+	})
+}
+
+func TestClientWatchState(t *testing.T) {
+	const (
+		model = "com.vyatta.test.foo.v1"
+		path  = "/foo-v1:routes/route[prefix='10.0.0.0/8']"
+	)
+	type change struct {
+		path  string
+		state *testState
+	}
+	setup := func(t *testing.T, state interface{}) (Model, *Client) {
+		resetTestBus()
+		comp := NewComponent("com.vyatta.test.foo")
+		m := comp.Model(model)
+		if state != nil {
+			m.State(state)
+		}
+		err := comp.Run()
+		if err != nil {
+			t.Fatal(err)
+		}
+		client, err := Dial()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return m, client
+	}
+	t.Run("path", func(t *testing.T) {
+		m, client := setup(t, &testPathState{routes: map[string]*testState{
+			path: {Value: "foo bar"},
+		}})
+		changes := make(chan change, 1)
+		sub := client.WatchState(model, func(path string, s *testState) {
+			changes <- change{path: path, state: s}
+		})
+		err := sub.Run()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer sub.Cancel()
+		err = m.StateChanged(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case got := <-changes:
+			if got.path != path || got.state.Value != "foo bar" {
+				t.Fatalf("unexpected change %v", got)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("didn't receive state change")
+		}
+	})
+	t.Run("whole-tree-channel", func(t *testing.T) {
+		m, client := setup(t, &testState{Value: "foo bar"})
+		ch := make(chan *testState, 1)
+		sub := client.WatchState(model, ch).Coalesce()
+		err := sub.Run()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer sub.Cancel()
+		err = m.StateChanged("")
+		if err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case got := <-ch:
+			if got.Value != "foo bar" {
+				t.Fatalf("unexpected state %v", got)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("didn't receive state change")
+		}
+		if sub.Stats().Delivered != 1 {
+			t.Fatalf("unexpected stats %v", sub.Stats())
+		}
+	})
+	t.Run("path-not-supported", func(t *testing.T) {
+		m, _ := setup(t, &testState{Value: "foo bar"})
+		err := m.StateChanged(path)
+		if err == nil {
+			t.Fatal("expected error did not occur")
+		}
+	})
+	t.Run("no-state", func(t *testing.T) {
+		m, _ := setup(t, nil)
+		err := m.StateChanged("")
+		if err == nil {
+			t.Fatal("expected error did not occur")
+		}
+	})
+	t.Run("invalid-subscriber", func(t *testing.T) {
+		_, client := setup(t, nil)
+		err := client.WatchState(model, func(int, *testState) {}).Run()
+		if err == nil {
+			t.Fatal("expected error did not occur")
+		}
+	})
+	t.Run("transport-not-supported", func(t *testing.T) {
+		setup(t, &testState{Value: "foo bar"})
+		// Embedding the interface hides the state change methods.
+		transport := struct{ Transport }{newTestTransport()}
+		client, err := DialWithOptions(WithTransport(transport))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer client.Close()
+		err = client.WatchState(model, func(*testState) {}).Run()
+		_, ok := err.(*mgmterror.OperationNotSupportedApplicationError)
+		if !ok {
+			t.Fatalf("unexpected error %v", err)
+		}
+	})
+	t.Run("undecodable", func(t *testing.T) {
+		_, client := setup(t, nil)
+		errs := make(chan *NotificationError, 1)
+		sub := client.WatchState(model, func(*testState) {}).
+			OnError(func(err *NotificationError) { errs <- err })
+		err := sub.Run()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer sub.Cancel()
+		err = client.transport.(stateChangeNotifier).EmitStateChange(
+			context.Background(), model, "", "not json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case err := <-errs:
+			if err.Kind != NotificationUndecodable ||
+				err.ModuleName != model {
+				t.Fatalf("unexpected error %v", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("didn't receive error")
+		}
+	})
+}
+
+type testBlockingHandlers struct {
+	release chan struct{}
+}
+
+func (h *testBlockingHandlers) Get() *testConfig {
+	<-h.release
+	return &testConfig{}
+}
+
+func (h *testBlockingHandlers) Set(config *testConfig) error {
+	<-h.release
+	return nil
+}
+
+func (h *testBlockingHandlers) Check(config *testConfig) error {
+	<-h.release
+	return nil
+}
+
+func (h *testBlockingHandlers) Hang(in *testConfig) (*testConfig, error) {
+	<-h.release
+	return in, nil
+}
+
+func TestClientContext(t *testing.T) {
+	resetTestBus()
+	hdlrs := &testBlockingHandlers{release: make(chan struct{})}
+	defer close(hdlrs.release)
+	comp := NewComponent("com.vyatta.test.foo")
+	comp.Model("com.vyatta.test.foo.v1").
+		Config(hdlrs).
+		State(hdlrs).
+		RPC("foo-v1", map[string]interface{}{
+			"hang": hdlrs.Hang,
+		})
+	err := comp.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const model = "com.vyatta.test.foo.v1"
+	calls := map[string]func(ctx context.Context) error{
+		"call": func(ctx context.Context) error {
+			var out map[string]interface{}
+			return client.CallContext(ctx, "foo-v1", "hang",
+				map[string]interface{}{}).StoreOutputInto(&out)
+		},
+		"set": func(ctx context.Context) error {
+			return client.SetConfigForModelContext(ctx, model,
+				testConfig{Value: "foo"})
+		},
+		"check": func(ctx context.Context) error {
+			return client.CheckConfigForModelContext(ctx, model,
+				testConfig{Value: "foo"})
+		},
+		"config": func(ctx context.Context) error {
+			var out map[string]interface{}
+			return client.StoreConfigByModelIntoContext(ctx, model, &out)
+		},
+		"state": func(ctx context.Context) error {
+			var out map[string]interface{}
+			return client.StoreStateByModelIntoContext(ctx, model, &out)
+		},
+	}
+	for name, call := range calls {
+		call := call
+		t.Run(name+"-deadline", func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(),
+				10*time.Millisecond)
+			defer cancel()
+			err := call(ctx)
+			if err != context.DeadlineExceeded {
+				t.Fatalf("expected %v, got %v",
+					context.DeadlineExceeded, err)
+			}
+		})
+		t.Run(name+"-canceled", func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			err := call(ctx)
+			if err != context.Canceled {
+				t.Fatalf("expected %v, got %v",
+					context.Canceled, err)
+			}
+		})
+	}
+	t.Run("emit-canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		err := client.EmitContext(ctx, "foo-v1", "bar",
+			map[string]interface{}{"baz": "quux"})
+		if err != context.Canceled {
+			t.Fatalf("expected %v, got %v", context.Canceled, err)
+		}
+	})
+}
@@ -7,25 +7,40 @@
 package vci
 
 import (
+	"context"
+	"errors"
+	"log"
+	"os"
 	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/danos/mgmterror"
 )
 
 type model struct {
+	// stats is first so it is 64-bit aligned for atomic access.
+	stats handlerStats
+
 	name      string
 	component *component
-	transport transporter
+	transport Transport
 	client    *Client
 
 	cfg   *config
 	state *state
 	rpcs  map[string]*rpcObject
+
+	serializeConfig bool
+	rpcWorkers      int
+	configFile      *configFile
 }
 
 func newModel(name string, c *component) *model {
 	return newModelWithTransport(name, c, defaultTransport())
 }
 
-func newModelWithTransport(name string, c *component, transport transporter) *model {
+func newModelWithTransport(name string, c *component, transport Transport) *model {
 	m := &model{
 		name:      name,
 		component: c,
@@ -41,20 +56,86 @@ func newModelWithTransport(name string, c *component, transport transporter) *mo
 
 func (m *model) Config(cfg interface{}) Model {
 	m.cfg = newConfig(cfg, m.client)
+	m.attach(&m.cfg.wrapperObject)
 	return m
 }
 
 func (m *model) State(state interface{}) Model {
 	m.state = newState(state, m.client)
+	m.attach(&m.state.wrapperObject)
 	return m
 }
 
 func (m *model) RPC(moduleName string, rpc interface{}) Model {
 	m.rpcs[moduleName] = newRPC(moduleName, rpc, m.client)
+	m.attach(&m.rpcs[moduleName].wrapperObject)
 	return m
 }
 
-func (m *model) withTransport(t transporter) *model {
+// attach connects the wrapper of one of the model's handlers to the
+// model's component, which keeps track of the calls to its handlers.
+func (m *model) attach(o *wrapperObject) {
+	o.onPanic = m.handlerPanicked
+	o.stats = &m.stats
+	if m.component != nil {
+		o.calls = &m.component.calls
+	}
+}
+
+func (m *model) ConfigFile(path string) Model {
+	m.configFile = newConfigFile(path)
+	return m
+}
+
+func (m *model) SerializeConfig() Model {
+	m.serializeConfig = true
+	return m
+}
+
+func (m *model) RPCWorkers(workers int) Model {
+	m.rpcWorkers = workers
+	return m
+}
+
+// limitHandlers applies the model's execution modes to its handlers.
+// The RPC handlers of all of the model's modules share the workers.
+func (m *model) limitHandlers() {
+	if m.cfg != nil && m.serializeConfig {
+		m.cfg.limit = make(chan struct{}, 1)
+	}
+	if m.rpcWorkers > 0 {
+		limit := make(chan struct{}, m.rpcWorkers)
+		for _, rpc := range m.rpcs {
+			rpc.limit = limit
+		}
+	}
+}
+
+// handlerPanicked is told of each panic recovered from one of the
+// model's handlers.
+func (m *model) handlerPanicked() {
+	if m.component != nil {
+		m.component.handlerPanicked()
+	}
+}
+
+func (m *model) StateChanged(path string) error {
+	if m.state == nil {
+		return errors.New("model " + m.name + " has no state")
+	}
+	encodedState, err := m.state.get(path)
+	if err != nil {
+		return err
+	}
+	notifier, ok := m.transport.(stateChangeNotifier)
+	if !ok {
+		return mgmterror.NewOperationNotSupportedApplicationError()
+	}
+	return notifier.EmitStateChange(context.Background(),
+		m.name, path, encodedState)
+}
+
+func (m *model) withTransport(t Transport) *model {
 	m.transport = t
 	if m.client != nil {
 		m.client.withTransport(t)
@@ -76,20 +157,21 @@ func (m *model) run() error {
 }
 
 func (m *model) register() error {
+	m.limitHandlers()
 	if m.cfg != nil {
-		err := m.transport.Export(m.cfg)
+		err := m.export(m.cfg)
 		if err != nil {
 			return err
 		}
 	}
 	if m.state != nil {
-		err := m.transport.Export(m.state)
+		err := m.export(m.state)
 		if err != nil {
 			return err
 		}
 	}
 	for _, rpc := range m.rpcs {
-		err := m.transport.Export(rpc)
+		err := m.export(rpc)
 		if err != nil {
 			return err
 		}
@@ -97,18 +179,71 @@ func (m *model) register() error {
 	return nil
 }
 
+// export exposes the object on the transport, passing calls to it
+// through the component's interceptors if it has any, and then through
+// the model's own. Objects with handlers that take a context are also
+// wrapped so that the handlers are given the caller's trace context.
+func (m *model) export(object TransportObject) error {
+	var interceptors []Interceptor
+	if m.component != nil {
+		interceptors = m.component.interceptors
+	}
+	if m.configFile != nil && object.Type() == "config" {
+		// Copy so as not to add to the component's interceptors.
+		interceptors = append(append([]Interceptor(nil), interceptors...),
+			m.configFile.interceptor())
+	}
+	if !object.IsValid() ||
+		(len(interceptors) == 0 && !hasContextMethods(object)) {
+		return m.transport.Export(object)
+	}
+	intercepted, err := newInterceptedObject(m.name, object,
+		interceptors)
+	if err != nil {
+		return err
+	}
+	return m.transport.Export(intercepted)
+}
+
 func (m *model) stop() error {
 	m.component.wg.Done()
 	return m.transport.Close()
 }
 
+// readyNotifier is implemented by transports that tell the service
+// manager when the component is ready, which is once it has requested
+// all of its identities.
+type readyNotifier interface {
+	NotifyReady() error
+}
+
 type component struct {
 	name      string
 	models    []*model
-	transport transporter
+	transport Transport
 	client    *Client
 	wg        sync.WaitGroup
 
+	interceptors []Interceptor
+	// configFile is the ConfigFile of the component's '.component' file,
+	// for the model that does not name its own.
+	configFile string
+
+	// panicLimit is the number of handler panics after which the
+	// process exits, or zero to keep running.
+	panicLimit int32
+	panics     int32
+
+	calls    handlerCalls
+	watchdog watchdog
+	started  time.Time
+
+	// err is the error the component terminated with. It is set once
+	// by stop, however many times the component is stopped.
+	stopOnce sync.Once
+	mu       sync.Mutex
+	err      error
+
 	subscriptions struct {
 		mu             sync.RWMutex
 		runOnSubscribe bool
@@ -120,13 +255,33 @@ type component struct {
 // interface and returns the opaque Component so it may be used.
 // Refer to the Component interface documentation for more information.
 func NewComponent(name string) Component {
+	return newComponent(name, defaultTransport())
+}
+
+// NewComponentWithOptions is the same as NewComponent but the supplied
+// options control how the component attaches to the bus.
+func NewComponentWithOptions(name string, opts ...Option) Component {
+	o := newOptions(opts)
+	comp := newComponent(name, o.transport())
+	comp.client.
+		withMarshaller(o.marshaller).
+		withInterceptors(o.callInterceptors).
+		withMetrics(o.metrics).
+		withTracer(o.tracer)
+	comp.interceptors = o.serverInterceptors
+	comp.panicLimit = o.panicLimit
+	comp.configFile = o.configFile
+	return comp
+}
+
+func newComponent(name string, transport Transport) *component {
 	comp := &component{name: name, client: newClient()}
 	comp.subscriptions.subs = make(map[string]*Subscription)
-	comp.withTransport(defaultTransport())
+	comp.withTransport(transport)
 	return comp
 }
 
-func (c *component) withTransport(transport transporter) *component {
+func (c *component) withTransport(transport Transport) *component {
 	c.transport = transport
 	if c.client != nil {
 		c.client.withTransport(c.transport)
@@ -139,6 +294,11 @@ func (c *component) withTransport(transport transporter) *component {
 
 func (c *component) Model(name string) Model {
 	newModel := newModelWithTransport(name, c, c.transport)
+	newModel.client.
+		withMarshaller(c.client.marshaller).
+		withInterceptors(c.client.interceptors).
+		withMetrics(c.client.metrics).
+		withTracer(c.client.tracer)
 	c.models = append(c.models, newModel)
 	return newModel
 }
@@ -180,8 +340,40 @@ func (c *component) Run() error {
 	if err != nil {
 		return err
 	}
+	c.started = time.Now()
+	err = c.exportIntrospection()
+	if err != nil {
+		return err
+	}
 	c.wg.Add(1)
-	return c.transport.RequestIdentity(c.name)
+	err = c.transport.RequestIdentity(c.name)
+	if err != nil {
+		return err
+	}
+	if notifier, ok := c.transport.(readyNotifier); ok {
+		err = notifier.NotifyReady()
+		if err != nil {
+			return err
+		}
+	}
+	c.watchdog.start(c.name)
+	return nil
+}
+
+// exportIntrospection exposes the component's introspection object,
+// passing calls to it through the component's interceptors so that it
+// is covered by the same authorization policy and audit as its models.
+func (c *component) exportIntrospection() error {
+	var object TransportObject = newIntrospection(c)
+	if len(c.interceptors) != 0 {
+		intercepted, err := newInterceptedObject(c.name, object,
+			c.interceptors)
+		if err != nil {
+			return err
+		}
+		object = intercepted
+	}
+	return c.transport.Export(object)
 }
 
 func (c *component) Client() *Client {
@@ -191,18 +383,65 @@ func (c *component) Client() *Client {
 
 func (c *component) Wait() error {
 	c.wg.Wait()
-	return nil
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
 }
 
 func (c *component) Stop() error {
-	for _, model := range c.models {
-		_ = model.stop()
+	return c.stop(nil)
+}
+
+// stop disconnects the component from the bus, ending its execution.
+// The error it terminated with, err or else any error closing the
+// transport, is returned and will be returned by Wait. Only the first
+// call stops the component; later calls return the same error.
+func (c *component) stop(err error) error {
+	c.stopOnce.Do(func() {
+		c.watchdog.stop()
+		for _, model := range c.models {
+			_ = model.stop()
+		}
+		closeErr := c.transport.Close()
+		if err == nil {
+			err = closeErr
+		}
+		c.mu.Lock()
+		c.err = err
+		c.mu.Unlock()
+		c.wg.Done()
+	})
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// exitProcess is replaced in tests.
+var exitProcess = os.Exit
+
+// handlerPanicked counts a panic recovered from one of the component's
+// handlers, exiting once the panic limit is reached so that the
+// component can be restarted in a known state.
+func (c *component) handlerPanicked() {
+	if c.panicLimit == 0 {
+		return
+	}
+	if atomic.AddInt32(&c.panics, 1) >= c.panicLimit {
+		log.Printf("vci: %s exiting after %d handler panics",
+			c.name, c.panicLimit)
+		exitProcess(1)
 	}
-	c.wg.Done()
-	return c.transport.Close()
 }
 
 func (c *component) register() error {
+	err := c.assignConfigFile()
+	if err != nil {
+		return err
+	}
+	// Every model is configured before any of them can be called.
+	for _, model := range c.models {
+		model.restoreConfig()
+	}
 	for _, model := range c.models {
 		err := model.run()
 		if err != nil {
@@ -6,6 +6,11 @@
 package vci
 
 import (
+	"context"
+	"io/ioutil"
+	"log"
+	"os"
+	"sync"
 	"testing"
 	"time"
 )
@@ -557,4 +562,209 @@ func TestModel(t *testing.T) {
 		func(t *testing.T) {
 			t.Skip("no way to test this, it is DBus specific...")
 		})
+
+	t.Run("model-client-shares-component-options", func(t *testing.T) {
+		resetTestBus()
+		metrics := NewMetrics()
+		intercept := func(
+			ctx context.Context,
+			inv *Invocation,
+			invoke Invoker,
+		) (string, error) {
+			return invoke(ctx, inv)
+		}
+		comp := NewComponentWithOptions("net.vyatta.test",
+			WithCallInterceptors(intercept),
+			WithMetrics(metrics),
+			WithTracing(&testSpanExporter{})).(*component)
+		m := comp.Model("net.vyatta.test.v1").(*model)
+		if m.client.metrics != metrics ||
+			m.client.tracer == nil || m.client.tracer != comp.client.tracer ||
+			len(m.client.interceptors) != len(comp.client.interceptors) {
+			t.Fatalf("model's client does not share the options")
+		}
+	})
+}
+
+type testPanickingConfig struct {
+	testRunningConfigWithValue
+}
+
+func (c *testPanickingConfig) Set(config *testConfig) error {
+	if config.Value == "panic" {
+		panic("bad config")
+	}
+	return c.testRunningConfigWithValue.Set(config)
+}
+
+func TestHandlerPanics(t *testing.T) {
+	const model = "net.vyatta.test.v1"
+	log.SetOutput(ioutil.Discard)
+	defer log.SetOutput(os.Stderr)
+	var exits []int
+	exitProcess = func(code int) { exits = append(exits, code) }
+	defer func() { exitProcess = os.Exit }()
+
+	run := func(t *testing.T, opts ...Option) *Client {
+		resetTestBus()
+		comp := NewComponentWithOptions("net.vyatta.test",
+			append([]Option{WithTransport(newTestTransport())}, opts...)...)
+		comp.Model(model).
+			Config(&testPanickingConfig{}).
+			RPC("test-v1", map[string]interface{}{
+				"call-me": func(in *testConfig) (*testConfig, error) {
+					panic("bad input")
+				},
+			})
+		err := comp.Run()
+		if err != nil {
+			t.Fatal(err)
+		}
+		client, err := Dial()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return client
+	}
+	checkOperationFailed := func(t *testing.T, err error) {
+		if err == nil || errorTag(err) != "operation-failed" {
+			t.Fatalf("expected operation-failed, got %v", err)
+		}
+	}
+
+	t.Run("recovered", func(t *testing.T) {
+		exits = nil
+		client := run(t)
+		defer client.Close()
+		err := client.SetConfigForModel(model, &testConfig{Value: "panic"})
+		checkOperationFailed(t, err)
+		var out testConfig
+		err = client.Call("test-v1", "call-me", &testConfig{}).
+			StoreOutputInto(&out)
+		checkOperationFailed(t, err)
+		err = client.SetConfigForModel(model, &testConfig{Value: "foo"})
+		if err != nil {
+			t.Fatalf("component did not keep running: %v", err)
+		}
+		if len(exits) != 0 {
+			t.Fatalf("unexpected exit %v", exits)
+		}
+	})
+	t.Run("limit", func(t *testing.T) {
+		exits = nil
+		client := run(t, WithPanicLimit(2))
+		defer client.Close()
+		err := client.SetConfigForModel(model, &testConfig{Value: "panic"})
+		checkOperationFailed(t, err)
+		if len(exits) != 0 {
+			t.Fatalf("exited before the limit was reached: %v", exits)
+		}
+		err = client.SetConfigForModel(model, &testConfig{Value: "panic"})
+		checkOperationFailed(t, err)
+		if len(exits) != 1 || exits[0] != 1 {
+			t.Fatalf("did not exit at the limit: %v", exits)
+		}
+	})
+}
+
+// testConcurrency records the most calls that were running at once.
+type testConcurrency struct {
+	mu           sync.Mutex
+	running, max int
+}
+
+func (c *testConcurrency) enter() {
+	c.mu.Lock()
+	c.running++
+	if c.running > c.max {
+		c.max = c.running
+	}
+	c.mu.Unlock()
+	time.Sleep(10 * time.Millisecond)
+	c.mu.Lock()
+	c.running--
+	c.mu.Unlock()
+}
+
+func (c *testConcurrency) maxRunning() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.max
+}
+
+type testConcurrentConfig struct {
+	testRunningConfigWithValue
+	calls *testConcurrency
+}
+
+func (c *testConcurrentConfig) Get() *testConfig {
+	c.calls.enter()
+	return &testConfig{}
+}
+
+func (c *testConcurrentConfig) Set(config *testConfig) error {
+	c.calls.enter()
+	return nil
+}
+
+func TestModelExecutionModes(t *testing.T) {
+	const model = "net.vyatta.test.v1"
+	resetTestBus()
+	configCalls := &testConcurrency{}
+	rpcCalls := &testConcurrency{}
+	comp := NewComponentWithOptions("net.vyatta.test",
+		WithTransport(newTestTransport()))
+	comp.Model(model).
+		SerializeConfig().
+		RPCWorkers(2).
+		Config(&testConcurrentConfig{calls: configCalls}).
+		RPC("test-v1", map[string]interface{}{
+			"call-me": func(in *testConfig) (*testConfig, error) {
+				rpcCalls.enter()
+				return in, nil
+			},
+		})
+	err := comp.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 12)
+	for i := 0; i < 4; i++ {
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			errs <- client.SetConfigForModel(model, &testConfig{})
+		}()
+		go func() {
+			defer wg.Done()
+			var out testConfig
+			errs <- client.StoreConfigByModelInto(model, &out)
+		}()
+		go func() {
+			defer wg.Done()
+			var out testConfig
+			errs <- client.Call("test-v1", "call-me", &testConfig{}).
+				StoreOutputInto(&out)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := configCalls.maxRunning(); n != 1 {
+		t.Fatalf("%d config calls ran at once", n)
+	}
+	if n := rpcCalls.maxRunning(); n > 2 {
+		t.Fatalf("%d RPCs ran at once with 2 workers", n)
+	}
 }
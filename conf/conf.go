@@ -25,6 +25,8 @@ type ServiceConfig struct {
 	StartOnBoot     bool
 	Ephemeral       bool
 	DefaultComp     bool
+	WatchdogSec     uint // 0 if the service has no watchdog
 	ModelByName     map[string]*Model
 	ModelByModelSet map[string]*Model
+	Policy          *Policy // nil if there are no Policy sections
 }
@@ -9,6 +9,7 @@ package conf
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/go-ini/ini"
@@ -84,6 +85,11 @@ func ParseConfiguration(input []byte) (*ServiceConfig, error) {
 		}
 	}
 
+	config.Policy, err = parsePolicySections(iniFile)
+	if err != nil {
+		return nil, err
+	}
+
 	return config, nil
 }
 
@@ -197,6 +203,14 @@ func parseComponent(section *ini.Section, config *ServiceConfig) error {
 					err.Error())
 			}
 			config.DefaultComp = isDefaultComp
+		case "WatchdogSec":
+			watchdogSec, err := strconv.ParseUint(value, 10, 32)
+			if err != nil {
+				return fmt.Errorf(
+					"Unable to parse 'WatchdogSec': '%s' must be a "+
+						"number of seconds\n", value)
+			}
+			config.WatchdogSec = uint(watchdogSec)
 		}
 	}
 
@@ -292,6 +292,32 @@ func TestParseNoDefaultComponent(t *testing.T) {
 	}
 }
 
+func TestParseWatchdogSec(t *testing.T) {
+	dotCompFile := fmt.Sprintf(testComp_parseDefault, "WatchdogSec=30")
+	svcCfg, err := ParseConfiguration([]byte(dotCompFile))
+	if err != nil {
+		t.Fatalf("Unable to parse configuration: %s\n", err.Error())
+		return
+	}
+	if svcCfg.WatchdogSec != 30 {
+		t.Fatalf("WatchdogSec not set to 30: %d", svcCfg.WatchdogSec)
+		return
+	}
+}
+
+func TestParseWatchdogSecInvalid(t *testing.T) {
+	dotCompFile := fmt.Sprintf(testComp_parseDefault, "WatchdogSec=30s")
+	_, err := ParseConfiguration([]byte(dotCompFile))
+	if err == nil {
+		t.Fatalf("WatchdogSec should not have been parsed.")
+		return
+	}
+	if !strings.Contains(err.Error(), "'30s' must be a number of seconds") {
+		t.Fatalf("WatchdogSec=30s: wrong error '%s'", err.Error())
+		return
+	}
+}
+
 func TestParseEphemeral(t *testing.T) {
 	compFile := "testdata/ephemeral/serviceEphemeral.component"
 	dotCompFile, err := ioutil.ReadFile(compFile)
@@ -0,0 +1,216 @@
+// Copyright (c) 2026, AT&T Intellectual Property. All rights reserved.
+//
+// SPDX-License-Identifier: MPL-2.0
+
+package conf
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/go-ini/ini"
+)
+
+// ConfigOperations are the configuration operations a policy may allow.
+var ConfigOperations = []string{
+	"get", "set", "check", "prepare", "commit", "abort",
+}
+
+// A Policy lists who may call a component's RPCs and use its
+// configuration. Anything not allowed by one of its rules is denied.
+type Policy struct {
+	Rules []*PolicyRule
+}
+
+// A PolicyRule allows the listed users, and the members of the listed
+// groups, to call the listed RPCs and carry out the listed configuration
+// operations. Modules allows every RPC of a YANG module; RPCs are given
+// as module:rpc. A '*' in any of the lists matches everything.
+// Introspect allows them to introspect the component.
+type PolicyRule struct {
+	Name       string
+	Users      []string
+	Groups     []string
+	Modules    []string
+	RPCs       []string
+	Config     []string
+	Introspect bool
+}
+
+const policyPrefix = "Policy "
+
+// AllowsRPC reports whether the user, a member of the groups, may call
+// the RPC of the YANG module.
+func (p *Policy) AllowsRPC(
+	user string,
+	groups []string,
+	module, rpc string,
+) bool {
+	for _, rule := range p.Rules {
+		if !rule.appliesTo(user, groups) {
+			continue
+		}
+		if contains(rule.Modules, module) ||
+			contains(rule.RPCs, module+":"+rpc) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsConfig reports whether the user, a member of the groups, may
+// carry out the configuration operation.
+func (p *Policy) AllowsConfig(
+	user string,
+	groups []string,
+	op string,
+) bool {
+	for _, rule := range p.Rules {
+		if rule.appliesTo(user, groups) && contains(rule.Config, op) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsIntrospection reports whether the user, a member of the groups,
+// may introspect the component.
+func (p *Policy) AllowsIntrospection(user string, groups []string) bool {
+	for _, rule := range p.Rules {
+		if rule.appliesTo(user, groups) && rule.Introspect {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *PolicyRule) appliesTo(user string, groups []string) bool {
+	if contains(r.Users, user) {
+		return true
+	}
+	for _, group := range groups {
+		if contains(r.Groups, group) {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(list []string, value string) bool {
+	for _, entry := range list {
+		if entry == "*" || entry == value {
+			return true
+		}
+	}
+	return false
+}
+
+// ParsePolicy parses the Policy sections of a policy file or of a
+// '.component' file, ignoring any other sections.
+func ParsePolicy(input []byte) (*Policy, error) {
+	if err := checkForDuplicateSections(string(input)); err != nil {
+		return nil, err
+	}
+
+	iniFile, err := ini.Load(input)
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := parsePolicySections(iniFile)
+	if err != nil {
+		return nil, err
+	}
+	if policy == nil {
+		return nil, fmt.Errorf("No Policy sections found")
+	}
+	return policy, nil
+}
+
+// LoadPolicyFile loads a policy from a policy file or '.component' file.
+func LoadPolicyFile(file string) (*Policy, error) {
+	contents, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePolicy(contents)
+}
+
+// parsePolicySections returns nil if there are no Policy sections.
+func parsePolicySections(iniFile *ini.File) (*Policy, error) {
+	var policy *Policy
+	for _, section := range iniFile.Sections() {
+		if !strings.HasPrefix(section.Name(), policyPrefix) {
+			continue
+		}
+		rule, err := parsePolicyRule(section)
+		if err != nil {
+			return nil, err
+		}
+		if policy == nil {
+			policy = &Policy{}
+		}
+		policy.Rules = append(policy.Rules, rule)
+	}
+	return policy, nil
+}
+
+func parsePolicyRule(section *ini.Section) (*PolicyRule, error) {
+	/*
+	   [Policy operators]
+	   Groups=vyattaop
+	   Modules=example-v1
+	   RPCs=example-interfaces-v1:reset
+	   Config=get
+	   Introspect=true
+	*/
+	rule := &PolicyRule{Name: section.Name()[len(policyPrefix):]}
+
+	for _, field := range section.KeyStrings() {
+		value := section.Key(field).String()
+		values, err := parseCSVs(value)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to parse '%s': '%s'\nError: %s",
+				field, value, err.Error())
+		}
+		switch field {
+		case "Users":
+			rule.Users = values
+		case "Groups":
+			rule.Groups = values
+		case "Modules":
+			rule.Modules = values
+		case "RPCs":
+			for _, rpc := range values {
+				if rpc != "*" && strings.Count(rpc, ":") != 1 {
+					return nil, fmt.Errorf(
+						"RPC '%s' must be given as module:rpc", rpc)
+				}
+			}
+			rule.RPCs = values
+		case "Config":
+			for _, op := range values {
+				if op != "*" && !contains(ConfigOperations, op) {
+					return nil, fmt.Errorf(
+						"Unknown configuration operation '%s'", op)
+				}
+			}
+			rule.Config = values
+		case "Introspect":
+			rule.Introspect, err = section.Key(field).Bool()
+			if err != nil {
+				return nil, fmt.Errorf("Unable to parse '%s': '%s'",
+					field, value)
+			}
+		default:
+			return nil, fmt.Errorf("Unknown field '%s' in %s section",
+				field, section.Name())
+		}
+	}
+
+	if len(rule.Users) == 0 && len(rule.Groups) == 0 {
+		return nil, missingField(section.Name(), "Users or Groups")
+	}
+	return rule, nil
+}
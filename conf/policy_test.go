@@ -0,0 +1,126 @@
+// Copyright (c) 2026, AT&T Intellectual Property. All rights reserved.
+//
+// SPDX-License-Identifier: MPL-2.0
+
+package conf
+
+import (
+	"strings"
+	"testing"
+)
+
+var test_policy []byte = []byte(
+	"[Policy configd]\n" +
+		"Users=root\n" +
+		"Config=*\n" +
+		"\n" +
+		"[Policy operators]\n" +
+		"Groups=vyattaop, vyattaadm\n" +
+		"Modules=example-v1\n" +
+		"RPCs=example-interfaces-v1:reset\n" +
+		"Config=get\n" +
+		"Introspect=yes\n")
+
+func TestParsePolicy(t *testing.T) {
+	policy, err := ParsePolicy(test_policy)
+	if err != nil {
+		t.Fatalf("Unexpected error when parsing policy\n  %s", err.Error())
+	}
+	if len(policy.Rules) != 2 {
+		t.Fatalf("Expected 2 rules, got %d", len(policy.Rules))
+	}
+	rule := policy.Rules[1]
+	if rule.Name != "operators" {
+		t.Fatalf("Unexpected rule name %q", rule.Name)
+	}
+	compareCSVs(t, "Groups", rule.Groups, []string{"vyattaop", "vyattaadm"})
+	compareCSVs(t, "Modules", rule.Modules, []string{"example-v1"})
+	compareCSVs(t, "RPCs", rule.RPCs,
+		[]string{"example-interfaces-v1:reset"})
+	compareCSVs(t, "Config", rule.Config, []string{"get"})
+}
+
+func TestPolicyAllows(t *testing.T) {
+	policy, err := ParsePolicy(test_policy)
+	if err != nil {
+		t.Fatalf("Unexpected error when parsing policy\n  %s", err.Error())
+	}
+	op := []string{"vyattaop"}
+
+	checks := []struct {
+		desc    string
+		allowed bool
+		exp     bool
+	}{
+		{"root set", policy.AllowsConfig("root", nil, "set"), true},
+		{"operator get", policy.AllowsConfig("alice", op, "get"), true},
+		{"operator set", policy.AllowsConfig("alice", op, "set"), false},
+		{"unknown get", policy.AllowsConfig("", nil, "get"), false},
+		{"module rpc",
+			policy.AllowsRPC("alice", op, "example-v1", "ping"), true},
+		{"listed rpc",
+			policy.AllowsRPC("alice", op, "example-interfaces-v1", "reset"),
+			true},
+		{"unlisted rpc",
+			policy.AllowsRPC("alice", op, "example-interfaces-v1", "clear"),
+			false},
+		{"root rpc", policy.AllowsRPC("root", nil, "example-v1", "ping"),
+			false},
+		{"operator introspect", policy.AllowsIntrospection("alice", op),
+			true},
+		{"root introspect", policy.AllowsIntrospection("root", nil),
+			false},
+	}
+	for _, check := range checks {
+		if check.allowed != check.exp {
+			t.Errorf("%s: expected %v, got %v",
+				check.desc, check.exp, check.allowed)
+		}
+	}
+}
+
+func TestParsePolicyErrors(t *testing.T) {
+	tests := []struct {
+		desc   string
+		policy string
+		err    string
+	}{
+		{"no sections", "[Vyatta Component]\nName=foo\n",
+			"No Policy sections found"},
+		{"no users", "[Policy foo]\nConfig=get\n",
+			"Missing Users or Groups field from Policy foo section"},
+		{"bad rpc", "[Policy foo]\nUsers=bob\nRPCs=reset\n",
+			"RPC 'reset' must be given as module:rpc"},
+		{"bad operation", "[Policy foo]\nUsers=bob\nConfig=delete\n",
+			"Unknown configuration operation 'delete'"},
+		{"bad introspect", "[Policy foo]\nUsers=bob\nIntrospect=maybe\n",
+			"Unable to parse 'Introspect': 'maybe'"},
+		{"unknown field", "[Policy foo]\nUser=bob\n",
+			"Unknown field 'User' in Policy foo section"},
+		{"duplicate", "[Policy foo]\nUsers=bob\n[Policy foo]\nUsers=al\n",
+			"Duplicate section: [Policy foo]"},
+	}
+	for _, test := range tests {
+		_, err := ParsePolicy([]byte(test.policy))
+		if err == nil {
+			t.Errorf("%s: expected error did not occur", test.desc)
+			continue
+		}
+		if !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: expected %q, got %q", test.desc, test.err, err)
+		}
+	}
+}
+
+func TestParseComponentPolicy(t *testing.T) {
+	config := getValidConfig(t, append(append([]byte{}, test_config...),
+		append([]byte("\n"), test_policy...)...))
+	if config.Policy == nil || len(config.Policy.Rules) != 2 {
+		t.Fatalf("Unexpected policy %v", config.Policy)
+	}
+
+	config = getValidConfig(t, test_config)
+	if config.Policy != nil {
+		t.Fatalf("Unexpected policy %v", config.Policy)
+	}
+}
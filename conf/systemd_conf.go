@@ -9,6 +9,7 @@ package conf
 
 import (
 	"bytes"
+	"strconv"
 	"strings"
 
 	"github.com/danos/vci/services"
@@ -38,6 +39,10 @@ func (comp *ServiceConfig) GenerateSystemdService() []byte {
 	cfg_service.NewKey("Type", "notify")
 	cfg_service.NewKey("Restart", "on-failure")
 	cfg_service.NewKey("ExecStart", comp.ExecName)
+	if comp.WatchdogSec > 0 {
+		cfg_service.NewKey("WatchdogSec",
+			strconv.FormatUint(uint64(comp.WatchdogSec), 10))
+	}
 	if comp.Ephemeral {
 		if comp.ExecName == "" {
 			cfg_service.NewKey("ExecStart",
@@ -117,6 +117,41 @@ func TestSystemdFileStartOnBoot(t *testing.T) {
 	checkSectionKeyEquals(t, iniFile, "Install", "WantedBy", "multi-user.target")
 }
 
+var systemdTestConfigWatchdog []byte = []byte(`[Vyatta Component]
+Name=com.brocade.vyatta.example
+Description=Super Example Project
+ExecName=/opt/vyatta/sbin/example-service
+WatchdogSec=30
+
+[Model com.brocade.vyatta.example.brocade]
+Modules=example-v1,example-interfaces-v1
+ModelSets=brocade-v1
+`)
+
+func TestSystemdFileWatchdog(t *testing.T) {
+	compConfig := getValidConfig(t, systemdTestConfigWatchdog)
+	systemdServiceFile := compConfig.GenerateSystemdService()
+	iniFile, err := ini.Load(systemdServiceFile)
+	if err != nil {
+		t.Fatalf("Unable to parse systemd service file: %s", err.Error())
+		return
+	}
+
+	checkSectionKeyEquals(t, iniFile, "Service", "WatchdogSec", "30")
+}
+
+func TestSystemdFileNoWatchdog(t *testing.T) {
+	compConfig := getValidConfig(t, systemdTestConfigStartOnBoot)
+	systemdServiceFile := compConfig.GenerateSystemdService()
+	iniFile, err := ini.Load(systemdServiceFile)
+	if err != nil {
+		t.Fatalf("Unable to parse systemd service file: %s", err.Error())
+		return
+	}
+
+	checkSectionKeysNotPresent(t, iniFile, "Service", "WatchdogSec")
+}
+
 func TestSystemdFileEphemeral(t *testing.T) {
 	compFile := "testdata/ephemeral/serviceEphemeral.component"
 	dotCompFile, err := ioutil.ReadFile(compFile)
@@ -0,0 +1,283 @@
+// Copyright (c) 2026, AT&T Intellectual Property. All rights reserved.
+//
+// SPDX-License-Identifier: MPL-2.0
+
+package vci
+
+import (
+	"context"
+	"sync"
+
+	"github.com/godbus/dbus"
+)
+
+const (
+	fdtNameOwnerChanged  = "NameOwnerChanged"
+	nameOwnerChangedRule = "type='signal',sender='" + fdtDBusName +
+		"',interface='" + fdtDBusName +
+		"',member='" + fdtNameOwnerChanged + "'"
+)
+
+// rpcCache remembers the destination yangd gives for each module and the
+// RPCs each destination implements for a module, so that a call need not
+// ask yangd and introspect the destination before it is made. It also
+// remembers the credentials of each connection that has called the
+// component, by its unique name, so that they are not asked for on
+// every call. Entries are forgotten when the owner of a name they depend
+// on changes, as the component behind it has restarted or gone.
+type rpcCache struct {
+	mu       sync.Mutex
+	watching bool
+	// generation is incremented whenever entries are forgotten, or a
+	// name that a lookup in progress depends on changes owner, so that
+	// lookups that began before then are not stored.
+	generation uint64
+	// lookups counts the lookups in progress that depend on each name.
+	lookups      map[string]int
+	destinations map[string]string
+	methods      map[string]map[string]map[string]bool
+	callers      map[string]RPCMetadata
+}
+
+func (c *rpcCache) isWatching() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.watching
+}
+
+func (c *rpcCache) startWatching() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.watching = true
+}
+
+// stopWatching forgets everything, as the cache can no longer be kept
+// current, until the signals are asked for again.
+func (c *rpcCache) stopWatching() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.watching = false
+	c.generation++
+	c.destinations = nil
+	c.methods = nil
+	c.callers = nil
+}
+
+// beginLookup notes a lookup that depends on the owner of the name and
+// returns the generation its result is to be stored with. endLookup
+// must be called once the result is stored or abandoned.
+func (c *rpcCache) beginLookup(name string) uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.lookups == nil {
+		c.lookups = make(map[string]int)
+	}
+	c.lookups[name]++
+	return c.generation
+}
+
+func (c *rpcCache) endLookup(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lookups[name]--
+	if c.lookups[name] <= 0 {
+		delete(c.lookups, name)
+	}
+}
+
+func (c *rpcCache) destination(moduleName string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	dest, ok := c.destinations[moduleName]
+	return dest, ok
+}
+
+func (c *rpcCache) storeDestination(generation uint64, moduleName, dest string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.watching || generation != c.generation {
+		return
+	}
+	if c.destinations == nil {
+		c.destinations = make(map[string]string)
+	}
+	c.destinations[moduleName] = dest
+}
+
+// rpcs returns the D-Bus names of the RPCs the destination implements
+// for the module.
+func (c *rpcCache) rpcs(dest, moduleName string) (map[string]bool, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	rpcs, ok := c.methods[dest][moduleName]
+	return rpcs, ok
+}
+
+func (c *rpcCache) storeRPCs(
+	generation uint64,
+	dest, moduleName string,
+	rpcs map[string]bool,
+) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.watching || generation != c.generation {
+		return
+	}
+	if c.methods == nil {
+		c.methods = make(map[string]map[string]map[string]bool)
+	}
+	if c.methods[dest] == nil {
+		c.methods[dest] = make(map[string]map[string]bool)
+	}
+	c.methods[dest][moduleName] = rpcs
+}
+
+func (c *rpcCache) caller(sender string) (RPCMetadata, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	meta, ok := c.callers[sender]
+	return meta, ok
+}
+
+func (c *rpcCache) storeCaller(
+	generation uint64,
+	sender string,
+	meta RPCMetadata,
+) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.watching || generation != c.generation {
+		return
+	}
+	if c.callers == nil {
+		c.callers = make(map[string]RPCMetadata)
+	}
+	c.callers[sender] = meta
+}
+
+// forget drops the entries that depend on the owner of the name, which
+// for yangd is every destination. Lookups in progress are only abandoned
+// if the name matters to the cache, so that the comings and goings of
+// unrelated connections do not stop anything being cached.
+func (c *rpcCache) forget(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	forgotten := name == yangdName || c.lookups[name] != 0
+	if name == yangdName {
+		c.destinations = nil
+	}
+	for module, dest := range c.destinations {
+		if dest == name {
+			delete(c.destinations, module)
+			forgotten = true
+		}
+	}
+	if _, ok := c.methods[name]; ok {
+		delete(c.methods, name)
+		forgotten = true
+	}
+	if _, ok := c.callers[name]; ok {
+		delete(c.callers, name)
+		forgotten = true
+	}
+	if forgotten {
+		c.generation++
+	}
+}
+
+// watchNameOwners asks the bus for the NameOwnerChanged signals that
+// keep the cache current. Nothing is cached if the bus refuses.
+func (t *dbusTransport) watchNameOwners() bool {
+	if t.rpcCache.isWatching() {
+		return true
+	}
+	conn := t.connection()
+	if conn == nil {
+		return false
+	}
+	// The cache is not locked during the call as the bus may deliver a
+	// signal before it replies.
+	call := conn.BusObject().Call(fdtAddMatch, 0, nameOwnerChangedRule)
+	if call.Err != nil {
+		return false
+	}
+	t.rpcCache.startWatching()
+	return true
+}
+
+// nameOwnerChanged is told of each NameOwnerChanged signal, whose
+// arguments are the name, its old owner and its new owner.
+func (t *dbusTransport) nameOwnerChanged(signal *dbus.Signal) {
+	if len(signal.Body) == 0 {
+		return
+	}
+	name, ok := signal.Body[0].(string)
+	if !ok {
+		return
+	}
+	t.rpcCache.forget(name)
+}
+
+// rpcDestination returns the model that implements the module's RPCs,
+// asking yangd if it is not known.
+func (t *dbusTransport) rpcDestination(
+	ctx context.Context,
+	moduleName string,
+) (string, error) {
+	if !t.watchNameOwners() {
+		return t.getDestinationByModuleName(ctx, moduleName)
+	}
+	if dest, ok := t.rpcCache.destination(moduleName); ok {
+		return dest, nil
+	}
+	// The destination is yangd's answer, so depends on yangd alone.
+	generation := t.rpcCache.beginLookup(yangdName)
+	defer t.rpcCache.endLookup(yangdName)
+	dest, err := t.getDestinationByModuleName(ctx, moduleName)
+	if err != nil {
+		return "", err
+	}
+	t.rpcCache.storeDestination(generation, moduleName, dest)
+	return dest, nil
+}
+
+// callerMetadata describes the connection that sent a call, from the
+// credentials the bus holds for it if they are not known.
+func (t *dbusTransport) callerMetadata(sender string) (RPCMetadata, error) {
+	if !t.watchNameOwners() {
+		return t.peerMetadata(sender)
+	}
+	if meta, ok := t.rpcCache.caller(sender); ok {
+		return meta, nil
+	}
+	generation := t.rpcCache.beginLookup(sender)
+	defer t.rpcCache.endLookup(sender)
+	meta, err := t.peerMetadata(sender)
+	if err != nil {
+		return RPCMetadata{}, err
+	}
+	t.rpcCache.storeCaller(generation, sender, meta)
+	return meta, nil
+}
+
+// moduleRPCs returns the D-Bus names of the RPCs the model implements
+// for the module, introspecting the model if they are not known.
+func (t *dbusTransport) moduleRPCs(
+	ctx context.Context,
+	modelName, moduleName string,
+) (map[string]bool, error) {
+	if !t.watchNameOwners() {
+		return t.introspectModuleRPCs(ctx, modelName, moduleName)
+	}
+	if rpcs, ok := t.rpcCache.rpcs(modelName, moduleName); ok {
+		return rpcs, nil
+	}
+	generation := t.rpcCache.beginLookup(modelName)
+	defer t.rpcCache.endLookup(modelName)
+	rpcs, err := t.introspectModuleRPCs(ctx, modelName, moduleName)
+	if err != nil {
+		return nil, err
+	}
+	t.rpcCache.storeRPCs(generation, modelName, moduleName, rpcs)
+	return rpcs, nil
+}
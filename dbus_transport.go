@@ -10,9 +10,15 @@ package vci
 
 import (
 	"bytes"
+	"context"
+	"encoding/xml"
 	"errors"
+	"fmt"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 	"unicode"
 
 	"github.com/coreos/go-systemd/daemon"
@@ -26,11 +32,34 @@ const (
 	fdtDBusName        = "org.freedesktop.DBus"
 	fdtAddMatch        = fdtDBusName + ".AddMatch"
 	fdtRemoveMatch     = fdtDBusName + ".RemoveMatch"
+	fdtIntrospect      = fdtDBusName + ".Introspectable.Introspect"
+	fdtGetCredentials  = fdtDBusName + ".GetConnectionCredentials"
+	fdtGetUnixUser     = fdtDBusName + ".GetConnectionUnixUser"
+	fdtGetUnixPID      = fdtDBusName + ".GetConnectionUnixProcessID"
+	fdtListNames       = fdtDBusName + ".ListNames"
+	fdtGetNameOwner    = fdtDBusName + ".GetNameOwner"
 	yangModuleDBusPfx  = "yang.module"
 	yangdRPCPath       = "/yangd_v1/rpc"
 	readDBusInterface  = "net.vyatta.vci.config.read"
 	writeDBusInterface = "net.vyatta.vci.config.write"
+	writeMetaInterface = "net.vyatta.vci.config.write.metadata"
+	txnDBusInterface   = "net.vyatta.vci.config.transaction"
+	pathDBusInterface  = "net.vyatta.vci.config.read.path"
+	stateDBusInterface = "net.vyatta.vci.state"
+	stateChangedSignal = "Changed"
+	introspectionIface = "net.vyatta.vci.introspection"
 	vciBusAddress      = "unix:path=/var/run/vci/vci_bus_socket"
+
+	// Bounds for the delay between attempts to re-establish a lost
+	// connection to the bus. The delay doubles after each failed attempt.
+	reconnectMinDelay = 100 * time.Millisecond
+	reconnectMaxDelay = 5 * time.Second
+)
+
+var (
+	errTransportClosed = errors.New("transport closed")
+	errMalformedSignal = errors.New(
+		"notification signal must have a single string argument")
 )
 
 func init() {
@@ -38,7 +67,7 @@ func init() {
 	// if porting to a new transport, removing this init
 	// and using a similar function in the new transport
 	// implementation will cause it to be used instead.
-	setDefaultTransportConstructor(func() transporter {
+	setDefaultTransportConstructor(func() Transport {
 		return newDBusVciTransport()
 	})
 }
@@ -47,20 +76,52 @@ type dbusServiceRead interface {
 	Get() (string, error)
 }
 
+type dbusServicePathRead interface {
+	GetPath(string) (string, error)
+}
+
 type dbusServiceWrite interface {
 	Check(string) error
 	Set(string) error
 }
 
+// dbusServiceWriteMetadata is implemented by configuration objects that
+// accept the metadata of the call, such as its trace context, with the
+// configuration.
+type dbusServiceWriteMetadata interface {
+	CheckWithMetadata(string, string) error
+	SetWithMetadata(string, string) error
+}
+
+type dbusServiceTransaction interface {
+	Prepare(string) error
+	Commit() error
+	Abort() error
+}
+
 type dbusCall struct {
+	ctx       context.Context
 	call      *dbus.Call
 	transport *dbusTransport
+	// unchecked is set when the RPC was called without introspecting
+	// the component, so a missing method is only discovered here.
+	unchecked bool
 }
 
-func (c *dbusCall) StoreOutputInto(output *string) error {
-	call := <-c.call.Done
+func (c *dbusCall) StoreOutputInto(ctx context.Context, output *string) error {
+	var call *dbus.Call
+	select {
+	case call = <-c.call.Done:
+	case <-c.ctx.Done():
+		return c.ctx.Err()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 	err := call.Store(output)
 	if err != nil {
+		if c.unchecked {
+			return c.transport.processOptionalMethodError(err)
+		}
 		err = c.transport.processError(err)
 	}
 	return err
@@ -69,32 +130,56 @@ func (c *dbusCall) StoreOutputInto(output *string) error {
 type dBusConnector func(dbus.Handler, dbus.SignalHandler) (*dbus.Conn, error)
 
 type dbusTransport struct {
-	busMgr         *objtree.BusManager
-	conn           *dbus.Conn
-	connectFn      dBusConnector
+	// mu protects the connection and the state that must be
+	// replayed onto a new connection if the bus goes away.
+	mu          sync.RWMutex
+	busMgr      *objtree.BusManager
+	conn        *dbus.Conn
+	connectFn   dBusConnector
+	address     string
+	closing     bool
+	identities  []string
+	objects     []TransportObject
+	onReconnect func(ReconnectEvent)
+
+	// skipRPCIntrospection makes RPC calls without first checking that
+	// the destination implements them.
+	skipRPCIntrospection bool
+	rpcCache             rpcCache
+
+	// reconnecting is set, atomically, while the connection is being
+	// re-established.
+	reconnecting int32
+
+	// signalHandlers holds the subscribers for each signal, keyed by
+	// the match rule that selects it.
 	signalHandlers struct {
 		mu       sync.RWMutex
-		handlers map[string][]transportSubscriber
+		handlers map[string][]TransportSubscriber
 	}
 }
 
 func newDBusTransport() *dbusTransport {
 	t := &dbusTransport{}
-	t.signalHandlers.handlers = make(map[string][]transportSubscriber)
+	t.signalHandlers.handlers = make(map[string][]TransportSubscriber)
 	t.connectFn = dBusConnector(t.systemConnectFn)
 	return t
 }
 
 func newDBusVciTransport() *dbusTransport {
-	t := &dbusTransport{}
-	t.signalHandlers.handlers = make(map[string][]transportSubscriber)
-	t.connectFn = dBusConnector(t.vciConnectFn)
+	return newDBusAddressTransport(vciBusAddress)
+}
+
+func newDBusAddressTransport(address string) *dbusTransport {
+	t := &dbusTransport{address: address}
+	t.signalHandlers.handlers = make(map[string][]TransportSubscriber)
+	t.connectFn = dBusConnector(t.addressConnectFn)
 	return t
 }
 
 func newDBusSessionTransport() *dbusTransport {
 	t := &dbusTransport{}
-	t.signalHandlers.handlers = make(map[string][]transportSubscriber)
+	t.signalHandlers.handlers = make(map[string][]TransportSubscriber)
 	t.connectFn = dBusConnector(t.sessionConnectFn)
 	return t
 }
@@ -111,7 +196,7 @@ func (t *dbusTransport) systemConnectFn(
 	return dbus.SystemBusPrivateHandler(hdlr, t)
 }
 
-func (t *dbusTransport) vciConnectFn(
+func (t *dbusTransport) addressConnectFn(
 	hdlr dbus.Handler,
 	_ dbus.SignalHandler,
 ) (*dbus.Conn, error) {
@@ -120,7 +205,7 @@ func (t *dbusTransport) vciConnectFn(
 	// instead we want to intercept the notifications. We can do this
 	// by using the passed in handler from objtree for normal
 	// calls and our own handler for signals.
-	return dbus.DialHandler(vciBusAddress, hdlr, t)
+	return dbus.DialHandler(t.address, hdlr, t)
 }
 
 // sessionConnectFn is used for testing but we may switch to it
@@ -140,95 +225,480 @@ func (t *dbusTransport) DeliverSignal(
 	iface, name string,
 	signal *dbus.Signal,
 ) {
+	if iface == fdtDBusName && name == fdtNameOwnerChanged {
+		t.nameOwnerChanged(signal)
+		return
+	}
 	t.signalHandlers.mu.RLock()
 	defer t.signalHandlers.mu.RUnlock()
 
-	sigName := iface + "/" + name
-	subs, ok := t.signalHandlers.handlers[sigName]
-	if !ok {
+	if iface == stateDBusInterface {
+		t.deliverStateChange(signal)
 		return
 	}
+	subs := t.signalHandlers.handlers[t.signalMatchRule(iface, name)]
+	moduleSubs := t.signalHandlers.handlers[t.interfaceMatchRule(iface)]
+	if len(subs) == 0 && len(moduleSubs) == 0 {
+		return
+	}
+	encodedData, err := t.signalBody(signal)
 	for _, sub := range subs {
-		_ = sub.Deliver(signal.Body[0].(string))
+		if err != nil {
+			deliverMalformed(sub, signal, err)
+			continue
+		}
+		_ = sub.Deliver(encodedData)
+	}
+	for _, sub := range moduleSubs {
+		if err != nil {
+			deliverMalformed(sub, signal, err)
+			continue
+		}
+		if msub, ok := sub.(TransportModuleSubscriber); ok {
+			_ = msub.DeliverNotification(
+				t.convertDBusNameToYang(name), encodedData)
+		}
+	}
+}
+
+// deliverMalformed reports a signal that could not be decoded to the
+// subscriber, if it wants to know of them.
+func deliverMalformed(
+	sub TransportSubscriber,
+	signal *dbus.Signal,
+	err error,
+) {
+	if msub, ok := sub.(TransportMalformedSubscriber); ok {
+		msub.DeliverMalformed(fmt.Sprint(signal.Body), err)
+	}
+}
+
+// deliverStateChange delivers a state change signal, which carries the
+// encoded state followed by the path it is found at, to the subscribers
+// to the model's state. The caller holds the signal handlers' lock.
+func (t *dbusTransport) deliverStateChange(signal *dbus.Signal) {
+	subs := t.signalHandlers.handlers[t.stateSignalMatchRule(signal.Path)]
+	if len(subs) == 0 {
+		return
+	}
+	encodedState, path, err := t.stateSignalBody(signal)
+	for _, sub := range subs {
+		if err != nil {
+			deliverMalformed(sub, signal, err)
+			continue
+		}
+		if ssub, ok := sub.(TransportStateSubscriber); ok {
+			_ = ssub.DeliverStateChange(path, encodedState)
+		}
+	}
+}
+
+func (t *dbusTransport) stateSignalBody(
+	signal *dbus.Signal,
+) (encodedState, path string, err error) {
+	if len(signal.Body) != 2 {
+		return "", "", errMalformedSignal
+	}
+	encodedState, ok := signal.Body[0].(string)
+	if !ok {
+		return "", "", errMalformedSignal
+	}
+	path, ok = signal.Body[1].(string)
+	if !ok {
+		return "", "", errMalformedSignal
 	}
+	return encodedState, path, nil
+}
+
+// signalBody extracts the encoded notification from a signal, which
+// must carry it as the only argument.
+func (t *dbusTransport) signalBody(signal *dbus.Signal) (string, error) {
+	if len(signal.Body) != 1 {
+		return "", errMalformedSignal
+	}
+	encodedData, ok := signal.Body[0].(string)
+	if !ok {
+		return "", errMalformedSignal
+	}
+	return encodedData, nil
 }
 
 func (t *dbusTransport) Dial() error {
-	if t.conn != nil {
+	if t.connection() != nil {
 		return nil
 	}
+	// The lock must not be held while connecting, a failed connection
+	// is closed and so calls back into Terminate.
 	busMgr, err := objtree.NewAnonymousBusManager(t.connectFn)
 	if err != nil {
 		return err
 	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
 	t.busMgr = busMgr
 	t.conn = busMgr.Conn()
+	t.closing = false
 	return nil
 }
 
 func (t *dbusTransport) RequestIdentity(id string) error {
+	// The lock is not held during the call, if the bus goes away the
+	// reply never comes and a reconnection would wait on the lock.
+	busMgr := t.manager()
+	if busMgr == nil {
+		return errTransportClosed
+	}
+	err := busMgr.RequestName(id)
+	if err != nil {
+		return err
+	}
+	t.mu.Lock()
+	t.identities = append(t.identities, id)
+	current := t.busMgr
+	t.mu.Unlock()
+	if current != nil && current != busMgr {
+		// The bus was reconnected during the call, without the name.
+		return current.RequestName(id)
+	}
+	return nil
+}
+
+// SkipRPCIntrospection makes RPC calls without first checking that the
+// component implements them. It must be called before the transport is
+// used.
+func (t *dbusTransport) SkipRPCIntrospection() {
+	t.skipRPCIntrospection = true
+}
+
+// NotifyReady tells systemd the service is ready, once the component
+// has requested all of its identities.
+func (t *dbusTransport) NotifyReady() error {
 	_, err := daemon.SdNotify(false, "READY=1")
+	return err
+}
+
+// ReleaseIdentities tells systemd the service is stopping and gives up
+// the names requested with RequestIdentity, so that no new calls are
+// routed to this connection.
+func (t *dbusTransport) ReleaseIdentities() error {
+	_, err := daemon.SdNotify(false, "STOPPING=1")
 	if err != nil {
 		return err
 	}
-	return t.busMgr.RequestName(id)
+	t.mu.Lock()
+	busMgr := t.busMgr
+	identities := t.identities
+	t.identities = nil
+	t.mu.Unlock()
+	if busMgr == nil {
+		return nil
+	}
+	for _, id := range identities {
+		err := busMgr.ReleaseName(id)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// OnReconnect registers a function that is called as the transport
+// attempts to re-establish a lost connection to the bus.
+func (t *dbusTransport) OnReconnect(fn func(ReconnectEvent)) {
+	t.mu.Lock()
+	t.onReconnect = fn
+	t.mu.Unlock()
+}
+
+// Terminate is called when the underlying connection closes. Unless the
+// transport was closed deliberately the bus has gone away, so begin
+// re-establishing the connection. It is called from the connection's
+// reader goroutine before the calls in flight are failed, so it must not
+// wait on anything that such a call may be holding.
+func (t *dbusTransport) Terminate() {
+	if !atomic.CompareAndSwapInt32(&t.reconnecting, 0, 1) {
+		return
+	}
+	go t.reconnect()
+}
+
+func (t *dbusTransport) reconnect() {
+	t.mu.RLock()
+	deliberate := t.conn == nil || t.closing
+	t.mu.RUnlock()
+	if deliberate {
+		atomic.StoreInt32(&t.reconnecting, 0)
+		return
+	}
+	t.notifyReconnect(ReconnectEvent{Err: ErrDisconnected})
+	delay := reconnectMinDelay
+	for attempt := 1; ; attempt++ {
+		time.Sleep(delay)
+		err := t.redial()
+		if err == errTransportClosed {
+			atomic.StoreInt32(&t.reconnecting, 0)
+			return
+		}
+		if err == nil {
+			atomic.StoreInt32(&t.reconnecting, 0)
+		}
+		t.notifyReconnect(ReconnectEvent{Attempt: attempt, Err: err})
+		if err == nil {
+			return
+		}
+		delay *= 2
+		if delay > reconnectMaxDelay {
+			delay = reconnectMaxDelay
+		}
+	}
+}
+
+// redial establishes a new connection to the bus and replays the
+// identities, exported objects and signal matches of the old one. No
+// lock is held while the bus is called; the state is copied, replayed,
+// and the new connection is only swapped in once it has caught up with
+// any changes made in the meantime.
+func (t *dbusTransport) redial() error {
+	if t.isClosing() {
+		return errTransportClosed
+	}
+	busMgr, err := objtree.NewAnonymousBusManager(t.connectFn)
+	if err != nil {
+		return err
+	}
+	restored := 0
+	identities := make(map[string]bool)
+	rules := make(map[string]bool)
+	for {
+		t.mu.Lock()
+		if t.closing || len(t.objects) < restored {
+			t.mu.Unlock()
+			busMgr.Conn().Close()
+			return errTransportClosed
+		}
+		newObjects := t.objects[restored:]
+		var newIdentities []string
+		for _, id := range t.identities {
+			if !identities[id] {
+				newIdentities = append(newIdentities, id)
+			}
+		}
+		newRules := t.newMatchRules(rules)
+		if len(newObjects) == 0 && len(newIdentities) == 0 &&
+			len(newRules) == 0 {
+			t.busMgr = busMgr
+			t.conn = busMgr.Conn()
+			t.mu.Unlock()
+			break
+		}
+		restored = len(t.objects)
+		t.mu.Unlock()
+
+		err = t.restore(busMgr, newObjects, newIdentities, newRules)
+		if err != nil {
+			// Terminate ignores this close since we are still
+			// reconnecting.
+			busMgr.Conn().Close()
+			return err
+		}
+		for _, id := range newIdentities {
+			identities[id] = true
+		}
+		for _, rule := range newRules {
+			rules[rule] = true
+		}
+	}
+	// Names may have changed hands while the bus was away.
+	t.rpcCache.stopWatching()
+	return nil
+}
+
+func (t *dbusTransport) isClosing() bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.closing
+}
+
+// manager returns the current bus manager, which is nil when the
+// transport is not connected.
+func (t *dbusTransport) manager() *objtree.BusManager {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.busMgr
+}
+
+// newMatchRules returns the match rules of the current subscriptions
+// that are not among those already added.
+func (t *dbusTransport) newMatchRules(added map[string]bool) []string {
+	t.signalHandlers.mu.RLock()
+	defer t.signalHandlers.mu.RUnlock()
+	var rules []string
+	for rule, subs := range t.signalHandlers.handlers {
+		if len(subs) != 0 && !added[rule] {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+func (t *dbusTransport) restore(
+	busMgr *objtree.BusManager,
+	objects []TransportObject,
+	identities []string,
+	rules []string,
+) error {
+	for _, object := range objects {
+		err := t.export(busMgr, object)
+		if err != nil {
+			return err
+		}
+	}
+	for _, id := range identities {
+		err := busMgr.RequestName(id)
+		if err != nil {
+			return err
+		}
+	}
+	for _, rule := range rules {
+		call := busMgr.Conn().BusObject().Call(fdtAddMatch, 0, rule)
+		if call.Err != nil {
+			return call.Err
+		}
+	}
+	return nil
+}
+
+func (t *dbusTransport) notifyReconnect(ev ReconnectEvent) {
+	t.mu.RLock()
+	fn := t.onReconnect
+	t.mu.RUnlock()
+	if fn != nil {
+		fn(ev)
+	}
+}
+
+// connection returns the current connection to the bus. It may change
+// underneath the caller if the bus goes away and is reconnected.
+func (t *dbusTransport) connection() *dbus.Conn {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.conn
+}
+
+// interfaceMatchRule selects all of the signals on an interface, which
+// for a module's notification interface are all of its notifications.
+func (t *dbusTransport) interfaceMatchRule(ifaceName string) string {
+	return "type='signal',interface='" + ifaceName + "'"
+}
+
+func (t *dbusTransport) signalMatchRule(ifaceName, sigName string) string {
+	return "type='signal',interface='" + ifaceName +
+		"',member='" + sigName + "'"
+}
+
+// stateSignalMatchRule selects the state change signals of one model,
+// which are told apart by their object path.
+func (t *dbusTransport) stateSignalMatchRule(path dbus.ObjectPath) string {
+	return "type='signal',path='" + string(path) +
+		"',interface='" + stateDBusInterface +
+		"',member='" + stateChangedSignal + "'"
 }
 
 func (t *dbusTransport) Call(
+	ctx context.Context,
 	moduleName, rpcName string,
 	metaData string,
 	encodedData string,
-) (transportRPCPromise, error) {
-	modelName, err := t.getDestinationByModuleName(moduleName)
+) (TransportRPCPromise, error) {
+	modelName, err := t.rpcDestination(ctx, moduleName)
 	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
 		return nil, errors.New(
 			"unable to locate RPC on Bus (no model): " +
 				moduleName + ":" + rpcName)
 	}
 	dbusRPCName := t.convertYangNameToDBus(rpcName)
-	if !t.isDBusRPC(modelName, moduleName, dbusRPCName) {
+	if !t.skipRPCIntrospection &&
+		!t.isDBusRPC(ctx, modelName, moduleName, dbusRPCName) {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
 		return nil, errors.New(
 			"unable to locate RPC on Bus: " +
 				modelName + ":" + moduleName + ":" + rpcName)
 	}
 
-	obj := t.conn.Object(modelName, t.getModuleRPCObjectPath(moduleName))
+	obj := t.connection().Object(modelName, t.getModuleRPCObjectPath(moduleName))
 	call := obj.Go(t.getModuleRPCInterfaceName(moduleName)+
 		"."+dbusRPCName, 0, nil, metaData, encodedData)
-	return &dbusCall{call: call, transport: t}, nil
+	return &dbusCall{ctx: ctx, call: call, transport: t,
+		unchecked: t.skipRPCIntrospection}, nil
 }
 
 func (t *dbusTransport) Subscribe(
 	moduleName, notificationName string,
-	subscriber transportSubscriber,
+	subscriber TransportSubscriber,
+) error {
+	return t.subscribeSignal(
+		t.notificationMatchRule(moduleName, notificationName), subscriber)
+}
+
+func (t *dbusTransport) Unsubscribe(
+	moduleName, notificationName string,
+	subscriber TransportSubscriber,
+) error {
+	return t.unsubscribeSignal(
+		t.notificationMatchRule(moduleName, notificationName), subscriber)
+}
+
+func (t *dbusTransport) SubscribeModule(
+	moduleName string,
+	subscriber TransportModuleSubscriber,
+) error {
+	return t.subscribeSignal(t.interfaceMatchRule(
+		t.getModuleNotificationInterfaceName(moduleName)), subscriber)
+}
+
+func (t *dbusTransport) UnsubscribeModule(
+	moduleName string,
+	subscriber TransportModuleSubscriber,
+) error {
+	return t.unsubscribeSignal(t.interfaceMatchRule(
+		t.getModuleNotificationInterfaceName(moduleName)), subscriber)
+}
+
+func (t *dbusTransport) notificationMatchRule(
+	moduleName, notificationName string,
+) string {
+	return t.signalMatchRule(
+		t.getModuleNotificationInterfaceName(moduleName),
+		t.convertYangNameToDBus(notificationName))
+}
+
+func (t *dbusTransport) subscribeSignal(
+	rule string,
+	subscriber TransportSubscriber,
 ) error {
-	sigName := t.convertYangNameToDBus(notificationName)
-	ifaceName := t.getModuleNotificationInterfaceName(moduleName)
-	call := t.conn.BusObject().Call(fdtAddMatch, 0,
-		"type='signal',interface='"+ifaceName+"',member='"+sigName+"'")
+	call := t.connection().BusObject().Call(fdtAddMatch, 0, rule)
 	if call.Err != nil {
 		return call.Err
 	}
-
-	name := ifaceName + "/" + sigName
-	t.addSubscriber(name, subscriber)
+	t.addSubscriber(rule, subscriber)
 	return nil
 }
 
-func (t *dbusTransport) Unsubscribe(
-	moduleName, notificationName string,
-	subscriber transportSubscriber,
+func (t *dbusTransport) unsubscribeSignal(
+	rule string,
+	subscriber TransportSubscriber,
 ) error {
-	sigName := t.convertYangNameToDBus(notificationName)
-	ifaceName := t.getModuleNotificationInterfaceName(moduleName)
-	name := ifaceName + "/" + sigName
-	numLeft := t.removeSubscriber(name, subscriber)
+	numLeft := t.removeSubscriber(rule, subscriber)
 	if numLeft != 0 {
 		return nil
 	}
 
-	call := t.conn.BusObject().Call(fdtRemoveMatch, 0,
-		"type='signal',interface='"+ifaceName+"',member='"+sigName+"'")
+	call := t.connection().BusObject().Call(fdtRemoveMatch, 0, rule)
 	if call.Err != nil {
 		return call.Err
 	}
@@ -236,42 +706,143 @@ func (t *dbusTransport) Unsubscribe(
 	return nil
 }
 
+func (t *dbusTransport) SubscribeStateChanges(
+	modelName string,
+	subscriber TransportStateSubscriber,
+) error {
+	return t.subscribeSignal(
+		t.stateSignalMatchRule(t.getModelStateObjectPath(modelName)),
+		subscriber)
+}
+
+func (t *dbusTransport) UnsubscribeStateChanges(
+	modelName string,
+	subscriber TransportStateSubscriber,
+) error {
+	return t.unsubscribeSignal(
+		t.stateSignalMatchRule(t.getModelStateObjectPath(modelName)),
+		subscriber)
+}
+
+// EmitStateChange emits the state as the signal's first argument, as
+// notifications are, and the path it is found at as the second.
+func (t *dbusTransport) EmitStateChange(
+	ctx context.Context,
+	modelName, path, encodedState string,
+) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return t.connection().Emit(t.getModelStateObjectPath(modelName),
+		stateDBusInterface+"."+stateChangedSignal, encodedState, path)
+}
+
 func (t *dbusTransport) Emit(
+	ctx context.Context,
 	moduleName, name, encodedData string,
 ) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	modulePath := t.getModuleNotificationObjectPath(moduleName)
 	notificationName := t.getModuleNotificationInterfaceName(moduleName) +
 		"." + t.convertYangNameToDBus(name)
-	return t.conn.Emit(modulePath, notificationName, encodedData)
+	return t.connection().Emit(modulePath, notificationName, encodedData)
 }
 
 func (t *dbusTransport) SetConfigForModel(
+	ctx context.Context,
+	modelName string, encodedData string,
+) error {
+	return t.writeConfig(ctx, modelName, "Set", encodedData)
+}
+
+func (t *dbusTransport) CheckConfigForModel(
+	ctx context.Context,
 	modelName string, encodedData string,
 ) error {
-	obj := t.conn.Object(modelName, "/running")
-	err := obj.Call(writeDBusInterface+".Set", 0, encodedData).Store()
+	return t.writeConfig(ctx, modelName, "Check", encodedData)
+}
+
+// writeConfig calls the Set or Check method of a model's configuration.
+// The trace context carried by ctx is sent with the configuration if
+// the component accepts metadata; components that have no need of it do
+// not implement the metadata interface.
+func (t *dbusTransport) writeConfig(
+	ctx context.Context,
+	modelName, method, encodedData string,
+) error {
+	obj := t.connection().Object(modelName, "/running")
+	if sc, ok := SpanContextFromContext(ctx); ok {
+		meta, err := encodeMetadata(RPCMetadata{
+			Traceparent: sc.Traceparent(),
+			Tracestate:  sc.TraceState,
+		})
+		if err != nil {
+			return err
+		}
+		err = t.callContext(ctx, obj,
+			writeMetaInterface+"."+method+"WithMetadata",
+			meta, encodedData).Store()
+		if !isUnknownMethodError(err) {
+			if err != nil {
+				err = t.processErrorIgnoreUnsupported(err)
+			}
+			return err
+		}
+	}
+	err := t.callContext(ctx, obj, writeDBusInterface+"."+method,
+		encodedData).Store()
 	if err != nil {
 		err = t.processErrorIgnoreUnsupported(err)
 	}
 	return err
 }
 
-func (t *dbusTransport) CheckConfigForModel(
+func (t *dbusTransport) PrepareConfigForModel(
+	ctx context.Context,
 	modelName string, encodedData string,
 ) error {
-	obj := t.conn.Object(modelName, "/running")
-	err := obj.Call(writeDBusInterface+".Check", 0, encodedData).Store()
+	obj := t.connection().Object(modelName, "/running")
+	err := t.callContext(ctx, obj, txnDBusInterface+".Prepare",
+		encodedData).Store()
 	if err != nil {
-		err = t.processErrorIgnoreUnsupported(err)
+		err = t.processOptionalMethodError(err)
+	}
+	return err
+}
+
+func (t *dbusTransport) CommitConfigForModel(
+	ctx context.Context,
+	modelName string,
+) error {
+	obj := t.connection().Object(modelName, "/running")
+	err := t.callContext(ctx, obj, txnDBusInterface+".Commit").Store()
+	if err != nil {
+		err = t.processOptionalMethodError(err)
+	}
+	return err
+}
+
+func (t *dbusTransport) AbortConfigForModel(
+	ctx context.Context,
+	modelName string,
+) error {
+	obj := t.connection().Object(modelName, "/running")
+	err := t.callContext(ctx, obj, txnDBusInterface+".Abort").Store()
+	if err != nil {
+		err = t.processOptionalMethodError(err)
 	}
 	return err
 }
 
 func (t *dbusTransport) StoreConfigByModelInto(
+	ctx context.Context,
 	modelName string, encodedData *string,
 ) error {
-	obj := t.conn.Object(modelName, "/running")
-	err := obj.Call(readDBusInterface+".Get", 0).Store(encodedData)
+	obj := t.connection().Object(modelName, "/running")
+	err := t.callContext(ctx, obj, readDBusInterface+".Get").
+		Store(encodedData)
 	if err != nil {
 		err = t.processError(err)
 	}
@@ -279,43 +850,226 @@ func (t *dbusTransport) StoreConfigByModelInto(
 }
 
 func (t *dbusTransport) StoreStateByModelInto(
+	ctx context.Context,
 	modelName string, encodedData *string,
 ) error {
-	obj := t.conn.Object(modelName, "/state")
-	err := obj.Call(readDBusInterface+".Get", 0).Store(encodedData)
+	obj := t.connection().Object(modelName, "/state")
+	err := t.callContext(ctx, obj, readDBusInterface+".Get").
+		Store(encodedData)
 	if err != nil {
 		err = t.processError(err)
 	}
 	return err
 }
 
-func (t *dbusTransport) Export(object transportObject) error {
+func (t *dbusTransport) StoreIntrospectionInto(
+	ctx context.Context,
+	componentName string, encodedData *string,
+) error {
+	obj := t.connection().Object(componentName,
+		dbus.ObjectPath("/"+introspectionObjectName))
+	err := t.callContext(ctx, obj, introspectionIface+".Get").
+		Store(encodedData)
+	if err != nil {
+		err = t.processError(err)
+	}
+	return err
+}
+
+// ListPeers finds the connections that own names on the bus and the VCI
+// objects they export, by introspecting their object trees. Names and
+// connections that go away while they are being listed are left out.
+func (t *dbusTransport) ListPeers(ctx context.Context) ([]TransportPeer, error) {
+	conn := t.connection()
+	if conn == nil {
+		return nil, errTransportClosed
+	}
+	var names []string
+	err := t.callContext(ctx, conn.BusObject(), fdtListNames).Store(&names)
+	if err != nil {
+		return nil, t.processError(err)
+	}
+	owners := make(map[string][]string)
+	for _, name := range names {
+		if strings.HasPrefix(name, ":") || name == fdtDBusName {
+			continue
+		}
+		var owner string
+		err := t.callContext(ctx, conn.BusObject(), fdtGetNameOwner,
+			name).Store(&owner)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		if err != nil {
+			continue
+		}
+		owners[owner] = append(owners[owner], name)
+	}
+	peers := make([]TransportPeer, 0, len(owners))
+	for owner, names := range owners {
+		objects, err := t.peerObjects(ctx, conn, owner)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		if err != nil {
+			continue
+		}
+		sort.Strings(names)
+		peers = append(peers, TransportPeer{Names: names, Objects: objects})
+	}
+	return peers, nil
+}
+
+// peerObjects introspects the objects at the top of a connection's object
+// tree, where components export their objects, and the RPC objects
+// beneath them.
+func (t *dbusTransport) peerObjects(
+	ctx context.Context,
+	conn *dbus.Conn,
+	owner string,
+) ([]TransportPeerObject, error) {
+	root, err := t.introspect(ctx, conn.Object(owner, "/"))
+	if err != nil {
+		return nil, err
+	}
+	var objects []TransportPeerObject
+	for _, child := range root.Children {
+		path := dbus.ObjectPath("/" + child.Name)
+		node, err := t.introspect(ctx, conn.Object(owner, path))
+		if err != nil {
+			return nil, err
+		}
+		obj := TransportPeerObject{Name: child.Name}
+		switch {
+		case hasInterface(node, writeDBusInterface):
+			obj.Type = "config"
+		case hasInterface(node, introspectionIface):
+			obj.Type = introspectionType
+		case hasInterface(node, readDBusInterface):
+			obj.Type = "state"
+		case hasChild(node, "rpc"):
+			obj, err = t.peerRPCObject(ctx, owner, child.Name)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if obj.Type != "" {
+			objects = append(objects, obj)
+		}
+	}
+	return objects, nil
+}
+
+func (t *dbusTransport) peerRPCObject(
+	ctx context.Context,
+	owner, nodeName string,
+) (TransportPeerObject, error) {
+	moduleName := strings.Replace(nodeName, "_", "-", -1)
+	obj := TransportPeerObject{Name: moduleName}
+	rpcs, err := t.introspectModuleRPCs(ctx, owner, moduleName)
+	if err != nil || len(rpcs) == 0 {
+		return obj, err
+	}
+	obj.Type = "rpc"
+	for name := range rpcs {
+		obj.Methods = append(obj.Methods, t.convertDBusNameToYang(name))
+	}
+	return obj, nil
+}
+
+func hasInterface(node *introspect.Node, name string) bool {
+	for _, iface := range node.Interfaces {
+		if iface.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func hasChild(node *introspect.Node, name string) bool {
+	for _, child := range node.Children {
+		if child.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *dbusTransport) StoreConfigByPathInto(
+	ctx context.Context,
+	modelName, path string, encodedData *string,
+) error {
+	obj := t.connection().Object(modelName, "/running")
+	err := t.callContext(ctx, obj, pathDBusInterface+".GetPath", path).
+		Store(encodedData)
+	if err != nil {
+		err = t.processOptionalMethodError(err)
+	}
+	return err
+}
+
+func (t *dbusTransport) StoreStateByPathInto(
+	ctx context.Context,
+	modelName, path string, encodedData *string,
+) error {
+	obj := t.connection().Object(modelName, "/state")
+	err := t.callContext(ctx, obj, pathDBusInterface+".GetPath", path).
+		Store(encodedData)
+	if err != nil {
+		err = t.processOptionalMethodError(err)
+	}
+	return err
+}
+
+func (t *dbusTransport) Export(object TransportObject) error {
 	if !object.IsValid() {
 		if err, ok := object.(error); ok {
 			return err
 		}
 		return errors.New("invalid object")
 	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	err := t.export(t.busMgr, object)
+	if err != nil {
+		return err
+	}
+	t.objects = append(t.objects, object)
+	return nil
+}
+
+func (t *dbusTransport) export(
+	busMgr *objtree.BusManager,
+	object TransportObject,
+) error {
 	switch object.Type() {
 	case "config":
-		return t.exportConfigInterfaces(object)
+		return t.exportConfigInterfaces(busMgr, object)
 	case "state":
-		return t.exportStateInterfaces(object)
+		return t.exportStateInterfaces(busMgr, object)
 	case "rpc":
-		return t.exportRPCInterfaces(object)
+		return t.exportRPCInterfaces(busMgr, object)
+	case introspectionType:
+		return t.exportIntrospectionInterface(busMgr, object)
 	}
 	return nil
 }
 
 func (t *dbusTransport) Close() error {
 	t.removeAllSubscribers()
-	if t.conn == nil {
-		return nil
-	}
-	err := t.conn.Close()
+	t.mu.Lock()
+	conn := t.conn
 	t.conn = nil
 	t.busMgr = nil
-	return err
+	t.closing = true
+	t.identities = nil
+	t.objects = nil
+	t.mu.Unlock()
+	t.rpcCache.stopWatching()
+	if conn == nil {
+		return nil
+	}
+	return conn.Close()
 }
 
 func (t *dbusTransport) mapMethodNames(
@@ -329,32 +1083,257 @@ func (t *dbusTransport) mapMethodNames(
 	return out
 }
 
-func (t *dbusTransport) exportConfigInterfaces(object transportObject) error {
-	busObj := t.busMgr.NewObjectFromTable(
-		dbus.ObjectPath("/"+object.Name()),
-		t.mapMethodNames(object.Methods(), t.convertYangNameToDBus))
+func (t *dbusTransport) exportConfigInterfaces(
+	busMgr *objtree.BusManager,
+	object TransportObject,
+) error {
+	methods := t.mapMethodNames(t.objectMethods(object),
+		t.convertYangNameToDBus)
+	metaMethods := t.writeMetadataMethods(object)
+	for name, method := range metaMethods {
+		methods[name] = method
+	}
+	busObj := busMgr.NewObjectFromTable(
+		dbus.ObjectPath("/"+object.Name()), methods)
 	err := busObj.Implements(readDBusInterface, (*dbusServiceRead)(nil))
 	if err != nil {
 		return err
 	}
-	return busObj.Implements(writeDBusInterface, (*dbusServiceWrite)(nil))
+	err = busObj.Implements(writeDBusInterface, (*dbusServiceWrite)(nil))
+	if err != nil {
+		return err
+	}
+	if len(metaMethods) != 0 {
+		err = busObj.Implements(writeMetaInterface,
+			(*dbusServiceWriteMetadata)(nil))
+		if err != nil {
+			return err
+		}
+	}
+	err = t.exportPathReadInterface(busObj, methods)
+	if err != nil {
+		return err
+	}
+	if _, ok := methods["Prepare"]; !ok {
+		return nil
+	}
+	return busObj.Implements(txnDBusInterface,
+		(*dbusServiceTransaction)(nil))
 }
 
-func (t *dbusTransport) exportStateInterfaces(object transportObject) error {
-	busObj := t.busMgr.NewObjectFromTable(
-		dbus.ObjectPath("/"+object.Name()),
-		t.mapMethodNames(object.Methods(), t.convertYangNameToDBus))
-	return busObj.Implements(readDBusInterface, (*dbusServiceRead)(nil))
+func (t *dbusTransport) exportStateInterfaces(
+	busMgr *objtree.BusManager,
+	object TransportObject,
+) error {
+	methods := t.mapMethodNames(t.objectMethods(object),
+		t.convertYangNameToDBus)
+	busObj := busMgr.NewObjectFromTable(
+		dbus.ObjectPath("/"+object.Name()), methods)
+	err := busObj.Implements(readDBusInterface, (*dbusServiceRead)(nil))
+	if err != nil {
+		return err
+	}
+	return t.exportPathReadInterface(busObj, methods)
+}
+
+func (t *dbusTransport) exportIntrospectionInterface(
+	busMgr *objtree.BusManager,
+	object TransportObject,
+) error {
+	methods := t.mapMethodNames(t.objectMethods(object),
+		t.convertYangNameToDBus)
+	busObj := busMgr.NewObjectFromTable(
+		dbus.ObjectPath("/"+object.Name()), methods)
+	return busObj.Implements(introspectionIface, (*dbusServiceRead)(nil))
+}
+
+// exportPathReadInterface implements the path read interface if the
+// object's Get accepts a path.
+func (t *dbusTransport) exportPathReadInterface(
+	busObj *objtree.Object,
+	methods map[string]interface{},
+) error {
+	if _, ok := methods["GetPath"]; !ok {
+		return nil
+	}
+	return busObj.Implements(pathDBusInterface,
+		(*dbusServicePathRead)(nil))
 }
 
-func (t *dbusTransport) exportRPCInterfaces(object transportObject) error {
+func (t *dbusTransport) exportRPCInterfaces(
+	busMgr *objtree.BusManager,
+	object TransportObject,
+) error {
 	intfName := t.getModuleRPCInterfaceName(object.Name())
 	methods := t.mapMethodNames(object.Methods(), t.convertYangNameToDBus)
-	busObj := t.busMgr.NewObjectFromTable(
+	for name, method := range methods {
+		methods[name] = t.verifyCaller(method)
+	}
+	busObj := busMgr.NewObjectFromTable(
 		t.getModuleRPCObjectPath(object.Name()), methods)
 	return busObj.ImplementsTable(intfName, methods)
 }
 
+// verifyCaller wraps an RPC method so that the metadata the component
+// sees describes the caller as the bus knows it, rather than as the
+// caller claims to be. The sender is supplied by godbus and is not part
+// of the method's signature on the bus.
+func (t *dbusTransport) verifyCaller(method interface{}) interface{} {
+	fn, ok := method.(func(string, string) (string, error))
+	if !ok {
+		return method
+	}
+	return func(sender dbus.Sender, meta, input string) (string, error) {
+		meta, err := t.verifiedMetadata(sender, meta)
+		if err != nil {
+			return "", err
+		}
+		return fn(meta, input)
+	}
+}
+
+// verifiedMetadata returns the metadata, supplied by the sender of a
+// call, that the component should see.
+func (t *dbusTransport) verifiedMetadata(
+	sender dbus.Sender,
+	meta string,
+) (string, error) {
+	peer, err := t.callerMetadata(string(sender))
+	if err != nil {
+		return "", err
+	}
+	var supplied RPCMetadata
+	if meta != "" {
+		err = decodeMetadata(meta, &supplied)
+		if err != nil {
+			return "", mgmterror.NewMalformedMessageError()
+		}
+	}
+	return encodeMetadata(verifyMetadata(supplied, peer))
+}
+
+// writeMetadataMethods returns the methods of the write metadata
+// interface for an object that wants to know who is calling. They take
+// the metadata supplied by the caller, verified as for RPCs.
+func (t *dbusTransport) writeMetadataMethods(
+	object TransportObject,
+) map[string]interface{} {
+	callerObj, ok := object.(TransportCallerObject)
+	if !ok {
+		return nil
+	}
+	callerMethods := callerObj.CallerMethods()
+	methods := make(map[string]interface{})
+	for _, name := range []string{"set", "check"} {
+		fn, ok := callerMethods[name].(func(string, string) error)
+		if !ok {
+			continue
+		}
+		methods[t.convertYangNameToDBus(name)+"WithMetadata"] =
+			func(sender dbus.Sender, meta, input string) error {
+				meta, err := t.verifiedMetadata(sender, meta)
+				if err != nil {
+					return err
+				}
+				return fn(meta, input)
+			}
+	}
+	return methods
+}
+
+// objectMethods returns the configuration or state methods to expose
+// for the object, telling them who is calling if they want to know.
+func (t *dbusTransport) objectMethods(
+	object TransportObject,
+) map[string]interface{} {
+	callerObj, ok := object.(TransportCallerObject)
+	if !ok {
+		return object.Methods()
+	}
+	methods := make(map[string]interface{})
+	for name, method := range callerObj.CallerMethods() {
+		methods[name] = t.withCaller(method)
+	}
+	return methods
+}
+
+// withCaller wraps one of the methods of a TransportCallerObject so
+// that it is given the caller's metadata, established from the sender.
+func (t *dbusTransport) withCaller(method interface{}) interface{} {
+	caller := func(sender dbus.Sender) (string, error) {
+		peer, err := t.callerMetadata(string(sender))
+		if err != nil {
+			return "", err
+		}
+		return encodeMetadata(peer)
+	}
+
+	switch fn := method.(type) {
+	case func(string) (string, error):
+		return func(sender dbus.Sender) (string, error) {
+			meta, err := caller(sender)
+			if err != nil {
+				return "", err
+			}
+			return fn(meta)
+		}
+	case func(string, string) (string, error):
+		return func(sender dbus.Sender, input string) (string, error) {
+			meta, err := caller(sender)
+			if err != nil {
+				return "", err
+			}
+			return fn(meta, input)
+		}
+	case func(string) error:
+		return func(sender dbus.Sender) error {
+			meta, err := caller(sender)
+			if err != nil {
+				return err
+			}
+			return fn(meta)
+		}
+	case func(string, string) error:
+		return func(sender dbus.Sender, input string) error {
+			meta, err := caller(sender)
+			if err != nil {
+				return err
+			}
+			return fn(meta, input)
+		}
+	}
+	return method
+}
+
+// peerMetadata describes the owner of a connection to the bus from the
+// credentials the bus holds for it. Older buses without
+// GetConnectionCredentials are asked for the user and process ids
+// separately, and the user's groups are looked up instead.
+func (t *dbusTransport) peerMetadata(sender string) (RPCMetadata, error) {
+	busObj := t.connection().BusObject()
+
+	var creds map[string]dbus.Variant
+	err := busObj.Call(fdtGetCredentials, 0, sender).Store(&creds)
+	if err == nil {
+		uid, ok := creds["UnixUserID"].Value().(uint32)
+		if !ok {
+			return RPCMetadata{}, mgmterror.NewAccessDeniedApplicationError()
+		}
+		pid, _ := creds["ProcessID"].Value().(uint32)
+		gids, _ := creds["UnixGroupIDs"].Value().([]uint32)
+		return newPeerMetadata(int32(pid), uid, gids), nil
+	}
+
+	var uid, pid uint32
+	err = busObj.Call(fdtGetUnixUser, 0, sender).Store(&uid)
+	if err != nil {
+		return RPCMetadata{}, mgmterror.NewAccessDeniedApplicationError()
+	}
+	// The process id is informational, not all platforms provide it.
+	_ = busObj.Call(fdtGetUnixPID, 0, sender).Store(&pid)
+	return newPeerMetadata(int32(pid), uid, nil), nil
+}
+
 func (t *dbusTransport) getModuleRPCInterfaceName(moduleName string) string {
 	return yangModuleDBusPfx + "." +
 		t.convertYangNameToDBus(moduleName) + ".RPC"
@@ -380,6 +1359,16 @@ func (t *dbusTransport) getModuleNotificationObjectPath(
 		strings.Replace(moduleName, "-", "_", -1) + "/notification")
 }
 
+// getModelStateObjectPath returns the path that state change signals
+// for the model are emitted from, such as /net/vyatta/test/v1/state
+// for the model net.vyatta.test.v1.
+func (t *dbusTransport) getModelStateObjectPath(
+	modelName string,
+) dbus.ObjectPath {
+	path := strings.NewReplacer(".", "/", "-", "_").Replace(modelName)
+	return dbus.ObjectPath("/" + path + "/state")
+}
+
 func (t *dbusTransport) convertYangNameToDBus(name string) string {
 	var afterHyphen bool
 	var buf []byte
@@ -398,7 +1387,25 @@ func (t *dbusTransport) convertYangNameToDBus(name string) string {
 	return b.String()
 }
 
+// convertDBusNameToYang reverses convertYangNameToDBus for YANG names
+// that are lower case, as they usually are.
+func (t *dbusTransport) convertDBusNameToYang(name string) string {
+	var buf []byte
+	b := bytes.NewBuffer(buf)
+	for i, r := range name {
+		if unicode.IsUpper(r) {
+			if i != 0 {
+				b.WriteRune('-')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
 func (t *dbusTransport) getDestinationByModuleName(
+	ctx context.Context,
 	moduleName string,
 ) (string, error) {
 	var data string
@@ -414,7 +1421,8 @@ func (t *dbusTransport) getDestinationByModuleName(
 	if data, err = marshaller.Marshal(in); err != nil {
 		return "", mgmterror.NewMalformedMessageError()
 	}
-	out, err = t.callYangdRPC("lookup-rpc-destination-by-module-name", data)
+	out, err = t.callYangdRPC(ctx,
+		"lookup-rpc-destination-by-module-name", data)
 	if err != nil {
 		return "", err
 	}
@@ -432,6 +1440,31 @@ func (t *dbusTransport) genYangdRPCMethodName(rpcName string) string {
 		"." + t.convertYangNameToDBus(rpcName)
 }
 
+// isUnknownMethodError reports whether the error is the bus telling us
+// the object does not implement the method called.
+func isUnknownMethodError(err error) bool {
+	dbuserr, ok := err.(dbus.Error)
+	if !ok {
+		return false
+	}
+	switch dbuserr.Name {
+	case "org.freedesktop.DBus.Error.UnknownInterface",
+		"org.freedesktop.DBus.Error.UnknownMethod":
+		return true
+	}
+	return false
+}
+
+// processOptionalMethodError is used for calls to optional interfaces,
+// such as transactions, where an object that exists but does not
+// implement the method is reported as unsupported.
+func (t *dbusTransport) processOptionalMethodError(err error) error {
+	if isUnknownMethodError(err) {
+		return mgmterror.NewOperationNotSupportedApplicationError()
+	}
+	return t.processError(err)
+}
+
 func (t *dbusTransport) processErrorIgnoreUnsupported(err error) error {
 	return t.processErrorInternal(err, true)
 }
@@ -461,47 +1494,92 @@ func (t *dbusTransport) processErrorInternal(
 	}
 	return err
 }
-func (t *dbusTransport) callYangdRPC(name, input string) (string, error) {
+func (t *dbusTransport) callYangdRPC(
+	ctx context.Context,
+	name, input string,
+) (string, error) {
 	var output string
-	obj := t.conn.Object(yangdName, yangdRPCPath)
-	err := obj.Call(t.genYangdRPCMethodName(name), 0, "{}", input).Store(&output)
+	obj := t.connection().Object(yangdName, yangdRPCPath)
+	err := t.callContext(ctx, obj, t.genYangdRPCMethodName(name),
+		"{}", input).Store(&output)
 	if err != nil {
 		err = t.processError(err)
 	}
 	return output, err
 }
 
+// callContext performs a method call on the bus, giving up if the context
+// expires before the reply arrives. The reply to an abandoned call is
+// discarded when it eventually arrives.
+func (t *dbusTransport) callContext(
+	ctx context.Context,
+	obj dbus.BusObject,
+	method string,
+	args ...interface{},
+) *dbus.Call {
+	call := obj.Go(method, 0, nil, args...)
+	select {
+	case call = <-call.Done:
+		return call
+	case <-ctx.Done():
+		return &dbus.Call{Err: ctx.Err()}
+	}
+}
+
+func (t *dbusTransport) introspect(
+	ctx context.Context,
+	obj dbus.BusObject,
+) (*introspect.Node, error) {
+	var xmldata string
+	var node introspect.Node
+	err := t.callContext(ctx, obj, fdtIntrospect).Store(&xmldata)
+	if err != nil {
+		return nil, err
+	}
+	err = xml.NewDecoder(strings.NewReader(xmldata)).Decode(&node)
+	if err != nil {
+		return nil, err
+	}
+	return &node, nil
+}
+
 func (t *dbusTransport) isDBusRPC(
+	ctx context.Context,
 	modelName, moduleName, dbusRPCName string,
 ) bool {
-	obj := t.conn.Object(modelName, t.getModuleRPCObjectPath(moduleName))
-	node, err := introspect.Call(obj)
+	rpcs, err := t.moduleRPCs(ctx, modelName, moduleName)
 	if err != nil {
 		return false
 	}
-	for _, iface := range node.Interfaces {
-		if iface.Name == t.getModuleRPCInterfaceName(moduleName) {
-			return t.findMethod(iface.Methods, dbusRPCName)
-		}
-	}
-	return false
+	return rpcs[dbusRPCName]
 }
 
-func (t *dbusTransport) findMethod(
-	methods []introspect.Method,
-	name string,
-) bool {
-	for _, method := range methods {
-		if method.Name == name {
-			return true
+// introspectModuleRPCs returns the D-Bus names of the RPCs the
+// destination implements for the module.
+func (t *dbusTransport) introspectModuleRPCs(
+	ctx context.Context,
+	dest, moduleName string,
+) (map[string]bool, error) {
+	obj := t.connection().Object(dest, t.getModuleRPCObjectPath(moduleName))
+	node, err := t.introspect(ctx, obj)
+	if err != nil {
+		return nil, err
+	}
+	rpcs := make(map[string]bool)
+	for _, iface := range node.Interfaces {
+		if iface.Name != t.getModuleRPCInterfaceName(moduleName) {
+			continue
+		}
+		for _, method := range iface.Methods {
+			rpcs[method.Name] = true
 		}
 	}
-	return false
+	return rpcs, nil
 }
 
 func (t *dbusTransport) addSubscriber(
 	name string,
-	subscriber transportSubscriber,
+	subscriber TransportSubscriber,
 ) {
 	t.signalHandlers.mu.Lock()
 	defer t.signalHandlers.mu.Unlock()
@@ -519,12 +1597,12 @@ func (t *dbusTransport) addSubscriber(
 
 func (t *dbusTransport) removeSubscriber(
 	name string,
-	subscriber transportSubscriber,
+	subscriber TransportSubscriber,
 ) int {
 	t.signalHandlers.mu.Lock()
 	defer t.signalHandlers.mu.Unlock()
 	subs := t.signalHandlers.handlers[name]
-	newSubs := make([]transportSubscriber, 0, len(subs))
+	newSubs := make([]TransportSubscriber, 0, len(subs))
 	for _, sub := range subs {
 		if sub == subscriber {
 			continue
@@ -538,7 +1616,7 @@ func (t *dbusTransport) removeSubscriber(
 func (t *dbusTransport) removeAllSubscribers() {
 	t.signalHandlers.mu.Lock()
 	defer t.signalHandlers.mu.Unlock()
-	t.signalHandlers.handlers = make(map[string][]transportSubscriber)
+	t.signalHandlers.handlers = make(map[string][]TransportSubscriber)
 }
 
 // Extracts an embedded MgmtError from a dbus.Error body
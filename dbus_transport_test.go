@@ -7,8 +7,20 @@
 package vci
 
 import (
-	godbus "github.com/godbus/dbus"
+	"bufio"
+	"context"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"sync/atomic"
+	"syscall"
 	"testing"
+	"time"
+
+	"github.com/danos/mgmterror"
+	"github.com/danos/vci/internal/queue"
+	godbus "github.com/godbus/dbus"
 )
 
 func TestGenDBusName(t *testing.T) {
@@ -54,8 +66,713 @@ func TestDBusRPCNameMappings(t *testing.T) {
 }
 
 func TestDBusTransportSemantics(t *testing.T) {
-	setDefaultTransportConstructor(func() transporter {
+	setDefaultTransportConstructor(func() Transport {
 		return newDBusSessionTransport()
 	})
 	testTransportSemantics(t, newDBusSessionTransport())
 }
+
+type testDBusDaemon struct {
+	address string
+	cmd     *exec.Cmd
+}
+
+func startTestDBusDaemon(t *testing.T, address string) *testDBusDaemon {
+	cmd := exec.Command("dbus-daemon", "--session", "--nofork",
+		"--address="+address, "--print-address")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = cmd.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The address is printed once the daemon is accepting connections.
+	_, err = bufio.NewReader(stdout).ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testDBusDaemon{address: address, cmd: cmd}
+}
+
+func (d *testDBusDaemon) stop() {
+	_ = d.cmd.Process.Signal(syscall.SIGTERM)
+	_ = d.cmd.Wait()
+}
+
+func TestDBusTransportReconnect(t *testing.T) {
+	if _, err := exec.LookPath("dbus-daemon"); err != nil {
+		t.Skip("dbus-daemon is not available")
+	}
+	testModel := "net.vyatta.test"
+	ctx := context.Background()
+	address := "unix:path=" + filepath.Join(t.TempDir(), "bus")
+	daemon := startTestDBusDaemon(t, address)
+	defer func() { daemon.stop() }()
+
+	transport := newDBusAddressTransport(address)
+	events := make(chan ReconnectEvent, 16)
+	newClient().withTransport(transport).OnReconnect(
+		func(ev ReconnectEvent) {
+			events <- ev
+		})
+	err := transport.Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer transport.Close()
+	err = transport.Export(newConfig(&testRunningConfig{},
+		newClient().withTransport(transport)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = transport.RequestIdentity(testModel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub := newTestSubscriber(queue.NewUnbounded())
+	err = transport.Subscribe("foo-v1", "bar", sub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	daemon.stop()
+	daemon = startTestDBusDaemon(t, address)
+
+	timeout := time.After(10 * time.Second)
+	for reconnected := false; !reconnected; {
+		select {
+		case ev := <-events:
+			if ev.Attempt == 0 && ev.Err != ErrDisconnected {
+				t.Fatalf("expected disconnect event, got %v", ev)
+			}
+			reconnected = ev.Attempt > 0 && ev.Err == nil
+		case <-timeout:
+			t.Fatal("transport did not reconnect")
+		}
+	}
+
+	peer := newDBusAddressTransport(address)
+	err = peer.Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer peer.Close()
+
+	t.Run("identity-and-objects", func(t *testing.T) {
+		var out string
+		err := peer.StoreConfigByModelInto(ctx, testModel, &out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		exp := `{"value":"foo bar"}`
+		if out != exp {
+			t.Fatalf("expected %q, got %q", exp, out)
+		}
+	})
+	t.Run("subscriptions", func(t *testing.T) {
+		notif := `{"baz":"quux"}`
+		err := peer.Emit(ctx, "foo-v1", "bar", notif)
+		if err != nil {
+			t.Fatal(err)
+		}
+		vals := make(chan interface{}, 1)
+		go func() { vals <- sub.queue.Dequeue() }()
+		select {
+		case val := <-vals:
+			if val != notif {
+				t.Fatalf("expected %q, got %q", notif, val)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("didn't receive expected notification")
+		}
+	})
+}
+
+func TestDBusDeliverMalformedSignal(t *testing.T) {
+	transport := newDBusTransport()
+	sub := newTestSubscriber(queue.NewUnbounded())
+	rule := transport.signalMatchRule("yang.module.FooV1.Notification", "Bar")
+	transport.signalHandlers.handlers[rule] =
+		[]TransportSubscriber{sub}
+
+	bodies := [][]interface{}{
+		nil,
+		{1},
+		{"foo", "bar"},
+	}
+	for _, body := range bodies {
+		transport.DeliverSignal("yang.module.FooV1.Notification", "Bar",
+			&godbus.Signal{Body: body})
+		if val := sub.queue.Dequeue(); val != errMalformedSignal {
+			t.Fatalf("expected malformed signal for %v, got %v",
+				body, val)
+		}
+	}
+	transport.DeliverSignal("yang.module.FooV1.Notification", "Bar",
+		&godbus.Signal{Body: []interface{}{`{"baz":"quux"}`}})
+	if val := sub.queue.Dequeue(); val != `{"baz":"quux"}` {
+		t.Fatalf("expected notification, got %v", val)
+	}
+}
+
+func TestDBusDeliverStateChangeSignal(t *testing.T) {
+	const path = "/foo-v1:routes/route[prefix='10.0.0.0/8']"
+	transport := newDBusTransport()
+	sub := newTestSubscriber(queue.NewUnbounded())
+	objPath := transport.getModelStateObjectPath("net.vyatta.test.v1")
+	transport.signalHandlers.handlers[transport.stateSignalMatchRule(objPath)] =
+		[]TransportSubscriber{sub}
+
+	bodies := [][]interface{}{
+		nil,
+		{`{"value":"foo bar"}`},
+		{`{"value":"foo bar"}`, 1},
+	}
+	for _, body := range bodies {
+		transport.DeliverSignal(stateDBusInterface, stateChangedSignal,
+			&godbus.Signal{Path: objPath, Body: body})
+		if val := sub.queue.Dequeue(); val != errMalformedSignal {
+			t.Fatalf("expected malformed signal for %v, got %v",
+				body, val)
+		}
+	}
+	transport.DeliverSignal(stateDBusInterface, stateChangedSignal,
+		&godbus.Signal{
+			Path: objPath,
+			Body: []interface{}{`{"value":"foo bar"}`, path},
+		})
+	exp := stateChange{path: path, state: `{"value":"foo bar"}`}
+	if val := sub.queue.Dequeue(); val != exp {
+		t.Fatalf("expected state change, got %v", val)
+	}
+}
+
+func TestDBusRPCCallerCredentials(t *testing.T) {
+	const (
+		model  = "net.vyatta.test.credentials"
+		module = "credentials-v1"
+	)
+	component := newDBusSessionTransport()
+	err := component.Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer component.Close()
+	err = component.RequestIdentity(model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = component.Export(newRPC(module,
+		map[string]interface{}{
+			"whoami": func(meta RPCMetadata, in string) (string, error) {
+				return encodeMetadata(meta)
+			},
+		},
+		newClient().withTransport(component)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	caller := newDBusSessionTransport()
+	err = caller.Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer caller.Close()
+	whoami := func(supplied RPCMetadata) RPCMetadata {
+		meta, err := encodeMetadata(supplied)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out string
+		err = caller.connection().
+			Object(model, caller.getModuleRPCObjectPath(module)).
+			Call(caller.getModuleRPCInterfaceName(module)+".Whoami",
+				0, meta, "{}").
+			Store(&out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got RPCMetadata
+		err = decodeMetadata(out, &got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+
+	got := whoami(RPCMetadata{})
+	if got.Uid != uint32(os.Getuid()) || got.Pid != int32(os.Getpid()) {
+		t.Fatalf("unexpected caller %+v", got)
+	}
+	if u, err := user.Current(); err == nil && got.User != u.Username {
+		t.Fatalf("expected user %q, got %q", u.Username, got.User)
+	}
+
+	spoofed := RPCMetadata{Uid: 1234, User: "mallory"}
+	got = whoami(spoofed)
+	if os.Getuid() == 0 {
+		if got.User != spoofed.User {
+			t.Fatalf("root's metadata was not trusted: %+v", got)
+		}
+	} else if got.User == spoofed.User || got.Uid == spoofed.Uid {
+		t.Fatalf("spoofed metadata was trusted: %+v", got)
+	}
+}
+
+func TestDBusConfigCallerCredentials(t *testing.T) {
+	const model = "net.vyatta.test.credentials"
+	ctx := context.Background()
+	component := newDBusSessionTransport()
+	err := component.Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer component.Close()
+	err = component.RequestIdentity(model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := &testInterceptorLog{}
+	cfg, err := newInterceptedObject(model,
+		newConfig(&testRunningConfigWithValue{testConfig{Value: "foo"}},
+			newClient().withTransport(component)),
+		[]Interceptor{log.intercept})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = component.Export(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	caller := newDBusSessionTransport()
+	err = caller.Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer caller.Close()
+
+	checkCaller := func(t *testing.T, method string) {
+		inv, _ := log.last()
+		if inv.Method != method {
+			t.Fatalf("expected %s, got %+v", method, inv)
+		}
+		if inv.Metadata.Uid != uint32(os.Getuid()) ||
+			inv.Metadata.Pid != int32(os.Getpid()) {
+			t.Fatalf("unexpected caller %+v", inv.Metadata)
+		}
+	}
+	t.Run("get", func(t *testing.T) {
+		var out string
+		err := caller.StoreConfigByModelInto(ctx, model, &out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkCaller(t, "get")
+	})
+	t.Run("set", func(t *testing.T) {
+		err := caller.SetConfigForModel(ctx, model, `{"value":"bar"}`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkCaller(t, "set")
+	})
+	t.Run("traced set", func(t *testing.T) {
+		sc, err := ParseTraceparent(testTraceparent, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = caller.SetConfigForModel(ContextWithSpanContext(ctx, sc),
+			model, `{"value":"baz"}`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkCaller(t, "set")
+		inv, _ := log.last()
+		if inv.Metadata.Traceparent != testTraceparent {
+			t.Fatalf("trace context was not passed: %+v", inv.Metadata)
+		}
+	})
+}
+
+func TestDBusTracedSetWithoutMetadata(t *testing.T) {
+	const model = "net.vyatta.test.untraced"
+	component := newDBusSessionTransport()
+	err := component.Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer component.Close()
+	err = component.RequestIdentity(model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &testRunningConfigWithValue{testConfig{Value: "foo"}}
+	err = component.Export(newConfig(cfg,
+		newClient().withTransport(component)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	caller := newDBusSessionTransport()
+	err = caller.Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer caller.Close()
+
+	// The component does not accept metadata so is sent the
+	// configuration alone.
+	sc, err := ParseTraceparent(testTraceparent, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = caller.SetConfigForModel(
+		ContextWithSpanContext(context.Background(), sc),
+		model, `{"value":"bar"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Value != "bar" {
+		t.Fatalf("configuration was not set: %q", cfg.Value)
+	}
+}
+
+func TestDBusIntrospection(t *testing.T) {
+	const name = "net.vyatta.test.introspected"
+	comp := newComponent(name, newDBusSessionTransport())
+	log := &testInterceptorLog{}
+	comp.interceptors = []Interceptor{log.intercept}
+	comp.Model(name + ".v1").Config(&testRunningConfigWithValue{})
+	err := comp.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer comp.Stop()
+
+	client := newClient().withTransport(newDBusSessionTransport()).dial()
+	defer client.Close()
+	info, err := client.Introspect(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Name != name || len(info.Models) != 1 ||
+		info.Models[0].Name != name+".v1" {
+		t.Fatalf("unexpected component info %+v", info)
+	}
+	inv, _ := log.last()
+	if inv.Kind != introspectionType || inv.ModelName != name ||
+		inv.Metadata.Pid != int32(os.Getpid()) {
+		t.Fatalf("introspection was not intercepted: %+v", inv)
+	}
+}
+
+func TestDBusSubscribeModule(t *testing.T) {
+	tport := newDBusSessionTransport()
+	err := tport.Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tport.Close()
+	client := newClient().withTransport(tport)
+	names := make(chan string, 1)
+	sub := client.SubscribeModule("test-mod1-v1",
+		func(name string, in string) {
+			names <- name
+		}).SkipValidation()
+	err = sub.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Cancel()
+
+	err = tport.Emit(context.Background(), "test-mod1-v1",
+		"link-state-changed", "{}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case name := <-names:
+		if name != "link-state-changed" {
+			t.Fatalf("unexpected notification name %q", name)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("didn't receive notification")
+	}
+}
+
+func TestDBusListComponents(t *testing.T) {
+	const name = "net.vyatta.test.listed"
+	comp := newComponent(name, newDBusSessionTransport())
+	comp.Model(name+".v1").
+		Config(&testRunningConfigWithValue{}).
+		RPC("test-listed-v1", map[string]interface{}{
+			"get-value": func(in *testConfig) (*testConfig, error) {
+				return in, nil
+			},
+		})
+	err := comp.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer comp.Stop()
+
+	tport := newDBusSessionTransport()
+	client := newClient().withTransport(tport).dial()
+	defer client.Close()
+
+	peers, err := tport.ListPeers(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var peer *TransportPeer
+	for i := range peers {
+		if len(peers[i].Names) != 0 && peers[i].Names[0] == name {
+			peer = &peers[i]
+		}
+	}
+	if peer == nil {
+		t.Fatalf("component not found in %+v", peers)
+	}
+	objects := make(map[string]TransportPeerObject)
+	for _, obj := range peer.Objects {
+		objects[obj.Name] = obj
+	}
+	if objects["running"].Type != "config" ||
+		objects[introspectionObjectName].Type != introspectionType {
+		t.Fatalf("unexpected objects %+v", peer.Objects)
+	}
+	rpc := objects["test-listed-v1"]
+	if rpc.Type != "rpc" || len(rpc.Methods) != 1 ||
+		rpc.Methods[0] != "get-value" {
+		t.Fatalf("unexpected RPC object %+v", rpc)
+	}
+
+	rpcs, err := client.ListRPCs("test-listed-v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rpcs) != 1 || rpcs[0].Model != name+".v1" {
+		t.Fatalf("unexpected RPCs %+v", rpcs)
+	}
+}
+
+type testCountingYangd struct {
+	*testYangService
+	lookups int32
+}
+
+func (ys *testCountingYangd) LookupRpcDestinationByModuleName(
+	encodedData string,
+) (map[string]interface{}, error) {
+	atomic.AddInt32(&ys.lookups, 1)
+	return ys.testYangService.LookupRpcDestinationByModuleName(encodedData)
+}
+
+func runTestCachedComponent(t *testing.T, address, name string) *component {
+	comp := newComponent(name, newDBusAddressTransport(address))
+	comp.Model(name+".v1").
+		RPC("test-cached-v1", map[string]interface{}{
+			"echo": func(in string) (string, error) {
+				return in, nil
+			},
+		})
+	err := comp.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return comp
+}
+
+func TestDBusRPCCache(t *testing.T) {
+	if _, err := exec.LookPath("dbus-daemon"); err != nil {
+		t.Skip("dbus-daemon is not available")
+	}
+	// A bus of our own, as the session bus may already have a yangd.
+	address := "unix:path=" + filepath.Join(t.TempDir(), "bus")
+	daemon := startTestDBusDaemon(t, address)
+	defer daemon.stop()
+
+	const name = "net.vyatta.test.cached"
+	ys := &testCountingYangd{testYangService: newTestYangService()}
+	ys.mapping["test-cached-v1"] = name + ".v1"
+	yangd := newComponent(testYangServiceName,
+		newDBusAddressTransport(address))
+	yangd.Model(testYangServiceModel).RPC(testYangServiceModule, ys)
+	err := yangd.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer yangd.Stop()
+
+	comp := runTestCachedComponent(t, address, name)
+	defer func() { comp.Stop() }()
+
+	tport := newDBusAddressTransport(address)
+	err = tport.Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tport.Close()
+	call := func(rpcName string) error {
+		promise, err := tport.Call(context.Background(),
+			"test-cached-v1", rpcName, "{}", `"hello"`)
+		if err != nil {
+			return err
+		}
+		var out string
+		return promise.StoreOutputInto(context.Background(), &out)
+	}
+
+	for i := 0; i < 3; i++ {
+		err = call("echo")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := atomic.LoadInt32(&ys.lookups); n != 1 {
+		t.Fatalf("expected 1 destination lookup, got %d", n)
+	}
+	if _, ok := tport.rpcCache.rpcs(name+".v1", "test-cached-v1"); !ok {
+		t.Fatal("RPCs were not cached")
+	}
+
+	// Restarting the component changes the owner of its names.
+	comp.Stop()
+	comp = runTestCachedComponent(t, address, name)
+	deadline := time.Now().Add(time.Second)
+	for {
+		_, ok := tport.rpcCache.destination("test-cached-v1")
+		if !ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("destination was not forgotten")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	err = call("echo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(&ys.lookups); n != 2 {
+		t.Fatalf("expected 2 destination lookups, got %d", n)
+	}
+
+	err = call("missing")
+	if err == nil {
+		t.Fatal("called an RPC the component does not implement")
+	}
+
+	tport.skipRPCIntrospection = true
+	err = call("missing")
+	if _, ok := err.(*mgmterror.OperationNotSupportedApplicationError); !ok {
+		t.Fatalf("expected operation-not-supported, got %T %v", err, err)
+	}
+}
+
+func TestRPCCacheForget(t *testing.T) {
+	c := &rpcCache{}
+	c.startWatching()
+	generation := c.beginLookup("net.vyatta.test.one")
+	c.storeRPCs(generation, "net.vyatta.test.one", "test-v1",
+		map[string]bool{"Ping": true})
+	c.endLookup("net.vyatta.test.one")
+
+	c.forget(":1.42")
+	if c.generation != generation {
+		t.Fatal("forgetting an unknown name abandoned lookups")
+	}
+	c.forget("net.vyatta.test.one")
+	if c.generation == generation {
+		t.Fatal("forgetting a cached name did not abandon lookups")
+	}
+	if _, ok := c.rpcs("net.vyatta.test.one", "test-v1"); ok {
+		t.Fatal("RPCs were not forgotten")
+	}
+
+	generation = c.beginLookup("net.vyatta.test.two")
+	c.forget("net.vyatta.test.two")
+	c.storeRPCs(generation, "net.vyatta.test.two", "test-v1",
+		map[string]bool{"Ping": true})
+	c.endLookup("net.vyatta.test.two")
+	if _, ok := c.rpcs("net.vyatta.test.two", "test-v1"); ok {
+		t.Fatal("stored the result of a lookup begun before the change")
+	}
+}
+
+func TestDBusCallerCache(t *testing.T) {
+	if _, err := exec.LookPath("dbus-daemon"); err != nil {
+		t.Skip("dbus-daemon is not available")
+	}
+	address := "unix:path=" + filepath.Join(t.TempDir(), "bus")
+	daemon := startTestDBusDaemon(t, address)
+	defer daemon.stop()
+
+	const (
+		model  = "net.vyatta.test.callers"
+		module = "callers-v1"
+	)
+	component := newDBusAddressTransport(address)
+	err := component.Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer component.Close()
+	err = component.RequestIdentity(model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = component.Export(newRPC(module,
+		map[string]interface{}{
+			"whoami": func(meta RPCMetadata, in string) (string, error) {
+				return encodeMetadata(meta)
+			},
+		},
+		newClient().withTransport(component)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	caller := newDBusAddressTransport(address)
+	err = caller.Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sender := caller.connection().Names()[0]
+	for i := 0; i < 2; i++ {
+		var out string
+		err = caller.connection().
+			Object(model, caller.getModuleRPCObjectPath(module)).
+			Call(caller.getModuleRPCInterfaceName(module)+".Whoami",
+				0, "", `"hello"`).
+			Store(&out)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	meta, ok := component.rpcCache.caller(sender)
+	if !ok {
+		t.Fatal("caller was not cached")
+	}
+	if meta.Uid != uint32(os.Getuid()) {
+		t.Fatalf("unexpected caller %+v", meta)
+	}
+
+	// The caller's unique name goes away with its connection.
+	caller.Close()
+	deadline := time.Now().Add(time.Second)
+	for {
+		_, ok := component.rpcCache.caller(sender)
+		if !ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("caller was not forgotten")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,235 @@
+// Copyright (c) 2026, AT&T Intellectual Property. All rights reserved.
+//
+// SPDX-License-Identifier: MPL-2.0
+
+package vci
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+)
+
+// ComponentDescription describes a component found on the bus.
+type ComponentDescription struct {
+	// Name is the component's name, which is empty if the component
+	// neither reports it nor owns a name that is a prefix of its
+	// models' names.
+	Name   string             `rfc7951:"name"`
+	Models []ModelDescription `rfc7951:"models"`
+}
+
+// ModelDescription describes a model found on the bus: whether it has
+// configuration and state and the RPCs it implements.
+type ModelDescription struct {
+	Name      string          `rfc7951:"name"`
+	Component string          `rfc7951:"component"`
+	Config    bool            `rfc7951:"config"`
+	State     bool            `rfc7951:"state"`
+	RPCs      []RPCModuleInfo `rfc7951:"rpcs"`
+}
+
+// RPCDescription names an RPC of a YANG module and the model that
+// implements it.
+type RPCDescription struct {
+	Module string `rfc7951:"module"`
+	Name   string `rfc7951:"name"`
+	Model  string `rfc7951:"model"`
+}
+
+// peerLister is implemented by transports that can list the peers
+// connected to them.
+type peerLister interface {
+	ListPeers(ctx context.Context) ([]TransportPeer, error)
+}
+
+// ListComponents finds the components on the bus and describes their
+// models, without needing to know their names or those of their YANG
+// modules.
+func (c *Client) ListComponents() ([]ComponentDescription, error) {
+	return c.ListComponentsContext(context.Background())
+}
+
+// ListComponentsContext is the same as ListComponents but the supplied
+// context bounds the lifetime of the calls.
+func (c *Client) ListComponentsContext(
+	ctx context.Context,
+) ([]ComponentDescription, error) {
+	lister, ok := c.transport.(peerLister)
+	if !ok {
+		return nil, errors.New("transport does not support discovery")
+	}
+	peers, err := lister.ListPeers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	comps := make([]ComponentDescription, 0, len(peers))
+	for _, peer := range peers {
+		comp, ok := c.describePeer(ctx, peer)
+		if ok {
+			comps = append(comps, comp)
+		}
+	}
+	sort.Slice(comps, func(i, j int) bool {
+		return comps[i].Name < comps[j].Name
+	})
+	return comps, nil
+}
+
+// ListModels finds the models on the bus, in the order of their names.
+func (c *Client) ListModels() ([]ModelDescription, error) {
+	return c.ListModelsContext(context.Background())
+}
+
+// ListModelsContext is the same as ListModels but the supplied context
+// bounds the lifetime of the calls.
+func (c *Client) ListModelsContext(
+	ctx context.Context,
+) ([]ModelDescription, error) {
+	comps, err := c.ListComponentsContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var models []ModelDescription
+	for _, comp := range comps {
+		models = append(models, comp.Models...)
+	}
+	sort.Slice(models, func(i, j int) bool {
+		return models[i].Name < models[j].Name
+	})
+	return models, nil
+}
+
+// ListRPCs finds the RPCs of a YANG module and the models on the bus
+// that implement them.
+func (c *Client) ListRPCs(moduleName string) ([]RPCDescription, error) {
+	return c.ListRPCsContext(context.Background(), moduleName)
+}
+
+// ListRPCsContext is the same as ListRPCs but the supplied context bounds
+// the lifetime of the calls.
+func (c *Client) ListRPCsContext(
+	ctx context.Context,
+	moduleName string,
+) ([]RPCDescription, error) {
+	models, err := c.ListModelsContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var rpcs []RPCDescription
+	for _, model := range models {
+		for _, module := range model.RPCs {
+			if module.Module != moduleName {
+				continue
+			}
+			for _, method := range module.Methods {
+				rpcs = append(rpcs, RPCDescription{
+					Module: moduleName,
+					Name:   method,
+					Model:  model.Name,
+				})
+			}
+		}
+	}
+	sort.SliceStable(rpcs, func(i, j int) bool {
+		return rpcs[i].Name < rpcs[j].Name
+	})
+	return rpcs, nil
+}
+
+// describePeer describes the component behind a peer. A component that
+// can be introspected describes itself. Any other is described from the
+// objects it exports, which its models share, and it is not a component
+// if it exports none.
+func (c *Client) describePeer(
+	ctx context.Context,
+	peer TransportPeer,
+) (ComponentDescription, bool) {
+	if len(peer.Names) == 0 {
+		return ComponentDescription{}, false
+	}
+	model := ModelDescription{}
+	introspectable := false
+	for _, obj := range peer.Objects {
+		switch obj.Type {
+		case "config":
+			model.Config = true
+		case "state":
+			model.State = true
+		case "rpc":
+			methods := append([]string(nil), obj.Methods...)
+			sort.Strings(methods)
+			model.RPCs = append(model.RPCs, RPCModuleInfo{
+				Module:  obj.Name,
+				Methods: methods,
+			})
+		case introspectionType:
+			introspectable = true
+		}
+	}
+	if introspectable {
+		info, err := c.IntrospectContext(ctx, peer.Names[0])
+		if err == nil {
+			return describeIntrospected(info), true
+		}
+	}
+	if !model.Config && !model.State && len(model.RPCs) == 0 {
+		return ComponentDescription{}, false
+	}
+	sort.Slice(model.RPCs, func(i, j int) bool {
+		return model.RPCs[i].Module < model.RPCs[j].Module
+	})
+
+	comp := ComponentDescription{Name: componentName(peer.Names)}
+	model.Component = comp.Name
+	for _, name := range peer.Names {
+		if name == comp.Name && len(peer.Names) > 1 {
+			continue
+		}
+		model.Name = name
+		comp.Models = append(comp.Models, model)
+	}
+	sort.Slice(comp.Models, func(i, j int) bool {
+		return comp.Models[i].Name < comp.Models[j].Name
+	})
+	return comp, true
+}
+
+func describeIntrospected(info *ComponentInfo) ComponentDescription {
+	comp := ComponentDescription{Name: info.Name}
+	for _, m := range info.Models {
+		comp.Models = append(comp.Models, ModelDescription{
+			Name:      m.Name,
+			Component: info.Name,
+			Config:    m.Config,
+			State:     m.State,
+			RPCs:      m.RPCs,
+		})
+	}
+	return comp
+}
+
+// componentName picks the component's name from those a peer owns, as
+// the one its models' names extend, such as net.vyatta.test for the
+// model net.vyatta.test.v1. A peer that owns a single name is taken to
+// be a component with a model of the same name.
+func componentName(names []string) string {
+	if len(names) == 1 {
+		return names[0]
+	}
+	for _, candidate := range names {
+		prefix := candidate + "."
+		extended := true
+		for _, name := range names {
+			if name != candidate && !strings.HasPrefix(name, prefix) {
+				extended = false
+				break
+			}
+		}
+		if extended {
+			return candidate
+		}
+	}
+	return ""
+}
@@ -0,0 +1,157 @@
+// Copyright (c) 2026, AT&T Intellectual Property. All rights reserved.
+//
+// SPDX-License-Identifier: MPL-2.0
+
+package vci
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestListComponents(t *testing.T) {
+	resetTestBus()
+	comp := NewComponent("net.vyatta.test")
+	comp.Model("net.vyatta.test.v1").
+		Config(&testRunningConfigWithValue{}).
+		RPC("test-v1", map[string]interface{}{
+			"echo": func(in *testConfig) (*testConfig, error) {
+				return in, nil
+			},
+			"ping": func(in *testConfig) (*testConfig, error) {
+				return in, nil
+			},
+		})
+	err := comp.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer comp.Stop()
+
+	// A component that cannot be introspected is described by the
+	// objects it exports.
+	legacy := newTestTransport()
+	err = legacy.Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer legacy.Close()
+	legacyClient := newClient().withTransport(legacy)
+	err = legacy.Export(newState(&testPathState{}, legacyClient))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = legacy.Export(newRPC("legacy-v1", map[string]interface{}{
+		"echo": func(in *testConfig) (*testConfig, error) {
+			return in, nil
+		},
+	}, legacyClient))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"net.vyatta.legacy", "net.vyatta.legacy.v1"} {
+		err = legacy.RequestIdentity(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	client, err := Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	comps, err := client.ListComponents()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var found []ComponentDescription
+	for _, c := range comps {
+		if c.Name == "net.vyatta.test" || c.Name == "net.vyatta.legacy" {
+			found = append(found, c)
+		}
+	}
+	expComps := []ComponentDescription{
+		{
+			Name: "net.vyatta.legacy",
+			Models: []ModelDescription{{
+				Name:      "net.vyatta.legacy.v1",
+				Component: "net.vyatta.legacy",
+				State:     true,
+				RPCs: []RPCModuleInfo{
+					{Module: "legacy-v1", Methods: []string{"echo"}},
+				},
+			}},
+		},
+		{
+			Name: "net.vyatta.test",
+			Models: []ModelDescription{{
+				Name:      "net.vyatta.test.v1",
+				Component: "net.vyatta.test",
+				Config:    true,
+				RPCs: []RPCModuleInfo{
+					{Module: "test-v1", Methods: []string{"echo", "ping"}},
+				},
+			}},
+		},
+	}
+	if !reflect.DeepEqual(found, expComps) {
+		t.Fatalf("unexpected components\n got %+v\nwant %+v",
+			found, expComps)
+	}
+
+	models, err := client.ListModels()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i < len(models); i++ {
+		if models[i-1].Name > models[i].Name {
+			t.Fatalf("models are not in order: %+v", models)
+		}
+	}
+
+	rpcs, err := client.ListRPCs("test-v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expRPCs := []RPCDescription{
+		{Module: "test-v1", Name: "echo", Model: "net.vyatta.test.v1"},
+		{Module: "test-v1", Name: "ping", Model: "net.vyatta.test.v1"},
+	}
+	if !reflect.DeepEqual(rpcs, expRPCs) {
+		t.Fatalf("unexpected RPCs\n got %+v\nwant %+v", rpcs, expRPCs)
+	}
+
+	rpcs, err = client.ListRPCs("missing-v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rpcs) != 0 {
+		t.Fatalf("found RPCs of a missing module: %+v", rpcs)
+	}
+}
+
+func TestComponentName(t *testing.T) {
+	tests := []struct {
+		names []string
+		exp   string
+	}{
+		{[]string{"net.vyatta.test"}, "net.vyatta.test"},
+		{[]string{"net.vyatta.test", "net.vyatta.test.v1"}, "net.vyatta.test"},
+		{[]string{"net.vyatta.test.v1", "net.vyatta.test"}, "net.vyatta.test"},
+		{[]string{
+			"net.vyatta.test",
+			"net.vyatta.test.v1",
+			"net.vyatta.test.v2",
+		}, "net.vyatta.test"},
+		{[]string{"net.vyatta.test.v1", "net.vyatta.other.v1"}, ""},
+		{[]string{"net.vyatta.test", "net.vyatta.tester"}, ""},
+	}
+	for _, test := range tests {
+		got := componentName(test.names)
+		if got != test.exp {
+			t.Errorf("componentName(%q) = %q, want %q",
+				test.names, got, test.exp)
+		}
+	}
+}
@@ -0,0 +1,321 @@
+// Copyright (c) 2026, AT&T Intellectual Property. All rights reserved.
+//
+// SPDX-License-Identifier: MPL-2.0
+
+package vci
+
+import (
+	"context"
+	"errors"
+
+	"github.com/danos/mgmterror"
+)
+
+// An Invocation describes a call to a component's handler, as seen by
+// an Interceptor on either side of the bus.
+type Invocation struct {
+	// ModelName is the model whose handler is called, or the component
+	// for introspection. It is empty for calls made by a Client, which
+	// does not know which model provides an RPC.
+	ModelName string
+	// Kind is the kind of handler called: "config", "state", "rpc" or
+	// "introspection".
+	Kind string
+	// ModuleName is the YANG module of an RPC. It is empty for
+	// configuration and state handlers.
+	ModuleName string
+	// Method is the YANG name of the RPC or, for configuration and
+	// state handlers, of the method called: get, get-path, set, check,
+	// prepare, commit or abort.
+	Method string
+	// Metadata is the caller's metadata. It is sent with RPCs; for
+	// configuration and state handlers it is supplied by transports
+	// that know who the caller is.
+	Metadata RPCMetadata
+	// Input is the encoded input of the call. An interceptor may
+	// replace it before passing the call on.
+	Input string
+}
+
+// An Invoker carries out an invocation, returning the encoded output.
+type Invoker func(ctx context.Context, inv *Invocation) (string, error)
+
+// An Interceptor wraps the handling of each invocation. It is given the
+// next Invoker in the chain and is responsible for calling it; it may
+// instead return an error to reject the invocation. Interceptors are
+// useful for logging, authorization and timing common to all of a
+// component's handlers or a client's calls.
+//
+// Interceptors are installed on a Component with the
+// WithServerInterceptors option and on a Client with the
+// WithCallInterceptors option. The first interceptor supplied is the
+// outermost.
+type Interceptor func(
+	ctx context.Context,
+	inv *Invocation,
+	invoke Invoker,
+) (string, error)
+
+var errUnknownMethodType = errors.New("cannot intercept method")
+
+// chainInterceptors returns an Invoker that passes each invocation
+// through the interceptors before calling invoke.
+func chainInterceptors(interceptors []Interceptor, invoke Invoker) Invoker {
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor, next := interceptors[i], invoke
+		invoke = func(ctx context.Context, inv *Invocation) (string, error) {
+			return interceptor(ctx, inv, next)
+		}
+	}
+	return invoke
+}
+
+// interceptedObject passes the methods of a TransportObject through the
+// server interceptors of its component.
+type interceptedObject struct {
+	TransportObject
+	methods       map[string]interface{}
+	callerMethods map[string]interface{}
+}
+
+// contextObject is implemented by the transport objects whose handlers
+// may take a context. contextMethods returns the methods of the handlers
+// that do, each the same as the object's method but taking the context
+// as an extra first argument.
+type contextObject interface {
+	contextMethods() map[string]interface{}
+}
+
+// hasContextMethods reports whether any of the object's handlers take a
+// context.
+func hasContextMethods(object TransportObject) bool {
+	ctxObj, ok := object.(contextObject)
+	return ok && len(ctxObj.contextMethods()) != 0
+}
+
+func newInterceptedObject(
+	modelName string,
+	object TransportObject,
+	interceptors []Interceptor,
+) (*interceptedObject, error) {
+	o := &interceptedObject{
+		TransportObject: object,
+		methods:         make(map[string]interface{}),
+		callerMethods:   make(map[string]interface{}),
+	}
+	var ctxMethods map[string]interface{}
+	if ctxObj, ok := object.(contextObject); ok {
+		ctxMethods = ctxObj.contextMethods()
+	}
+	for name, method := range object.Methods() {
+		inv := Invocation{
+			ModelName: modelName,
+			Kind:      object.Type(),
+			Method:    name,
+		}
+		if object.Type() == "rpc" {
+			inv.ModuleName = object.Name()
+		}
+		invoke, err := methodInvoker(method, ctxMethods[name])
+		if err != nil {
+			return nil, err
+		}
+		call := newInterceptedCall(inv, interceptors, invoke)
+		o.methods[name] = interceptMethod(method, call)
+		o.callerMethods[name] = interceptCallerMethod(method, call)
+	}
+	return o, nil
+}
+
+func (o *interceptedObject) Methods() map[string]interface{} {
+	return o.methods
+}
+
+func (o *interceptedObject) CallerMethods() map[string]interface{} {
+	return o.callerMethods
+}
+
+// methodInvoker returns a function that calls one of the methods
+// generated for a handler, or its context form if it has one, with the
+// metadata and input of an invocation.
+func methodInvoker(
+	method, ctxMethod interface{},
+) (func(ctx context.Context, meta, input string) (string, error), error) {
+	switch fn := ctxMethod.(type) {
+	case func(context.Context, string, string) (string, error):
+		return fn, nil
+	case func(context.Context, string) error:
+		return func(ctx context.Context, _, input string) (string, error) {
+			return "", fn(ctx, input)
+		}, nil
+	}
+
+	switch fn := method.(type) {
+	case func() (string, error):
+		return func(_ context.Context, _, _ string) (string, error) {
+			return fn()
+		}, nil
+	case func(string) (string, error):
+		return func(_ context.Context, _, input string) (string, error) {
+			return fn(input)
+		}, nil
+	case func(string, string) (string, error):
+		return func(_ context.Context, meta, input string) (string, error) {
+			return fn(meta, input)
+		}, nil
+	case func() error:
+		return func(_ context.Context, _, _ string) (string, error) {
+			return "", fn()
+		}, nil
+	case func(string) error:
+		return func(_ context.Context, _, input string) (string, error) {
+			return "", fn(input)
+		}, nil
+	}
+	return nil, errUnknownMethodType
+}
+
+// newInterceptedCall returns a function that passes a call through the
+// interceptors before invoking the method. The metadata is that sent
+// with an RPC or, for other methods, that describing the caller if the
+// transport supplied it. The call's context carries the trace context
+// from the metadata.
+func newInterceptedCall(
+	template Invocation,
+	interceptors []Interceptor,
+	invoke func(ctx context.Context, meta, input string) (string, error),
+) func(meta, input string) (string, error) {
+	return func(meta, input string) (string, error) {
+		inv := template
+		inv.Input = input
+		if meta != "" {
+			// Metadata that cannot be decoded is left for the
+			// handler to reject.
+			_ = decodeMetadata(meta, &inv.Metadata)
+		}
+		// Handlers that take a context see the caller's trace.
+		ctx := context.Background()
+		sc, err := ParseTraceparent(inv.Metadata.Traceparent,
+			inv.Metadata.Tracestate)
+		if err == nil {
+			ctx = ContextWithSpanContext(ctx, sc)
+		}
+		chain := chainInterceptors(interceptors,
+			func(ctx context.Context, inv *Invocation) (string, error) {
+				return invoke(ctx, meta, inv.Input)
+			})
+		return chain(ctx, &inv)
+	}
+}
+
+// interceptMethod wraps one of the methods generated for a handler so
+// that calls to it are made through call. The wrapper has the same
+// signature as the method, which methodInvoker has already checked.
+func interceptMethod(
+	method interface{},
+	call func(meta, input string) (string, error),
+) interface{} {
+	switch method.(type) {
+	case func() (string, error):
+		return func() (string, error) {
+			return call("", "")
+		}
+	case func(string) (string, error):
+		return func(input string) (string, error) {
+			return call("", input)
+		}
+	case func(string, string) (string, error):
+		return call
+	case func() error:
+		return func() error {
+			_, err := call("", "")
+			return err
+		}
+	default:
+		return func(input string) error {
+			_, err := call("", input)
+			return err
+		}
+	}
+}
+
+// interceptCallerMethod is the same as interceptMethod but the wrapper
+// of a configuration or state method takes the caller's metadata as an
+// extra first argument, as described by TransportCallerObject.
+func interceptCallerMethod(
+	method interface{},
+	call func(meta, input string) (string, error),
+) interface{} {
+	switch method.(type) {
+	case func() (string, error):
+		return func(caller string) (string, error) {
+			return call(caller, "")
+		}
+	case func(string) (string, error):
+		return call
+	case func() error:
+		return func(caller string) error {
+			_, err := call(caller, "")
+			return err
+		}
+	case func(string) error:
+		return func(caller, input string) error {
+			_, err := call(caller, input)
+			return err
+		}
+	}
+	return interceptMethod(method, call)
+}
+
+// interceptedPromise is the result of a call made through a Client's
+// interceptors, which run in the background so that the call is made
+// straight away as it would be without them.
+type interceptedPromise struct {
+	done chan struct{}
+	out  string
+	err  error
+}
+
+func (c *Client) interceptCall(
+	ctx context.Context,
+	inv *Invocation,
+) TransportRPCPromise {
+	p := &interceptedPromise{done: make(chan struct{})}
+	chain := chainInterceptors(c.interceptors,
+		func(ctx context.Context, inv *Invocation) (string, error) {
+			// The interceptors may have changed the metadata.
+			encodedMetadata, err := encodeMetadata(inv.Metadata)
+			if err != nil {
+				return "", mgmterror.NewMalformedMessageError()
+			}
+			promise, err := c.transport.Call(ctx, inv.ModuleName,
+				inv.Method, encodedMetadata, inv.Input)
+			if err != nil {
+				return "", err
+			}
+			var out string
+			err = promise.StoreOutputInto(ctx, &out)
+			return out, err
+		})
+	go func() {
+		p.out, p.err = chain(ctx, inv)
+		close(p.done)
+	}()
+	return p
+}
+
+func (p *interceptedPromise) StoreOutputInto(
+	ctx context.Context,
+	output *string,
+) error {
+	select {
+	case <-p.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if p.err != nil {
+		return p.err
+	}
+	*output = p.out
+	return nil
+}
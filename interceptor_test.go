@@ -0,0 +1,290 @@
+// Copyright (c) 2026, AT&T Intellectual Property. All rights reserved.
+//
+// SPDX-License-Identifier: MPL-2.0
+
+package vci
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+type testInterceptorLog struct {
+	mu   sync.Mutex
+	invs []Invocation
+	outs []string
+}
+
+func (l *testInterceptorLog) intercept(
+	ctx context.Context,
+	inv *Invocation,
+	invoke Invoker,
+) (string, error) {
+	out, err := invoke(ctx, inv)
+	l.mu.Lock()
+	l.invs = append(l.invs, *inv)
+	l.outs = append(l.outs, out)
+	l.mu.Unlock()
+	return out, err
+}
+
+func (l *testInterceptorLog) last() (Invocation, string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.invs) == 0 {
+		return Invocation{}, ""
+	}
+	return l.invs[len(l.invs)-1], l.outs[len(l.outs)-1]
+}
+
+func testRejectMethod(method string) Interceptor {
+	return func(
+		ctx context.Context,
+		inv *Invocation,
+		invoke Invoker,
+	) (string, error) {
+		if inv.Method == method {
+			return "", errors.New("rejected " + method)
+		}
+		return invoke(ctx, inv)
+	}
+}
+
+func TestChainInterceptors(t *testing.T) {
+	var order []string
+	record := func(name string) Interceptor {
+		return func(
+			ctx context.Context,
+			inv *Invocation,
+			invoke Invoker,
+		) (string, error) {
+			order = append(order, name+"-in")
+			out, err := invoke(ctx, inv)
+			order = append(order, name+"-out")
+			return out, err
+		}
+	}
+	chain := chainInterceptors(
+		[]Interceptor{record("first"), record("second")},
+		func(ctx context.Context, inv *Invocation) (string, error) {
+			order = append(order, "invoke")
+			return inv.Input, nil
+		})
+	out, err := chain(context.Background(), &Invocation{Input: "foo"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "foo" {
+		t.Fatalf("expected %q, got %q", "foo", out)
+	}
+	exp := []string{
+		"first-in", "second-in", "invoke", "second-out", "first-out",
+	}
+	if !reflect.DeepEqual(order, exp) {
+		t.Fatalf("expected %v, got %v", exp, order)
+	}
+}
+
+func TestServerInterceptors(t *testing.T) {
+	const model = "com.vyatta.test.foo.v1"
+	setup := func(t *testing.T, interceptors ...Interceptor) *Client {
+		resetTestBus()
+		comp := NewComponentWithOptions("com.vyatta.test.foo",
+			WithTransport(newTestTransport()),
+			WithServerInterceptors(interceptors...))
+		comp.Model(model).
+			Config(&testRunningConfigWithValue{testConfig{Value: "foo"}}).
+			State(&testState{Value: "bar"}).
+			RPC("foo-v1", map[string]interface{}{
+				"call-me": func(
+					meta RPCMetadata,
+					in *testConfig,
+				) (*testConfig, error) {
+					return &testConfig{Value: meta.User}, nil
+				},
+			})
+		err := comp.Run()
+		if err != nil {
+			t.Fatal(err)
+		}
+		client, err := Dial()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return client
+	}
+
+	t.Run("rpc", func(t *testing.T) {
+		log := &testInterceptorLog{}
+		client := setup(t, log.intercept)
+		var out testConfig
+		err := client.CallWithMetadata("foo-v1", "call-me",
+			RPCMetadata{User: "alice"}, &testConfig{Value: "in"}).
+			StoreOutputInto(&out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		inv, encodedOut := log.last()
+		exp := Invocation{
+			ModelName:  model,
+			Kind:       "rpc",
+			ModuleName: "foo-v1",
+			Method:     "call-me",
+			Metadata:   RPCMetadata{User: "alice"},
+			Input:      `{"value":"in"}`,
+		}
+		if !reflect.DeepEqual(inv, exp) {
+			t.Fatalf("expected %+v, got %+v", exp, inv)
+		}
+		if encodedOut != `{"value":"alice"}` {
+			t.Fatalf("unexpected output %q", encodedOut)
+		}
+	})
+	t.Run("config-and-state", func(t *testing.T) {
+		log := &testInterceptorLog{}
+		client := setup(t, log.intercept)
+		err := client.SetConfigForModel(model, &testConfig{Value: "baz"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		inv, _ := log.last()
+		if inv.Kind != "config" || inv.Method != "set" ||
+			inv.Input != `{"value":"baz"}` {
+			t.Fatalf("unexpected invocation %+v", inv)
+		}
+		var state testState
+		err = client.StoreStateByModelInto(model, &state)
+		if err != nil {
+			t.Fatal(err)
+		}
+		inv, out := log.last()
+		if inv.Kind != "state" || inv.Method != "get" ||
+			out != `{"value":"bar"}` {
+			t.Fatalf("unexpected invocation %+v, output %q", inv, out)
+		}
+	})
+	t.Run("reject", func(t *testing.T) {
+		client := setup(t, testRejectMethod("set"))
+		err := client.SetConfigForModel(model, &testConfig{Value: "baz"})
+		if err == nil || err.Error() != "rejected set" {
+			t.Fatalf("unexpected error %v", err)
+		}
+		var cfg testConfig
+		err = client.StoreConfigByModelInto(model, &cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cfg.Value != "foo" {
+			t.Fatalf("rejected set was applied: %v", cfg)
+		}
+	})
+	t.Run("rewrite-input", func(t *testing.T) {
+		client := setup(t, func(
+			ctx context.Context,
+			inv *Invocation,
+			invoke Invoker,
+		) (string, error) {
+			if inv.Method == "set" {
+				inv.Input = `{"value":"rewritten"}`
+			}
+			return invoke(ctx, inv)
+		})
+		err := client.SetConfigForModel(model, &testConfig{Value: "baz"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var cfg testConfig
+		err = client.StoreConfigByModelInto(model, &cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cfg.Value != "rewritten" {
+			t.Fatalf("expected %q, got %q", "rewritten", cfg.Value)
+		}
+	})
+}
+
+func TestCallInterceptors(t *testing.T) {
+	resetTestBus()
+	comp := NewComponent("com.vyatta.test.foo")
+	comp.Model("com.vyatta.test.foo.v1").
+		RPC("foo-v1", &testRPCs{})
+	err := comp.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("record", func(t *testing.T) {
+		log := &testInterceptorLog{}
+		client, err := DialWithOptions(WithCallInterceptors(log.intercept))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer client.Close()
+		var out testConfig
+		err = client.CallWithMetadata("foo-v1", "call-me",
+			RPCMetadata{User: "alice"}, &testConfig{Value: "foo"}).
+			StoreOutputInto(&out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out.Value != "foo" {
+			t.Fatalf("expected %q, got %q", "foo", out.Value)
+		}
+		inv, encodedOut := log.last()
+		exp := Invocation{
+			Kind:       "rpc",
+			ModuleName: "foo-v1",
+			Method:     "call-me",
+			Metadata:   RPCMetadata{User: "alice"},
+			Input:      `{"value":"foo"}`,
+		}
+		if !reflect.DeepEqual(inv, exp) {
+			t.Fatalf("expected %+v, got %+v", exp, inv)
+		}
+		if encodedOut != `{"value":"foo"}` {
+			t.Fatalf("unexpected output %q", encodedOut)
+		}
+	})
+	t.Run("reject", func(t *testing.T) {
+		client, err := DialWithOptions(
+			WithCallInterceptors(testRejectMethod("call-me")))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer client.Close()
+		var out testConfig
+		err = client.Call("foo-v1", "call-me", &testConfig{Value: "foo"}).
+			StoreOutputInto(&out)
+		if err == nil || err.Error() != "rejected call-me" {
+			t.Fatalf("unexpected error %v", err)
+		}
+	})
+	t.Run("context", func(t *testing.T) {
+		release := make(chan struct{})
+		defer close(release)
+		client, err := DialWithOptions(WithCallInterceptors(func(
+			ctx context.Context,
+			inv *Invocation,
+			invoke Invoker,
+		) (string, error) {
+			<-release
+			return invoke(ctx, inv)
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer client.Close()
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		var out testConfig
+		err = client.Call("foo-v1", "call-me", &testConfig{Value: "foo"}).
+			StoreOutputIntoContext(ctx, &out)
+		if err != context.Canceled {
+			t.Fatalf("unexpected error %v", err)
+		}
+	})
+}
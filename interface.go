@@ -6,6 +6,12 @@
 
 package vci
 
+import (
+	"context"
+	"os"
+	"time"
+)
+
 type PathError struct {
 	Path    string
 	Message string
@@ -46,8 +52,30 @@ type Component interface {
 	// an error during component execution it will be returned from Wait.
 	Wait() error
 	// Stop terminates the component execution. It will close all transport
-	// connections and disconnect the component from the bus.
+	// connections and disconnect the component from the bus. Stopping a
+	// component that has already been stopped or shut down returns the
+	// error it terminated with.
 	Stop() error
+	// Shutdown terminates the component execution gracefully. It tells
+	// systemd the component is stopping, releases the component's names
+	// on the bus and refuses new calls, then waits for the calls in
+	// progress to finish and for the notifications already queued for
+	// its subscriptions to be delivered before disconnecting from the
+	// bus. If the context expires first the component is disconnected
+	// anyway and the context's error is returned, and then returned by
+	// Wait.
+	Shutdown(ctx context.Context) error
+	// RunUntilSignal runs the component until it receives one of the
+	// signals, SIGINT or SIGTERM if none are given, or is stopped, then
+	// shuts it down allowing it the timeout to finish what it is doing.
+	// It returns the error the component terminated with.
+	RunUntilSignal(timeout time.Duration, signals ...os.Signal) error
+	// HealthCheck adds a check that must pass before the component
+	// tells systemd's watchdog that it is alive. When the service has
+	// WatchdogSec set the checks are run at half that interval, and
+	// while any of them fails, or does not return, the watchdog is not
+	// notified and systemd will restart the component.
+	HealthCheck(name string, check func() error)
 }
 
 // A Model represents a self-consistent set of YANG models. The Model
@@ -66,15 +94,30 @@ type Model interface {
 	//       against a set of constraints that cannot be modeled in YANG.
 	//   (3) Get() (T, error)
 	//       This method returns the configuration in a form that matches
-	//       the data-model.
+	//       the data-model. Get may instead take the RFC7951
+	//       instance-identifier of the subtree to return,
+	//       Get(path string) (T, error), where an empty path means
+	//       the whole tree.
 	// where T is any type that can be marshalled by the RFC7951
 	// encoder.
+	// To take part in transactions the handler must also implement:
+	//   (4) Prepare(config T) error
+	//       This method validates and stages the configuration
+	//       supplied without applying it.
+	//   (5) Commit() error
+	//       This method applies the staged configuration.
+	//   (6) Abort() error
+	//       This method discards the staged configuration.
+	// Set and Check may take a context.Context before the configuration,
+	// Set(ctx context.Context, config T) error; the context carries the
+	// trace context of the caller.
 	Config(object interface{}) Model
 	// State attaches an operational state handler to the model.
 	// This handler must implement one method:
 	//   (1) Get() (T, error)
 	//       This method returns the configuration in a form that matches
-	//       the data-model.
+	//       the data-model. As for Config, Get may instead take the
+	//       path of the subtree to return, Get(path string) (T, error).
 	// where T is any type that can be marshalled by the RFC7951
 	// encoder.
 	State(object interface{}) Model
@@ -95,10 +138,40 @@ type Model interface {
 	//       Names will be converted from the Go style CamelCase
 	//       to the standard YANG convention of camel-case.
 	// where T1, T2 are any types that can be marshalled by the
-	// RFC7951 encoder.
+	// RFC7951 encoder. Any of these forms may also take a
+	// context.Context as the first argument, which carries the trace
+	// context of the caller.
 	// The RPCs must implement the functionallity specified in the YANG model
 	// and must conform to the model in both input and output.
 	RPC(moduleName string, object interface{}) Model
+	// StateChanged tells the clients watching the model's state, see
+	// Client.WatchState, that the state at the RFC7951
+	// instance-identifier path has changed. The new state is retrieved
+	// from the state handler's Get and sent to them: the whole of the
+	// subtree at the path, not just the leaves that changed, so narrower
+	// paths send less. An empty path sends the whole tree; any other
+	// path requires the handler's Get to accept a path. The model's
+	// component must be running and its transport must support state
+	// changes, otherwise an OperationNotSupported error is returned.
+	StateChanged(path string) error
+	// ConfigFile keeps a copy of the running configuration in the file,
+	// normally the ConfigFile of the component's '.component' file,
+	// which WithComponentConfig supplies without ConfigFile being
+	// called. Each configuration that is successfully set, or committed,
+	// is written to the file atomically. When the component is run the
+	// saved configuration is Set before the component signals it is
+	// ready, so that it boots with its configuration without waiting for
+	// the configuration system.
+	ConfigFile(path string) Model
+	// SerializeConfig makes calls to the configuration handler run one
+	// at a time, so that, for instance, a Set does not race with a Get
+	// or another Set and the handler needs no locking of its own.
+	SerializeConfig() Model
+	// RPCWorkers limits the number of the model's RPCs that run at once
+	// to workers; further calls wait for a running RPC to finish. By
+	// default RPCs, like all other calls, run as concurrently as they
+	// are made.
+	RPCWorkers(workers int) Model
 }
 
 // EmitNotification connects to the transport sends the notification
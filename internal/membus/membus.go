@@ -0,0 +1,392 @@
+// Copyright (c) 2026, AT&T Intellectual Property. All rights reserved.
+//
+// SPDX-License-Identifier: MPL-2.0
+
+// Package membus implements an in-process message bus with the same
+// semantics the VCI library relies on from D-Bus: connections claim
+// well-known names, export named objects with methods, call methods
+// on objects owned by other names and broadcast notifications.
+//
+// It backs the vcitest package, which provides the vci.Transport that
+// speaks to it.
+package membus
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+var (
+	// ErrNotConnected is returned for any operation on a closed
+	// connection or on a connection to a bus that refused it.
+	ErrNotConnected = errors.New("not connected to the bus")
+	// ErrUnknownName is returned when calling a name nobody owns.
+	ErrUnknownName = errors.New("unknown name")
+	// ErrUnknownObject is returned when calling an object that the
+	// owner of the name has not exported.
+	ErrUnknownObject = errors.New("unknown object")
+	// ErrUnknownMethod is returned when calling a method the object
+	// does not have.
+	ErrUnknownMethod = errors.New("unknown method")
+
+	errorType = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// An Object is something exported on the bus. Its methods may take up
+// to two string arguments and return a string, an error, or both.
+type Object interface {
+	Methods() map[string]interface{}
+	Name() string
+	Type() string
+}
+
+// A Subscriber receives the notifications it has subscribed to.
+type Subscriber interface {
+	Deliver(encodedData string) error
+}
+
+// A Bus connects the parties of a test together.
+type Bus struct {
+	mu            sync.Mutex
+	failDial      bool
+	conns         map[*Conn]struct{}
+	names         map[string]*Conn
+	subscriptions map[string][]Subscriber
+}
+
+// New returns an empty bus.
+func New() *Bus {
+	return &Bus{
+		conns:         make(map[*Conn]struct{}),
+		names:         make(map[string]*Conn),
+		subscriptions: make(map[string][]Subscriber),
+	}
+}
+
+// SetDialFailure controls whether new connections to the bus fail,
+// which simulates the bus being unavailable.
+func (b *Bus) SetDialFailure(fail bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failDial = fail
+}
+
+// Dial returns a new connection to the bus.
+func (b *Bus) Dial() (*Conn, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failDial {
+		return nil, ErrNotConnected
+	}
+	c := &Conn{
+		bus:     b,
+		objects: make(map[string]*object),
+	}
+	b.conns[c] = struct{}{}
+	return c, nil
+}
+
+// Names returns the names currently owned on the bus in sorted order.
+func (b *Bus) Names() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	out := make([]string, 0, len(b.names))
+	for name := range b.names {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// A Peer is a connection that owns names on the bus, with the objects
+// it exports.
+type Peer struct {
+	Names   []string
+	Objects []PeerObject
+}
+
+// A PeerObject is an object exported by a Peer, with the names of its
+// methods.
+type PeerObject struct {
+	Name    string
+	Type    string
+	Methods []string
+}
+
+// Peers returns the connections that own names on the bus, in the order
+// of their first name.
+func (b *Bus) Peers() []Peer {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var peers []Peer
+	for c := range b.conns {
+		if len(c.names) == 0 {
+			continue
+		}
+		peer := Peer{Names: append([]string(nil), c.names...)}
+		sort.Strings(peer.Names)
+		for name, obj := range c.objects {
+			peerObj := PeerObject{Name: name, Type: obj.typ}
+			for method := range obj.methods {
+				peerObj.Methods = append(peerObj.Methods, method)
+			}
+			sort.Strings(peerObj.Methods)
+			peer.Objects = append(peer.Objects, peerObj)
+		}
+		sort.Slice(peer.Objects, func(i, j int) bool {
+			return peer.Objects[i].Name < peer.Objects[j].Name
+		})
+		peers = append(peers, peer)
+	}
+	sort.Slice(peers, func(i, j int) bool {
+		return peers[i].Names[0] < peers[j].Names[0]
+	})
+	return peers
+}
+
+func (b *Bus) lookup(name, objectName string) (*object, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	owner, ok := b.names[name]
+	if !ok {
+		return nil, ErrUnknownName
+	}
+	obj, ok := owner.objects[objectName]
+	if !ok {
+		return nil, ErrUnknownObject
+	}
+	return obj, nil
+}
+
+func (b *Bus) emit(notification, encodedData string) {
+	b.mu.Lock()
+	subs := append([]Subscriber(nil), b.subscriptions[notification]...)
+	b.mu.Unlock()
+	for _, sub := range subs {
+		_ = sub.Deliver(encodedData)
+	}
+}
+
+// A Conn is one party's connection to the bus.
+type Conn struct {
+	bus     *Bus
+	closed  bool
+	names   []string
+	objects map[string]*object
+	subs    map[string][]Subscriber
+}
+
+func (c *Conn) checkConnected() error {
+	if c.closed {
+		return ErrNotConnected
+	}
+	return nil
+}
+
+// RequestName claims a well-known name for the connection.
+func (c *Conn) RequestName(name string) error {
+	b := c.bus
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := c.checkConnected(); err != nil {
+		return err
+	}
+	if owner, ok := b.names[name]; ok && owner != c {
+		return errors.New("name " + name + " already in use")
+	}
+	b.names[name] = c
+	c.names = append(c.names, name)
+	return nil
+}
+
+// Export makes the object callable by other parties under the names
+// owned by the connection.
+func (c *Conn) Export(obj Object) error {
+	b := c.bus
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := c.checkConnected(); err != nil {
+		return err
+	}
+	if _, ok := c.objects[obj.Name()]; ok {
+		return errors.New("object " + obj.Name() + " already exists")
+	}
+	c.objects[obj.Name()] = &object{
+		typ:     obj.Type(),
+		methods: obj.Methods(),
+	}
+	return nil
+}
+
+// Call invokes a method on an object owned by name and returns its
+// output. If the context expires first its error is returned, and the
+// method is left running in the background much as a real bus peer
+// would be.
+func (c *Conn) Call(
+	ctx context.Context,
+	name, objectName, method, meta, encodedData string,
+) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	c.bus.mu.Lock()
+	err := c.checkConnected()
+	c.bus.mu.Unlock()
+	if err != nil {
+		return "", err
+	}
+	obj, err := c.bus.lookup(name, objectName)
+	if err != nil {
+		return "", err
+	}
+
+	type result struct {
+		out string
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		out, err := obj.call(method, meta, encodedData)
+		done <- result{out: out, err: err}
+	}()
+	select {
+	case res := <-done:
+		return res.out, res.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// Subscribe delivers each future emission of the notification to sub.
+// Subscribing the same subscriber twice has no further effect.
+func (c *Conn) Subscribe(notification string, sub Subscriber) error {
+	b := c.bus
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := c.checkConnected(); err != nil {
+		return err
+	}
+	for _, s := range b.subscriptions[notification] {
+		if s == sub {
+			return nil
+		}
+	}
+	b.subscriptions[notification] =
+		append(b.subscriptions[notification], sub)
+	if c.subs == nil {
+		c.subs = make(map[string][]Subscriber)
+	}
+	c.subs[notification] = append(c.subs[notification], sub)
+	return nil
+}
+
+// Unsubscribe stops delivery of the notification to sub.
+func (c *Conn) Unsubscribe(notification string, sub Subscriber) error {
+	b := c.bus
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := c.checkConnected(); err != nil {
+		return err
+	}
+	b.subscriptions[notification] =
+		removeSubscriber(b.subscriptions[notification], sub)
+	if len(b.subscriptions[notification]) == 0 {
+		delete(b.subscriptions, notification)
+	}
+	c.subs[notification] = removeSubscriber(c.subs[notification], sub)
+	return nil
+}
+
+// Emit delivers the notification to every subscriber on the bus.
+func (c *Conn) Emit(notification, encodedData string) error {
+	c.bus.mu.Lock()
+	err := c.checkConnected()
+	c.bus.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	c.bus.emit(notification, encodedData)
+	return nil
+}
+
+// Close disconnects from the bus, releasing the connection's names,
+// objects and subscriptions.
+func (c *Conn) Close() error {
+	b := c.bus
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	for _, name := range c.names {
+		if b.names[name] == c {
+			delete(b.names, name)
+		}
+	}
+	for notification, subs := range c.subs {
+		for _, sub := range subs {
+			b.subscriptions[notification] =
+				removeSubscriber(b.subscriptions[notification], sub)
+		}
+		if len(b.subscriptions[notification]) == 0 {
+			delete(b.subscriptions, notification)
+		}
+	}
+	delete(b.conns, c)
+	return nil
+}
+
+func removeSubscriber(subs []Subscriber, sub Subscriber) []Subscriber {
+	out := make([]Subscriber, 0, len(subs))
+	for _, s := range subs {
+		if s == sub {
+			continue
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+type object struct {
+	typ     string
+	methods map[string]interface{}
+}
+
+func (o *object) call(name, meta, encodedData string) (string, error) {
+	method, ok := o.methods[name]
+	if !ok {
+		return "", ErrUnknownMethod
+	}
+	mVal := reflect.ValueOf(method)
+	mType := mVal.Type()
+	var ins []reflect.Value
+	switch mType.NumIn() {
+	case 0:
+	case 1:
+		ins = []reflect.Value{reflect.ValueOf(encodedData)}
+	case 2:
+		ins = []reflect.Value{
+			reflect.ValueOf(meta),
+			reflect.ValueOf(encodedData),
+		}
+	default:
+		return "", ErrUnknownMethod
+	}
+
+	var out string
+	for i, val := range mVal.Call(ins) {
+		switch {
+		case mType.Out(i) == errorType:
+			if !val.IsNil() {
+				return "", val.Interface().(error)
+			}
+		case val.Kind() == reflect.String:
+			out = val.String()
+		}
+	}
+	return out, nil
+}
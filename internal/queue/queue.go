@@ -13,6 +13,7 @@ package queue
 import (
 	"math/big"
 	"sync"
+	"sync/atomic"
 )
 
 var (
@@ -45,6 +46,43 @@ type Queue interface {
 	Close()
 }
 
+// Stats describes how many items a queue holds and how many it has lost
+// since it was created.
+type Stats struct {
+	// Length is the number of items waiting to be dequeued.
+	Length int
+	// Dropped is the number of items a bounded queue discarded because
+	// it was full.
+	Dropped uint64
+	// Coalesced is the number of items a coalesced queue replaced
+	// before they were dequeued.
+	Coalesced uint64
+}
+
+// StatsOf returns the statistics of a queue created by this package.
+func StatsOf(q Queue) Stats {
+	if sq, ok := q.(interface{ stats() Stats }); ok {
+		return sq.stats()
+	}
+	return Stats{}
+}
+
+// PolicyOf returns the name of the policy of a queue created by this
+// package, and its limit if it has one.
+func PolicyOf(q Queue) (policy string, limit int) {
+	switch q := q.(type) {
+	case *unboundedQueue:
+		return "unbounded", 0
+	case *coalescedQueue:
+		return "coalesce", 0
+	case *boundedQueue:
+		return "drop-after-limit", cap(q.ch)
+	case *blockingQueue:
+		return "block-after-limit", cap(q.ch)
+	}
+	return "", 0
+}
+
 // Range calls the fn on each enqueued item until the queue is closed
 func Range(q Queue, fn func(item interface{})) {
 	for i, ok := q.DequeueOrClosed(); ok; i, ok = q.DequeueOrClosed() {
@@ -62,10 +100,11 @@ func Move(out Queue, in Queue) {
 }
 
 type coalescedQueue struct {
-	cond    *sync.Cond
-	value   interface{}
-	closed  bool
-	updated bool
+	cond      *sync.Cond
+	value     interface{}
+	closed    bool
+	updated   bool
+	coalesced uint64
 }
 
 // A coalesced queue, is useful when one does not care about missed updates.
@@ -96,6 +135,9 @@ func (q *coalescedQueue) TryEnqueue(item interface{}) bool {
 		return false
 	}
 	defer q.cond.Signal()
+	if q.updated {
+		q.coalesced++
+	}
 	q.value = item
 	q.updated = true
 	return true
@@ -129,6 +171,15 @@ func (q *coalescedQueue) Close() {
 	defer q.cond.Signal()
 	q.closed = true
 }
+func (q *coalescedQueue) stats() Stats {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+	stats := Stats{Coalesced: q.coalesced}
+	if q.updated {
+		stats.Length = 1
+	}
+	return stats
+}
 
 type unboundedQueue struct {
 	closed bool
@@ -193,6 +244,12 @@ func (q *unboundedQueue) Close() {
 	q.closed = true
 }
 
+func (q *unboundedQueue) stats() Stats {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+	return Stats{Length: int(q.length.Int64())}
+}
+
 func (q *unboundedQueue) dequeue(block bool) (interface{}, bool) {
 	q.cond.L.Lock()
 	defer q.cond.L.Unlock()
@@ -210,9 +267,11 @@ func (q *unboundedQueue) dequeue(block bool) (interface{}, bool) {
 }
 
 type boundedQueue struct {
-	mu     sync.RWMutex
-	closed bool
-	ch     chan interface{}
+	// dropped is first so it is 64-bit aligned for atomic access.
+	dropped uint64
+	mu      sync.RWMutex
+	closed  bool
+	ch      chan interface{}
 }
 
 // A bounded queue has the semantics of a go channel that drops
@@ -236,6 +295,7 @@ func (q *boundedQueue) Enqueue(item interface{}) {
 	select {
 	case q.ch <- item:
 	default:
+		atomic.AddUint64(&q.dropped, 1)
 	}
 }
 
@@ -280,6 +340,13 @@ func (q *boundedQueue) Close() {
 	close(q.ch)
 }
 
+func (q *boundedQueue) stats() Stats {
+	return Stats{
+		Length:  len(q.ch),
+		Dropped: atomic.LoadUint64(&q.dropped),
+	}
+}
+
 type blockingQueue struct {
 	*boundedQueue
 }
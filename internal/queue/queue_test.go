@@ -332,6 +332,33 @@ func TestBoundedDequeueWhenFull(t *testing.T) {
 	assert(t, !ok, "TryDequeue should have failed")
 }
 
+func TestStats(t *testing.T) {
+	coalesced := NewCoalesced()
+	coalesced.Enqueue(1)
+	coalesced.Enqueue(2)
+	coalesced.Enqueue(3)
+	stats := StatsOf(coalesced)
+	assert(t, stats == Stats{Length: 1, Coalesced: 2},
+		"Coalesced queue should have counted replaced values")
+
+	bounded := NewBounded(2)
+	for i := 0; i < 5; i++ {
+		bounded.Enqueue(i)
+	}
+	stats = StatsOf(bounded)
+	assert(t, stats == Stats{Length: 2, Dropped: 3},
+		"Bounded queue should have counted dropped values")
+
+	unbounded := NewUnbounded()
+	for i := 0; i < 5; i++ {
+		unbounded.Enqueue(i)
+	}
+	unbounded.Dequeue()
+	stats = StatsOf(unbounded)
+	assert(t, stats == Stats{Length: 4},
+		"Unbounded queue should have reported its length")
+}
+
 func TestBlockingQueueSemantics(t *testing.T) {
 	testQueueSemantics(t, func() Queue {
 		return NewBlocking(10)
@@ -396,3 +423,22 @@ func TestMove(t *testing.T) {
 		assert(t, q2.Dequeue() == i, "Incorrect entry at element")
 	}
 }
+
+func TestPolicyOf(t *testing.T) {
+	for _, test := range []struct {
+		q      Queue
+		policy string
+		limit  int
+	}{
+		{NewUnbounded(), "unbounded", 0},
+		{NewCoalesced(), "coalesce", 0},
+		{NewBounded(3), "drop-after-limit", 3},
+		{NewBlocking(4), "block-after-limit", 4},
+	} {
+		policy, limit := PolicyOf(test.q)
+		if policy != test.policy || limit != test.limit {
+			t.Errorf("got %s %d, want %s %d", policy, limit,
+				test.policy, test.limit)
+		}
+	}
+}
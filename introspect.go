@@ -0,0 +1,257 @@
+// Copyright (c) 2026, AT&T Intellectual Property. All rights reserved.
+//
+// SPDX-License-Identifier: MPL-2.0
+
+package vci
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"runtime/debug"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/danos/vci/internal/queue"
+)
+
+const (
+	// introspectionObjectName is the name every component exports its
+	// introspection object under.
+	introspectionObjectName = "introspection"
+	// introspectionType is the transport object type of the
+	// introspection object.
+	introspectionType = "introspection"
+
+	libraryModulePath = "github.com/danos/vci"
+)
+
+// ComponentInfo describes a running component, as reported by the
+// component itself to Client.Introspect.
+type ComponentInfo struct {
+	Name string `rfc7951:"name"`
+	// LibraryVersion is the version of the VCI library the component
+	// was built with, if it is known.
+	LibraryVersion string             `rfc7951:"library-version"`
+	UptimeSeconds  uint64             `rfc7951:"uptime-seconds"`
+	Models         []ModelInfo        `rfc7951:"models"`
+	Subscriptions  []SubscriptionInfo `rfc7951:"subscriptions"`
+}
+
+// ModelInfo describes one of a component's models and the calls made
+// to its handlers.
+type ModelInfo struct {
+	Name   string          `rfc7951:"name"`
+	Config bool            `rfc7951:"config"`
+	State  bool            `rfc7951:"state"`
+	RPCs   []RPCModuleInfo `rfc7951:"rpcs"`
+	// Calls is the number of calls to the model's handlers, Errors the
+	// number that returned an error and Panics the number that
+	// panicked.
+	Calls  uint64 `rfc7951:"calls"`
+	Errors uint64 `rfc7951:"errors"`
+	Panics uint64 `rfc7951:"panics"`
+}
+
+// RPCModuleInfo names a YANG module whose RPCs a model implements, and
+// the RPCs.
+type RPCModuleInfo struct {
+	Module  string   `rfc7951:"module"`
+	Methods []string `rfc7951:"methods"`
+}
+
+// SubscriptionInfo describes one of a component's subscriptions and
+// its queue.
+type SubscriptionInfo struct {
+	Module       string `rfc7951:"module"`
+	Notification string `rfc7951:"notification"`
+	Running      bool   `rfc7951:"running"`
+	// QueuePolicy is unbounded, coalesce, drop-after-limit or
+	// block-after-limit, as set on the Subscription.
+	QueuePolicy string `rfc7951:"queue-policy"`
+	QueueLimit  int    `rfc7951:"queue-limit"`
+	QueueLength int    `rfc7951:"queue-length"`
+	Delivered   uint64 `rfc7951:"delivered"`
+	Dropped     uint64 `rfc7951:"dropped"`
+	Coalesced   uint64 `rfc7951:"coalesced"`
+}
+
+// handlerStats counts the calls to a model's handlers.
+type handlerStats struct {
+	calls  uint64
+	errors uint64
+	panics uint64
+}
+
+// called counts a call to a handler from the values it returned,
+// which are nil if it panicked.
+func (s *handlerStats) called(outs []reflect.Value) {
+	atomic.AddUint64(&s.calls, 1)
+	if outs == nil {
+		atomic.AddUint64(&s.panics, 1)
+		return
+	}
+	if len(outs) == 0 {
+		return
+	}
+	last := outs[len(outs)-1]
+	if last.Type() == reflectErrorType && !last.IsNil() {
+		atomic.AddUint64(&s.errors, 1)
+	}
+}
+
+func (m *model) info() ModelInfo {
+	info := ModelInfo{
+		Name:   m.name,
+		Config: m.cfg != nil,
+		State:  m.state != nil,
+		Calls:  atomic.LoadUint64(&m.stats.calls),
+		Errors: atomic.LoadUint64(&m.stats.errors),
+		Panics: atomic.LoadUint64(&m.stats.panics),
+	}
+	for name, rpc := range m.rpcs {
+		methods := make([]string, 0, len(rpc.methods))
+		for method := range rpc.methods {
+			methods = append(methods, method)
+		}
+		sort.Strings(methods)
+		info.RPCs = append(info.RPCs, RPCModuleInfo{
+			Module:  name,
+			Methods: methods,
+		})
+	}
+	sort.Slice(info.RPCs, func(i, j int) bool {
+		return info.RPCs[i].Module < info.RPCs[j].Module
+	})
+	return info
+}
+
+func (s *Subscription) info() SubscriptionInfo {
+	stats := s.Stats()
+	policy, limit := queue.PolicyOf(s.queue.Load())
+	return SubscriptionInfo{
+		Module:       s.moduleName,
+		Notification: s.notificationName,
+		Running:      s.isRunning(),
+		QueuePolicy:  policy,
+		QueueLimit:   limit,
+		QueueLength:  stats.QueueLength,
+		Delivered:    stats.Delivered,
+		Dropped:      stats.Dropped,
+		Coalesced:    stats.Coalesced,
+	}
+}
+
+func (c *component) info() *ComponentInfo {
+	info := &ComponentInfo{
+		Name:           c.name,
+		LibraryVersion: libraryVersion(),
+		UptimeSeconds:  uint64(time.Since(c.started) / time.Second),
+	}
+	for _, m := range c.models {
+		info.Models = append(info.Models, m.info())
+	}
+	c.subscriptions.mu.RLock()
+	for _, sub := range c.subscriptions.subs {
+		info.Subscriptions = append(info.Subscriptions, sub.info())
+	}
+	c.subscriptions.mu.RUnlock()
+	sort.Slice(info.Subscriptions, func(i, j int) bool {
+		a, b := info.Subscriptions[i], info.Subscriptions[j]
+		if a.Module != b.Module {
+			return a.Module < b.Module
+		}
+		return a.Notification < b.Notification
+	})
+	return info
+}
+
+// libraryVersion returns the version of this module the program was
+// built with, which is only known for programs built in module mode.
+func libraryVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	if info.Main.Path == libraryModulePath {
+		return info.Main.Version
+	}
+	for _, dep := range info.Deps {
+		if dep.Path != libraryModulePath {
+			continue
+		}
+		if dep.Replace != nil {
+			return dep.Replace.Version
+		}
+		return dep.Version
+	}
+	return ""
+}
+
+// introspection is the transport object through which a component
+// reports what it is running.
+type introspection struct {
+	component *component
+}
+
+func newIntrospection(c *component) *introspection {
+	return &introspection{component: c}
+}
+
+func (o *introspection) get() (string, error) {
+	return o.component.client.marshalObject(o.component.info())
+}
+
+func (o *introspection) Methods() map[string]interface{} {
+	return map[string]interface{}{"get": o.get}
+}
+
+func (o *introspection) IsValid() bool {
+	return o != nil && o.component != nil
+}
+
+func (o *introspection) Name() string {
+	return introspectionObjectName
+}
+
+func (o *introspection) Type() string {
+	return introspectionType
+}
+
+// introspectionReader is implemented by transports that can read the
+// introspection object of a component.
+type introspectionReader interface {
+	StoreIntrospectionInto(ctx context.Context,
+		componentName string, encodedData *string) error
+}
+
+// Introspect asks a running component to describe itself: its models,
+// the RPCs they implement, its subscriptions, how long it has been
+// running and how many calls to its handlers have failed.
+func (c *Client) Introspect(componentName string) (*ComponentInfo, error) {
+	return c.IntrospectContext(context.Background(), componentName)
+}
+
+// IntrospectContext is the same as Introspect but the supplied context
+// bounds the lifetime of the call.
+func (c *Client) IntrospectContext(
+	ctx context.Context,
+	componentName string,
+) (*ComponentInfo, error) {
+	reader, ok := c.transport.(introspectionReader)
+	if !ok {
+		return nil, errors.New("transport does not support introspection")
+	}
+	var encodedData string
+	err := reader.StoreIntrospectionInto(ctx, componentName, &encodedData)
+	if err != nil {
+		return nil, err
+	}
+	info := &ComponentInfo{}
+	err = c.unmarshalObject(encodedData, info)
+	if err != nil {
+		return nil, err
+	}
+	return info, nil
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2026, AT&T Intellectual Property. All rights reserved.
+//
+// SPDX-License-Identifier: MPL-2.0
+
+package vci
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestIntrospect(t *testing.T) {
+	resetTestBus()
+	comp := NewComponent("net.vyatta.test")
+	comp.Model("net.vyatta.test.v1").
+		Config(&testRunningConfigWithValue{}).
+		RPC("test-v1", map[string]interface{}{
+			"echo": func(in *testConfig) (*testConfig, error) {
+				return in, nil
+			},
+			"fail": func(in *testConfig) (*testConfig, error) {
+				return nil, errors.New("failed")
+			},
+		}).
+		RPC("other-v1", map[string]interface{}{
+			"ping": func(in *testConfig) (*testConfig, error) {
+				return in, nil
+			},
+		})
+	err := comp.Subscribe("foo-v1", "bar", func(map[string]interface{}) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	comp.LookupSubscription("foo-v1", "bar").DropAfterLimit(5)
+	err = comp.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer comp.Stop()
+
+	client, err := Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	var out testConfig
+	_ = client.Call("test-v1", "echo", &testConfig{}).StoreOutputInto(&out)
+	_ = client.Call("test-v1", "fail", &testConfig{}).StoreOutputInto(&out)
+
+	info, err := client.Introspect("net.vyatta.test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Name != "net.vyatta.test" {
+		t.Fatalf("unexpected name %q", info.Name)
+	}
+	expModels := []ModelInfo{{
+		Name:   "net.vyatta.test.v1",
+		Config: true,
+		RPCs: []RPCModuleInfo{
+			{Module: "other-v1", Methods: []string{"ping"}},
+			{Module: "test-v1", Methods: []string{"echo", "fail"}},
+		},
+		Calls:  2,
+		Errors: 1,
+	}}
+	if !reflect.DeepEqual(info.Models, expModels) {
+		t.Fatalf("unexpected models\n got %+v\nwant %+v",
+			info.Models, expModels)
+	}
+	expSubs := []SubscriptionInfo{{
+		Module:       "foo-v1",
+		Notification: "bar",
+		Running:      true,
+		QueuePolicy:  "drop-after-limit",
+		QueueLimit:   5,
+	}}
+	if !reflect.DeepEqual(info.Subscriptions, expSubs) {
+		t.Fatalf("unexpected subscriptions\n got %+v\nwant %+v",
+			info.Subscriptions, expSubs)
+	}
+
+	_, err = client.Introspect("net.vyatta.missing")
+	if err == nil {
+		t.Fatal("introspected a component that is not running")
+	}
+}
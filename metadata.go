@@ -0,0 +1,74 @@
+// Copyright (c) 2026, AT&T Intellectual Property. All rights reserved.
+//
+// SPDX-License-Identifier: MPL-2.0
+
+package vci
+
+import (
+	"os/user"
+	"strconv"
+)
+
+// encodeMetadata encodes RPCMetadata to be sent with an RPC. It is
+// always RFC7951 encoded, whatever the marshaller, so that a transport
+// can check it against the caller.
+func encodeMetadata(metadata RPCMetadata) (string, error) {
+	return defaultMarshaller().Marshal(metadata)
+}
+
+func decodeMetadata(encodedData string, metadata *RPCMetadata) error {
+	return defaultMarshaller().Unmarshal(encodedData, metadata)
+}
+
+// newPeerMetadata describes the caller of an RPC from the credentials
+// of its connection to the bus, resolving the user and group names.
+// If gids is nil the groups are those of the user in the group
+// database. Ids that cannot be resolved are given by number.
+func newPeerMetadata(pid int32, uid uint32, gids []uint32) RPCMetadata {
+	metadata := RPCMetadata{
+		Pid:  pid,
+		Uid:  uid,
+		User: strconv.FormatUint(uint64(uid), 10),
+	}
+	u, err := user.LookupId(metadata.User)
+	if err == nil {
+		metadata.User = u.Username
+	}
+
+	var groupIds []string
+	if gids != nil {
+		for _, gid := range gids {
+			groupIds = append(groupIds,
+				strconv.FormatUint(uint64(gid), 10))
+		}
+	} else if u != nil {
+		groupIds, _ = u.GroupIds()
+	}
+	for _, gid := range groupIds {
+		name := gid
+		g, err := user.LookupGroupId(gid)
+		if err == nil {
+			name = g.Name
+		}
+		metadata.Groups = append(metadata.Groups, name)
+	}
+	return metadata
+}
+
+// verifyMetadata returns the metadata that a component should see for
+// a call made by peer with the supplied metadata. Only root may speak
+// for another user, as configd does when forwarding a user's request,
+// and then only the user's identity is taken from the supplied
+// metadata; for anyone else it is replaced. Root's own calls with no
+// user are described by its credentials. The trace context is kept
+// whoever the caller is.
+func verifyMetadata(supplied, peer RPCMetadata) RPCMetadata {
+	if peer.Uid == 0 && supplied.User != "" {
+		peer.User = supplied.User
+		peer.Uid = supplied.Uid
+		peer.Groups = supplied.Groups
+	}
+	peer.Traceparent = supplied.Traceparent
+	peer.Tracestate = supplied.Tracestate
+	return peer
+}
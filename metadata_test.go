@@ -0,0 +1,37 @@
+// Copyright (c) 2026, AT&T Intellectual Property. All rights reserved.
+//
+// SPDX-License-Identifier: MPL-2.0
+
+package vci
+
+import (
+	"testing"
+)
+
+func TestVerifyMetadata(t *testing.T) {
+	supplied := RPCMetadata{Uid: 1234, User: "mallory"}
+	peer := RPCMetadata{Pid: 1, Uid: 1000, User: "alice"}
+	if got := verifyMetadata(supplied, peer); got.User != "alice" {
+		t.Fatalf("spoofed metadata was trusted: %+v", got)
+	}
+	root := RPCMetadata{Pid: 1, Uid: 0, User: "root"}
+	if got := verifyMetadata(supplied, root); got.User != "mallory" {
+		t.Fatalf("root's metadata was not trusted: %+v", got)
+	}
+	proxied := RPCMetadata{Pid: 42, Uid: 1000, User: "alice",
+		Groups: []string{"vyattacfg"}}
+	got := verifyMetadata(proxied, root)
+	if got.Pid != root.Pid || got.Uid != 1000 || got.User != "alice" ||
+		len(got.Groups) != 1 || got.Groups[0] != "vyattacfg" {
+		t.Fatalf("root's user was not passed on as given: %+v", got)
+	}
+	if got := verifyMetadata(RPCMetadata{}, root); got.User != "root" {
+		t.Fatalf("root's credentials were not used: %+v", got)
+	}
+	traced := RPCMetadata{User: "mallory",
+		Traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"}
+	if got := verifyMetadata(traced, peer); got.User != "alice" ||
+		got.Traceparent != traced.Traceparent {
+		t.Fatalf("trace context was not kept: %+v", got)
+	}
+}
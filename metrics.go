@@ -0,0 +1,367 @@
+// Copyright (c) 2026, AT&T Intellectual Property. All rights reserved.
+//
+// SPDX-License-Identifier: MPL-2.0
+
+package vci
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// metricsBuckets are the upper bounds, in seconds, of the buckets of
+// the call duration histograms.
+var metricsBuckets = []float64{
+	.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10,
+}
+
+// Metrics collects counts and latencies of the calls handled by a
+// Component and made by a Client, and the state of a Client's
+// subscriptions. It is installed with the WithMetrics option and may be
+// shared by several Components and Clients. The collected metrics are
+// written in the Prometheus text exposition format.
+//
+// Calls handled by a Component are counted by model, kind, RPC module
+// and method. Calls made by a Client are its RPCs, counted by module and
+// method, and its configuration and state calls, counted by model, kind
+// and method. Failed calls are also counted by the error-tag of the
+// mgmterror returned, or "operation-failed" for other errors.
+type Metrics struct {
+	mu            sync.Mutex
+	calls         map[callMetricsKey]*callMetrics
+	subscriptions map[*Subscription]struct{}
+	// cancelled holds the counts of the subscriptions that have been
+	// cancelled, so that the counters do not go down.
+	cancelled map[subscriptionMetricsKey]*SubscriptionStats
+}
+
+type callMetricsKey struct {
+	side, kind, model, module, method string
+}
+
+type callMetrics struct {
+	count   uint64
+	errors  map[string]uint64
+	buckets []uint64
+	sum     float64
+}
+
+// NewMetrics returns an empty collection of metrics.
+func NewMetrics() *Metrics {
+	return &Metrics{
+		calls:         make(map[callMetricsKey]*callMetrics),
+		subscriptions: make(map[*Subscription]struct{}),
+		cancelled:     make(map[subscriptionMetricsKey]*SubscriptionStats),
+	}
+}
+
+// interceptor returns an interceptor that times each invocation on
+// the given side, "server" or "client".
+func (m *Metrics) interceptor(side string) Interceptor {
+	return func(
+		ctx context.Context,
+		inv *Invocation,
+		invoke Invoker,
+	) (string, error) {
+		start := time.Now()
+		out, err := invoke(ctx, inv)
+		m.observe(callMetricsKey{
+			side:   side,
+			kind:   inv.Kind,
+			model:  inv.ModelName,
+			module: inv.ModuleName,
+			method: inv.Method,
+		}, time.Since(start), err)
+		return out, err
+	}
+}
+
+// timeCall times a configuration or state call made by the Client,
+// which unlike its RPCs does not pass through the interceptors, if it
+// collects metrics.
+func (c *Client) timeCall(
+	kind, modelName, method string,
+	call func() error,
+) error {
+	if c.metrics == nil {
+		return call()
+	}
+	start := time.Now()
+	err := call()
+	c.metrics.observe(callMetricsKey{
+		side:   "client",
+		kind:   kind,
+		model:  modelName,
+		method: method,
+	}, time.Since(start), err)
+	return err
+}
+
+func (m *Metrics) observe(key callMetricsKey, d time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	call, ok := m.calls[key]
+	if !ok {
+		call = &callMetrics{
+			errors:  make(map[string]uint64),
+			buckets: make([]uint64, len(metricsBuckets)),
+		}
+		m.calls[key] = call
+	}
+	call.count++
+	seconds := d.Seconds()
+	call.sum += seconds
+	for i, bound := range metricsBuckets {
+		if seconds <= bound {
+			call.buckets[i]++
+		}
+	}
+	if err != nil {
+		call.errors[errorTag(err)]++
+	}
+}
+
+func (m *Metrics) addSubscription(s *Subscription) {
+	m.mu.Lock()
+	m.subscriptions[s] = struct{}{}
+	m.mu.Unlock()
+}
+
+func (m *Metrics) removeSubscription(s *Subscription) {
+	stats := s.Stats()
+	key := subscriptionMetricsKey{s.moduleName, s.notificationName}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.subscriptions[s]; !ok {
+		return
+	}
+	delete(m.subscriptions, s)
+	total, ok := m.cancelled[key]
+	if !ok {
+		total = &SubscriptionStats{}
+		m.cancelled[key] = total
+	}
+	total.Dropped += stats.Dropped
+	total.Coalesced += stats.Coalesced
+}
+
+type subscriptionMetricsKey struct {
+	module, notification string
+}
+
+// WriteTo writes the metrics to w in the Prometheus text exposition
+// format.
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	var buf bytes.Buffer
+	m.mu.Lock()
+	m.writeCalls(&buf)
+	subs := make([]*Subscription, 0, len(m.subscriptions))
+	for s := range m.subscriptions {
+		subs = append(subs, s)
+	}
+	totals := make(map[subscriptionMetricsKey]*SubscriptionStats,
+		len(m.cancelled))
+	for key, stats := range m.cancelled {
+		total := *stats
+		totals[key] = &total
+	}
+	m.mu.Unlock()
+	writeSubscriptions(&buf, subs, totals)
+	return buf.WriteTo(w)
+}
+
+func (m *Metrics) writeCalls(buf *bytes.Buffer) {
+	keys := make([]callMetricsKey, 0, len(m.calls))
+	for key := range m.calls {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].String() < keys[j].String()
+	})
+
+	writeMetricHeader(buf, "vci_calls_total", "counter",
+		"Calls handled by components or made by clients.")
+	for _, key := range keys {
+		writeMetric(buf, "vci_calls_total", key.labels(),
+			strconv.FormatUint(m.calls[key].count, 10))
+	}
+
+	writeMetricHeader(buf, "vci_call_errors_total", "counter",
+		"Failed calls by the error-tag of the error returned.")
+	for _, key := range keys {
+		call := m.calls[key]
+		tags := make([]string, 0, len(call.errors))
+		for tag := range call.errors {
+			tags = append(tags, tag)
+		}
+		sort.Strings(tags)
+		for _, tag := range tags {
+			writeMetric(buf, "vci_call_errors_total",
+				append(key.labels(), "tag", tag),
+				strconv.FormatUint(call.errors[tag], 10))
+		}
+	}
+
+	writeMetricHeader(buf, "vci_call_duration_seconds", "histogram",
+		"Time taken by calls handled by components or made by clients.")
+	for _, key := range keys {
+		call := m.calls[key]
+		for i, bound := range metricsBuckets {
+			writeMetric(buf, "vci_call_duration_seconds_bucket",
+				append(key.labels(), "le", formatFloat(bound)),
+				strconv.FormatUint(call.buckets[i], 10))
+		}
+		writeMetric(buf, "vci_call_duration_seconds_bucket",
+			append(key.labels(), "le", "+Inf"),
+			strconv.FormatUint(call.count, 10))
+		writeMetric(buf, "vci_call_duration_seconds_sum", key.labels(),
+			formatFloat(call.sum))
+		writeMetric(buf, "vci_call_duration_seconds_count", key.labels(),
+			strconv.FormatUint(call.count, 10))
+	}
+}
+
+// writeSubscriptions writes the state of the subscriptions, summed
+// over the subscriptions to each notification and added to the totals
+// of those that were cancelled.
+func writeSubscriptions(
+	buf *bytes.Buffer,
+	subs []*Subscription,
+	totals map[subscriptionMetricsKey]*SubscriptionStats,
+) {
+	for _, s := range subs {
+		key := subscriptionMetricsKey{s.moduleName, s.notificationName}
+		total, ok := totals[key]
+		if !ok {
+			total = &SubscriptionStats{}
+			totals[key] = total
+		}
+		stats := s.Stats()
+		total.QueueLength += stats.QueueLength
+		total.Dropped += stats.Dropped
+		total.Coalesced += stats.Coalesced
+	}
+	keys := make([]subscriptionMetricsKey, 0, len(totals))
+	for key := range totals {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].module != keys[j].module {
+			return keys[i].module < keys[j].module
+		}
+		return keys[i].notification < keys[j].notification
+	})
+
+	metrics := []struct {
+		name, typ, help string
+		value           func(*SubscriptionStats) string
+	}{
+		{"vci_subscription_queue_length", "gauge",
+			"Notifications waiting to be delivered to subscribers.",
+			func(s *SubscriptionStats) string {
+				return strconv.Itoa(s.QueueLength)
+			}},
+		{"vci_subscription_dropped_total", "counter",
+			"Notifications dropped because a subscriber's queue was full.",
+			func(s *SubscriptionStats) string {
+				return strconv.FormatUint(s.Dropped, 10)
+			}},
+		{"vci_subscription_coalesced_total", "counter",
+			"Notifications replaced by a later one before delivery.",
+			func(s *SubscriptionStats) string {
+				return strconv.FormatUint(s.Coalesced, 10)
+			}},
+	}
+	for _, metric := range metrics {
+		writeMetricHeader(buf, metric.name, metric.typ, metric.help)
+		for _, key := range keys {
+			writeMetric(buf, metric.name,
+				[]string{"module", key.module,
+					"notification", key.notification},
+				metric.value(totals[key]))
+		}
+	}
+}
+
+func (k callMetricsKey) String() string {
+	return strings.Join(k.labels(), " ")
+}
+
+// labels returns the label names and values identifying the calls,
+// omitting those that are empty.
+func (k callMetricsKey) labels() []string {
+	var labels []string
+	for _, label := range [][2]string{
+		{"side", k.side},
+		{"kind", k.kind},
+		{"model", k.model},
+		{"module", k.module},
+		{"method", k.method},
+	} {
+		if label[1] != "" {
+			labels = append(labels, label[0], label[1])
+		}
+	}
+	return labels
+}
+
+func writeMetricHeader(buf *bytes.Buffer, name, typ, help string) {
+	buf.WriteString("# HELP " + name + " " + help + "\n")
+	buf.WriteString("# TYPE " + name + " " + typ + "\n")
+}
+
+// writeMetric writes a sample. The labels are given as pairs of name
+// and value.
+func writeMetric(
+	buf *bytes.Buffer,
+	name string,
+	labels []string,
+	value string,
+) {
+	buf.WriteString(name)
+	if len(labels) != 0 {
+		buf.WriteByte('{')
+		for i := 0; i < len(labels); i += 2 {
+			if i != 0 {
+				buf.WriteByte(',')
+			}
+			buf.WriteString(labels[i] + `="` +
+				escapeLabelValue(labels[i+1]) + `"`)
+		}
+		buf.WriteByte('}')
+	}
+	buf.WriteString(" " + value + "\n")
+}
+
+var labelValueEscaper = strings.NewReplacer(
+	`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(value string) string {
+	return labelValueEscaper.Replace(value)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// ServeHTTP writes the metrics in response to any request, so that
+// Metrics may be used as an http.Handler.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	m.WriteTo(w)
+}
+
+// Serve serves the metrics over HTTP on the listener until it fails or
+// is closed. The listener may be a local unix socket, for example
+// net.Listen("unix", "/run/vci/exampled.metrics"), or a TCP listener.
+func (m *Metrics) Serve(listener net.Listener) error {
+	return http.Serve(listener, m)
+}
@@ -0,0 +1,204 @@
+// Copyright (c) 2026, AT&T Intellectual Property. All rights reserved.
+//
+// SPDX-License-Identifier: MPL-2.0
+
+package vci
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/danos/mgmterror"
+)
+
+func checkMetricLines(t *testing.T, out string, lines ...string) {
+	for _, line := range lines {
+		if !strings.Contains(out, line+"\n") {
+			t.Fatalf("missing %q from metrics:\n%s", line, out)
+		}
+	}
+}
+
+func TestMetricsCalls(t *testing.T) {
+	const model = "com.vyatta.test.foo.v1"
+	resetTestBus()
+	metrics := NewMetrics()
+	comp := NewComponentWithOptions("com.vyatta.test.foo",
+		WithTransport(newTestTransport()),
+		WithMetrics(metrics))
+	comp.Model(model).
+		Config(&testRunningConfigWithValue{testConfig{Value: "foo"}}).
+		RPC("foo-v1", map[string]interface{}{
+			"call-me": func(in *testConfig) (*testConfig, error) {
+				if in.Value == "fail" {
+					return nil, mgmterror.NewInvalidValueApplicationError()
+				}
+				return in, nil
+			},
+		})
+	err := comp.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := DialWithOptions(WithMetrics(metrics))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	err = client.SetConfigForModel(model, &testConfig{Value: "bar"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out testConfig
+	err = client.StoreConfigByModelInto(model, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, value := range []string{"foo", "fail", "fail"} {
+		_ = client.Call("foo-v1", "call-me", &testConfig{Value: value}).
+			StoreOutputInto(&out)
+	}
+
+	var buf bytes.Buffer
+	_, err = metrics.WriteTo(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := `side="server",kind="rpc",model="` + model +
+		`",module="foo-v1",method="call-me"`
+	clientRPC := `side="client",kind="rpc",module="foo-v1",method="call-me"`
+	checkMetricLines(t, buf.String(),
+		"# TYPE vci_calls_total counter",
+		`vci_calls_total{side="server",kind="config",model="`+model+
+			`",method="set"} 1`,
+		`vci_calls_total{side="client",kind="config",model="`+model+
+			`",method="set"} 1`,
+		`vci_calls_total{side="client",kind="config",model="`+model+
+			`",method="get"} 1`,
+		"vci_calls_total{"+server+"} 3",
+		"vci_calls_total{"+clientRPC+"} 3",
+		"vci_call_errors_total{"+server+`,tag="invalid-value"} 2`,
+		"vci_call_errors_total{"+clientRPC+`,tag="invalid-value"} 2`,
+		"# TYPE vci_call_duration_seconds histogram",
+		"vci_call_duration_seconds_bucket{"+server+`,le="+Inf"} 3`,
+		"vci_call_duration_seconds_count{"+server+"} 3",
+	)
+}
+
+func TestMetricsSubscriptions(t *testing.T) {
+	resetTestBus()
+	metrics := NewMetrics()
+	client, err := DialWithOptions(WithMetrics(metrics))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	sub := client.Subscribe("foo-v1", "bar",
+		func(map[string]interface{}) {})
+	err = sub.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	metrics.mu.Lock()
+	_, ok := metrics.subscriptions[sub]
+	metrics.mu.Unlock()
+	if !ok {
+		t.Fatal("running subscription was not added to the metrics")
+	}
+	err = sub.Cancel()
+	if err != nil {
+		t.Fatal(err)
+	}
+	metrics.mu.Lock()
+	_, ok = metrics.subscriptions[sub]
+	metrics.mu.Unlock()
+	if ok {
+		t.Fatal("cancelled subscription was not removed from the metrics")
+	}
+
+	// Notifications delivered to a subscription that is not running
+	// stay in its queue.
+	dropping := client.Subscribe("foo-v1", "bar",
+		func(map[string]interface{}) {}).DropAfterLimit(2)
+	coalescing := client.Subscribe("foo-v1", "baz",
+		func(map[string]interface{}) {}).Coalesce()
+	for _, val := range []string{"a", "b", "c", "d", "e"} {
+		dropping.Deliver(`{"baz":"` + val + `"}`)
+		coalescing.Deliver(`{"baz":"` + val + `"}`)
+	}
+	metrics.addSubscription(dropping)
+	metrics.addSubscription(coalescing)
+
+	var buf bytes.Buffer
+	_, err = metrics.WriteTo(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkMetricLines(t, buf.String(),
+		`vci_subscription_queue_length{module="foo-v1",notification="bar"} 2`,
+		`vci_subscription_dropped_total{module="foo-v1",notification="bar"} 3`,
+		`vci_subscription_queue_length{module="foo-v1",notification="baz"} 1`,
+		`vci_subscription_coalesced_total{module="foo-v1",notification="baz"} 4`,
+	)
+
+	// The counts of cancelled subscriptions are kept.
+	metrics.removeSubscription(dropping)
+	metrics.removeSubscription(coalescing)
+	buf.Reset()
+	_, err = metrics.WriteTo(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkMetricLines(t, buf.String(),
+		`vci_subscription_queue_length{module="foo-v1",notification="bar"} 0`,
+		`vci_subscription_dropped_total{module="foo-v1",notification="bar"} 3`,
+		`vci_subscription_coalesced_total{module="foo-v1",notification="baz"} 4`,
+	)
+}
+
+func TestMetricsServe(t *testing.T) {
+	metrics := NewMetrics()
+	metrics.observe(callMetricsKey{side: "server", kind: "config",
+		model: "a\"b", method: "set"}, 0, nil)
+
+	socket := filepath.Join(t.TempDir(), "metrics")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go metrics.Serve(listener)
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(
+			ctx context.Context,
+			_, _ string,
+		) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", socket)
+		},
+	}}
+	resp, err := client.Get("http://vci/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkMetricLines(t, string(body),
+		`vci_calls_total{side="server",kind="config",model="a\"b",`+
+			`method="set"} 1`,
+		`vci_call_duration_seconds_bucket{side="server",kind="config",`+
+			`model="a\"b",method="set",le="0.001"} 1`,
+	)
+}
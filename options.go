@@ -0,0 +1,228 @@
+// Copyright (c) 2026, AT&T Intellectual Property. All rights reserved.
+//
+// SPDX-License-Identifier: MPL-2.0
+
+package vci
+
+import (
+	"errors"
+
+	"github.com/danos/vci/conf"
+)
+
+// An Option changes how a Client or Component attaches to the bus.
+// Options are passed to DialWithOptions and NewComponentWithOptions.
+type Option func(*options)
+
+type options struct {
+	transport          func() Transport
+	marshaller         Marshaller
+	serverInterceptors []Interceptor
+	callInterceptors   []Interceptor
+	metrics            *Metrics
+	tracer             *tracer
+	panicLimit         int32
+	configFile         string
+
+	skipRPCIntrospection bool
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		transport:  defaultTransport,
+		marshaller: defaultMarshaller(),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.skipRPCIntrospection {
+		transport := o.transport
+		o.transport = func() Transport {
+			t := transport()
+			skipper, ok := t.(rpcIntrospectionSkipper)
+			if !ok {
+				return &unsupportedOptionTransport{Transport: t,
+					option: "WithoutRPCIntrospection"}
+			}
+			skipper.SkipRPCIntrospection()
+			return t
+		}
+	}
+	return o
+}
+
+// rpcIntrospectionSkipper is implemented by transports that can call
+// RPCs without first checking that the component implements them.
+type rpcIntrospectionSkipper interface {
+	SkipRPCIntrospection()
+}
+
+// unsupportedOptionTransport stands in for a transport that does not
+// support one of the options it was created with, so that the Client or
+// Component fails to dial instead of silently ignoring the option.
+type unsupportedOptionTransport struct {
+	Transport
+	option string
+}
+
+func (t *unsupportedOptionTransport) Dial() error {
+	return errors.New("transport does not support " + t.option)
+}
+
+// WithBusAddress connects to the D-Bus bus at the given address instead
+// of the VCI bus, for example "unix:path=/run/vci/private_bus_socket".
+func WithBusAddress(address string) Option {
+	return func(o *options) {
+		o.transport = func() Transport {
+			return newDBusAddressTransport(address)
+		}
+	}
+}
+
+// WithSessionBus connects to the D-Bus session bus instead of the VCI
+// bus. This is useful for running components in test environments.
+func WithSessionBus() Option {
+	return func(o *options) {
+		o.transport = func() Transport {
+			return newDBusSessionTransport()
+		}
+	}
+}
+
+// WithTransport uses the supplied transport instead of D-Bus. The Client
+// or Component takes over the transport and closes it when it is closed
+// or stopped, so a transport must not be supplied to more than one.
+func WithTransport(transport Transport) Option {
+	return func(o *options) {
+		o.transport = func() Transport {
+			return transport
+		}
+	}
+}
+
+// WithMarshaller uses the supplied marshaller instead of the RFC7951
+// encoding to convert objects to and from their encoded form.
+func WithMarshaller(marshaller Marshaller) Option {
+	return func(o *options) {
+		o.marshaller = marshaller
+	}
+}
+
+// WithServerInterceptors passes every call to a Component's
+// configuration, state and RPC handlers through the interceptors. It
+// may be given more than once, adding to the interceptors already
+// supplied.
+func WithServerInterceptors(interceptors ...Interceptor) Option {
+	return func(o *options) {
+		o.serverInterceptors = append(o.serverInterceptors,
+			interceptors...)
+	}
+}
+
+// WithCallInterceptors passes every RPC called by a Client, or by a
+// Component's Client, through the interceptors. It may be given more
+// than once, adding to the interceptors already supplied.
+func WithCallInterceptors(interceptors ...Interceptor) Option {
+	return func(o *options) {
+		o.callInterceptors = append(o.callInterceptors,
+			interceptors...)
+	}
+}
+
+// WithAuthorizationPolicy denies calls to a Component's RPCs and
+// configuration handlers that the policy does not allow, returning an
+// access-denied error before the handler runs. The policy may be loaded
+// with conf.LoadPolicyFile, from a policy file or from the Policy
+// sections of the component's '.component' file. It is enforced by a
+// server interceptor, placed in the order the options are given.
+func WithAuthorizationPolicy(policy *conf.Policy) Option {
+	return func(o *options) {
+		o.serverInterceptors = append(o.serverInterceptors,
+			authorizationInterceptor(policy))
+	}
+}
+
+// WithComponentConfig takes the ConfigFile of a Component from its
+// '.component' file, as loaded by conf.ParseConfiguration. The model
+// with a configuration handler keeps its running configuration in the
+// first file listed, as though it had been given to Model.ConfigFile,
+// unless it names a file of its own. Running the component fails if
+// more than one model is left to share the file. The Policy sections are
+// not used; give them to WithAuthorizationPolicy.
+func WithComponentConfig(config *conf.ServiceConfig) Option {
+	return func(o *options) {
+		if len(config.ConfigFiles) != 0 {
+			o.configFile = config.ConfigFiles[0]
+		}
+	}
+}
+
+// WithAudit records every change to and check of a Component's
+// configuration, and every call to its RPCs, in the sink. The values of
+// the leaves named in redact are removed from the recorded input and
+// output. Auditing is done by a server interceptor, placed in the order
+// the options are given; give WithAudit before WithAuthorizationPolicy
+// to record the calls the policy denies.
+func WithAudit(sink AuditSink, redact ...string) Option {
+	return func(o *options) {
+		o.serverInterceptors = append(o.serverInterceptors,
+			auditInterceptor(sink, redact))
+	}
+}
+
+// WithMetrics collects metrics of the calls handled by a Component and
+// of the RPCs called and subscriptions made by a Client, or by a
+// Component's Client. Timing is done by interceptors, placed in the
+// order the options are given.
+func WithMetrics(metrics *Metrics) Option {
+	return func(o *options) {
+		o.serverInterceptors = append(o.serverInterceptors,
+			metrics.interceptor("server"))
+		o.callInterceptors = append(o.callInterceptors,
+			metrics.interceptor("client"))
+		o.metrics = metrics
+	}
+}
+
+// WithTracing records a span, passed to the exporter as it ends, for
+// each call handled by a Component and each RPC and configuration change
+// or check made by a Client, or by a Component's Client. Spans continue
+// the trace of the context a call is made with, or of the trace context
+// sent by the caller, and their context is sent on with the calls made.
+// Tracing is done by interceptors, placed in the order the options are
+// given.
+func WithTracing(exporter SpanExporter) Option {
+	return func(o *options) {
+		t := newTracer(exporter)
+		o.serverInterceptors = append(o.serverInterceptors,
+			t.interceptor("server"))
+		o.callInterceptors = append(o.callInterceptors,
+			t.interceptor("client"))
+		o.tracer = t
+	}
+}
+
+// WithPanicLimit makes a Component exit the process, with status 1, once
+// its handlers have panicked limit times, so that it can be restarted in
+// a known state, for example by systemd. A panic in a handler is always
+// recovered, logged with its stack and returned to the caller as an
+// operation-failed error. Without this option, or with a limit of zero,
+// the component keeps running however many panics there are.
+func WithPanicLimit(limit int) Option {
+	return func(o *options) {
+		o.panicLimit = int32(limit)
+	}
+}
+
+// WithoutRPCIntrospection makes RPC calls over D-Bus without first
+// introspecting the component to check that it implements the RPC, which
+// saves a round trip the first time each module's RPCs are called after
+// the component starts. A call to an RPC the component does not implement
+// then fails with an operation-not-supported error when its output is
+// read rather than when it is made. Dialling fails with transports that
+// do not support the option.
+func WithoutRPCIntrospection() Option {
+	return func(o *options) {
+		o.skipRPCIntrospection = true
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright (c) 2026, AT&T Intellectual Property. All rights reserved.
+//
+// SPDX-License-Identifier: MPL-2.0
+
+package vci
+
+import (
+	"os/exec"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+type testCountingMarshaller struct {
+	rfc7951Marshaller
+	marshals   int32
+	unmarshals int32
+}
+
+func (m *testCountingMarshaller) Marshal(object interface{}) (string, error) {
+	atomic.AddInt32(&m.marshals, 1)
+	return m.rfc7951Marshaller.Marshal(object)
+}
+
+func (m *testCountingMarshaller) Unmarshal(
+	data string,
+	object interface{},
+) error {
+	atomic.AddInt32(&m.unmarshals, 1)
+	return m.rfc7951Marshaller.Unmarshal(data, object)
+}
+
+func TestDialWithOptions(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		resetTestBus()
+		client, err := DialWithOptions()
+		if err != nil {
+			t.Fatal(err)
+		}
+		client.Close()
+	})
+	t.Run("failing-transport", func(t *testing.T) {
+		resetTestBus()
+		tBus.toggleDialFailure()
+		defer tBus.toggleDialFailure()
+		_, err := DialWithOptions(WithTransport(newTestTransport()))
+		if err == nil {
+			t.Fatal("expected error did not occur")
+		}
+	})
+	t.Run("bus-address", func(t *testing.T) {
+		if _, err := exec.LookPath("dbus-daemon"); err != nil {
+			t.Skip("dbus-daemon is not available")
+		}
+		address := "unix:path=" + filepath.Join(t.TempDir(), "bus")
+		daemon := startTestDBusDaemon(t, address)
+		defer daemon.stop()
+		client, err := DialWithOptions(WithBusAddress(address))
+		if err != nil {
+			t.Fatal(err)
+		}
+		client.Close()
+	})
+}
+
+func TestOptionsMarshaller(t *testing.T) {
+	resetTestBus()
+	compMarshaller := &testCountingMarshaller{}
+	comp := NewComponentWithOptions("net.vyatta.test",
+		WithTransport(newTestTransport()),
+		WithMarshaller(compMarshaller))
+	comp.Model("net.vyatta.test.v1").
+		Config(&testRunningConfigWithValue{testConfig{Value: "foo"}})
+	err := comp.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer comp.Stop()
+
+	clientMarshaller := &testCountingMarshaller{}
+	client, err := DialWithOptions(WithMarshaller(clientMarshaller))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	var cfg testConfig
+	err = client.StoreConfigByModelInto("net.vyatta.test.v1", &cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Value != "foo" {
+		t.Fatalf("expected %q, got %q", "foo", cfg.Value)
+	}
+	if atomic.LoadInt32(&compMarshaller.marshals) == 0 {
+		t.Fatal("component did not use the supplied marshaller")
+	}
+	if atomic.LoadInt32(&clientMarshaller.unmarshals) == 0 {
+		t.Fatal("client did not use the supplied marshaller")
+	}
+}
+
+func TestOptionsWithoutRPCIntrospection(t *testing.T) {
+	for _, opts := range [][]Option{
+		{WithSessionBus(), WithoutRPCIntrospection()},
+		{WithoutRPCIntrospection(), WithSessionBus()},
+	} {
+		tport, ok := newOptions(opts).transport().(*dbusTransport)
+		if !ok {
+			t.Fatal("expected a D-Bus transport")
+		}
+		if !tport.skipRPCIntrospection {
+			t.Fatal("RPC introspection was not skipped")
+		}
+	}
+	tport := newOptions([]Option{WithSessionBus()}).transport().(*dbusTransport)
+	if tport.skipRPCIntrospection {
+		t.Fatal("RPC introspection was skipped without the option")
+	}
+
+	resetTestBus()
+	_, err := DialWithOptions(WithTransport(newTestTransport()),
+		WithoutRPCIntrospection())
+	if err == nil {
+		t.Fatal("option was ignored by a transport that does not support it")
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright (c) 2026, AT&T Intellectual Property. All rights reserved.
+//
+// SPDX-License-Identifier: MPL-2.0
+
+package vci
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// configFile keeps a copy of a model's running configuration in a file,
+// so that the component can restore its configuration when it restarts
+// without waiting for the configuration system.
+type configFile struct {
+	path string
+
+	mu       sync.Mutex
+	prepared string
+}
+
+func newConfigFile(path string) *configFile {
+	return &configFile{path: path}
+}
+
+// interceptor returns an interceptor that writes the configuration to
+// the file after each successful Set, and after each successful Commit
+// of the configuration given to Prepare. The configuration has already
+// been applied by then, so failure to write it is logged rather than
+// returned to the caller.
+func (f *configFile) interceptor() Interceptor {
+	return func(
+		ctx context.Context,
+		inv *Invocation,
+		invoke Invoker,
+	) (string, error) {
+		out, err := invoke(ctx, inv)
+		if err != nil || inv.Kind != "config" {
+			return out, err
+		}
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		switch inv.Method {
+		case "set":
+			f.save(inv.Input)
+		case "prepare":
+			f.prepared = inv.Input
+		case "commit":
+			// A commit without a prepare has nothing to save.
+			if f.prepared != "" {
+				f.save(f.prepared)
+			}
+			f.prepared = ""
+		case "abort":
+			f.prepared = ""
+		}
+		return out, err
+	}
+}
+
+func (f *configFile) save(encodedData string) {
+	err := writeFileAtomic(f.path, []byte(encodedData), 0600)
+	if err != nil {
+		log.Printf("vci: unable to save configuration to %s: %s",
+			f.path, err)
+	}
+}
+
+// read returns the saved configuration, which is empty if there is
+// none.
+func (f *configFile) read() (string, error) {
+	data, err := ioutil.ReadFile(f.path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	return string(data), err
+}
+
+// writeFileAtomic replaces the file with one holding the data, so that
+// readers see either the old or the new contents and never part of
+// them, even if the system fails while it is written.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path),
+		"."+filepath.Base(path)+".")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), perm)
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// assignConfigFile gives the component's config file, named by the
+// ConfigFile of its '.component' file, to the model with a configuration
+// handler that has no config file of its own. Models cannot share the
+// file as each would overwrite the other's configuration.
+func (c *component) assignConfigFile() error {
+	if c.configFile == "" {
+		return nil
+	}
+	var owner *model
+	for _, model := range c.models {
+		if model.cfg == nil || model.configFile != nil {
+			continue
+		}
+		if owner != nil {
+			return fmt.Errorf(
+				"models %s and %s cannot share the config file %s",
+				owner.name, model.name, c.configFile)
+		}
+		owner = model
+	}
+	if owner != nil {
+		owner.configFile = newConfigFile(c.configFile)
+	}
+	return nil
+}
+
+// restoreConfig sets the configuration saved in the model's config
+// file, if it has one. The configuration is given straight to the
+// handler, as it has already been checked and allowed. A configuration
+// that cannot be restored is logged rather than stopping the component,
+// which will then be configured by the configuration system instead.
+func (m *model) restoreConfig() {
+	if m.configFile == nil || m.cfg == nil || !m.cfg.IsValid() {
+		return
+	}
+	encodedData, err := m.configFile.read()
+	if err != nil {
+		log.Printf("vci: unable to restore configuration of %s: %s",
+			m.name, err)
+		return
+	}
+	if encodedData == "" || m.cfg.marshaller().IsEmptyObject(encodedData) {
+		return
+	}
+	set := m.cfg.Methods()["set"].(func(string) error)
+	err = set(encodedData)
+	if err != nil {
+		log.Printf("vci: unable to restore configuration of %s from %s: %s",
+			m.name, m.configFile.path, err)
+	}
+}
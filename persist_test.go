@@ -0,0 +1,253 @@
+// Copyright (c) 2026, AT&T Intellectual Property. All rights reserved.
+//
+// SPDX-License-Identifier: MPL-2.0
+
+package vci
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/danos/vci/conf"
+)
+
+func TestConfigFile(t *testing.T) {
+	const model = "net.vyatta.test.v1"
+	dir, err := ioutil.TempDir("", "vci-config-file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "test.conf")
+
+	run := func(t *testing.T, cfg interface{}) *Client {
+		resetTestBus()
+		comp := NewComponentWithOptions("net.vyatta.test",
+			WithTransport(newTestTransport()))
+		comp.Model(model).ConfigFile(path).Config(cfg)
+		err := comp.Run()
+		if err != nil {
+			t.Fatal(err)
+		}
+		client, err := Dial()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return client
+	}
+
+	t.Run("nothing-saved", func(t *testing.T) {
+		cfg := &testRunningConfigWithValue{}
+		client := run(t, cfg)
+		defer client.Close()
+		if cfg.Value != "" {
+			t.Fatalf("configuration was restored from nothing: %q",
+				cfg.Value)
+		}
+	})
+
+	t.Run("set-is-saved", func(t *testing.T) {
+		client := run(t, &testRunningConfigWithValue{})
+		defer client.Close()
+		err := client.SetConfigForModel(model, &testConfig{Value: "bar"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().Perm() != 0600 {
+			t.Fatalf("config file has mode %v", info.Mode())
+		}
+	})
+
+	t.Run("restored-on-run", func(t *testing.T) {
+		cfg := &testRunningConfigWithValue{}
+		client := run(t, cfg)
+		defer client.Close()
+		if cfg.Value != "bar" {
+			t.Fatalf("configuration was not restored: %q", cfg.Value)
+		}
+	})
+
+	t.Run("commit-is-saved", func(t *testing.T) {
+		var log []string
+		client := run(t, &testTxnConfig{log: &log})
+		defer client.Close()
+		err := client.Transaction().
+			SetConfigForModel(model, &testConfig{Value: "baz"}).
+			Commit()
+		if err != nil {
+			t.Fatal(err)
+		}
+		cfg := &testRunningConfigWithValue{}
+		client = run(t, cfg)
+		defer client.Close()
+		if cfg.Value != "baz" {
+			t.Fatalf("committed configuration was not restored: %q",
+				cfg.Value)
+		}
+	})
+
+	t.Run("commit-without-prepare-is-not-saved", func(t *testing.T) {
+		intercept := newConfigFile(path).interceptor()
+		_, err := intercept(context.Background(),
+			&Invocation{Kind: "config", Method: "commit"},
+			func(context.Context, *Invocation) (string, error) {
+				return "", nil
+			})
+		if err != nil {
+			t.Fatal(err)
+		}
+		cfg := &testRunningConfigWithValue{}
+		client := run(t, cfg)
+		defer client.Close()
+		if cfg.Value != "baz" {
+			t.Fatalf("saved configuration was replaced: %q", cfg.Value)
+		}
+	})
+
+	t.Run("failed-set-is-not-saved", func(t *testing.T) {
+		client := run(t, &testRunningConfigSetErrorReturn{})
+		defer client.Close()
+		err := client.SetConfigForModel(model, &testConfig{Value: "qux"})
+		if err == nil {
+			t.Fatal("expected Set to fail")
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var saved testConfig
+		err = defaultMarshaller().Unmarshal(string(data), &saved)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if saved.Value != "baz" {
+			t.Fatalf("unexpected saved configuration %q", saved.Value)
+		}
+	})
+}
+
+type testRecordingConfig struct {
+	testRunningConfigWithValue
+	name string
+	log  *[]string
+}
+
+func (c *testRecordingConfig) Set(config *testConfig) error {
+	*c.log = append(*c.log, "set "+c.name)
+	return c.testRunningConfigWithValue.Set(config)
+}
+
+// testReadyTransport records the identities requested and when the
+// component says it is ready.
+type testReadyTransport struct {
+	*testTransport
+	log *[]string
+}
+
+func (t *testReadyTransport) RequestIdentity(id string) error {
+	*t.log = append(*t.log, "request "+id)
+	return t.testTransport.RequestIdentity(id)
+}
+
+func (t *testReadyTransport) NotifyReady() error {
+	*t.log = append(*t.log, "ready")
+	return nil
+}
+
+func TestConfigFileRestoredBeforeReady(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vci-config-file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	resetTestBus()
+	var log []string
+	comp := NewComponentWithOptions("net.vyatta.test",
+		WithTransport(&testReadyTransport{
+			testTransport: newTestTransport(),
+			log:           &log,
+		}))
+	for _, name := range []string{"one", "two"} {
+		path := filepath.Join(dir, name+".conf")
+		err := ioutil.WriteFile(path, []byte(`{"value":"`+name+`"}`), 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+		comp.Model("net.vyatta.test." + name).
+			ConfigFile(path).
+			Config(&testRecordingConfig{name: name, log: &log})
+	}
+	err = comp.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer comp.Stop()
+
+	exp := []string{
+		"set one",
+		"set two",
+		"request net.vyatta.test.one",
+		"request net.vyatta.test.two",
+		"request net.vyatta.test",
+		"ready",
+	}
+	if !reflect.DeepEqual(log, exp) {
+		t.Fatalf("expected %v, got %v", exp, log)
+	}
+}
+
+func TestComponentConfigFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vci-config-file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "test.conf")
+	err = ioutil.WriteFile(path, []byte(`{"value":"saved"}`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &conf.ServiceConfig{ConfigFiles: []string{path}}
+
+	t.Run("restored", func(t *testing.T) {
+		resetTestBus()
+		comp := NewComponentWithOptions("net.vyatta.test",
+			WithTransport(newTestTransport()),
+			WithComponentConfig(config))
+		comp.Model("net.vyatta.test.v1").State(&testState{})
+		cfg := &testRunningConfigWithValue{}
+		comp.Model("net.vyatta.test.v2").Config(cfg)
+		err := comp.Run()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer comp.Stop()
+		if cfg.Value != "saved" {
+			t.Fatalf("configuration was not restored: %q", cfg.Value)
+		}
+	})
+
+	t.Run("shared", func(t *testing.T) {
+		resetTestBus()
+		comp := NewComponentWithOptions("net.vyatta.test",
+			WithTransport(newTestTransport()),
+			WithComponentConfig(config))
+		comp.Model("net.vyatta.test.v1").
+			Config(&testRunningConfigWithValue{})
+		comp.Model("net.vyatta.test.v2").
+			Config(&testRunningConfigWithValue{})
+		err := comp.Run()
+		if err == nil {
+			t.Fatal("models were allowed to share the config file")
+		}
+	})
+}
@@ -0,0 +1,127 @@
+// Copyright (c) 2026, AT&T Intellectual Property. All rights reserved.
+//
+// SPDX-License-Identifier: MPL-2.0
+
+package vci
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/danos/mgmterror"
+)
+
+// identityReleaser is implemented by transports that can give up the
+// identities they requested, so that a component that is shutting down
+// is sent no new calls.
+type identityReleaser interface {
+	ReleaseIdentities() error
+}
+
+// handlerCalls tracks the calls to a component's handlers so that, when
+// it shuts down, it can refuse new calls and wait for those in flight.
+type handlerCalls struct {
+	mu       sync.Mutex
+	stopping bool
+	wg       sync.WaitGroup
+}
+
+// begin records the start of a call, returning an error if the
+// component is shutting down.
+func (h *handlerCalls) begin() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.stopping {
+		err := mgmterror.NewResourceDeniedApplicationError()
+		err.Message = "component is shutting down"
+		return err
+	}
+	h.wg.Add(1)
+	return nil
+}
+
+func (h *handlerCalls) end() {
+	h.wg.Done()
+}
+
+// stop refuses any further calls and waits for those in flight to
+// finish, or for the context to expire.
+func (h *handlerCalls) stop(ctx context.Context) error {
+	h.mu.Lock()
+	h.stopping = true
+	h.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		h.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *component) Shutdown(ctx context.Context) error {
+	var err error
+	if releaser, ok := c.transport.(identityReleaser); ok {
+		err = releaser.ReleaseIdentities()
+	}
+	if stopErr := c.calls.stop(ctx); err == nil {
+		err = stopErr
+	}
+
+	c.subscriptions.mu.Lock()
+	c.subscriptions.runOnSubscribe = false
+	subs := make([]*Subscription, 0, len(c.subscriptions.subs))
+	for _, sub := range c.subscriptions.subs {
+		subs = append(subs, sub)
+	}
+	c.subscriptions.mu.Unlock()
+	for _, sub := range subs {
+		if drainErr := sub.Drain(ctx); err == nil {
+			err = drainErr
+		}
+	}
+
+	return c.stop(err)
+}
+
+func (c *component) RunUntilSignal(
+	timeout time.Duration,
+	signals ...os.Signal,
+) error {
+	if len(signals) == 0 {
+		signals = []os.Signal{syscall.SIGINT, syscall.SIGTERM}
+	}
+	received := make(chan os.Signal, 1)
+	signal.Notify(received, signals...)
+	defer signal.Stop(received)
+
+	err := c.Run()
+	if err != nil {
+		return err
+	}
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- c.Wait()
+	}()
+	select {
+	case <-received:
+	case err := <-stopped:
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	err = c.Shutdown(ctx)
+	if waitErr := <-stopped; err == nil {
+		err = waitErr
+	}
+	return err
+}
@@ -0,0 +1,234 @@
+// Copyright (c) 2026, AT&T Intellectual Property. All rights reserved.
+//
+// SPDX-License-Identifier: MPL-2.0
+
+package vci
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// runBlockingComponent runs a component with an RPC that blocks until
+// release is closed, telling started when it is called.
+func runBlockingComponent(
+	t *testing.T,
+) (*component, chan struct{}, chan struct{}) {
+	resetTestBus()
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	comp := NewComponent("net.vyatta.test").(*component)
+	comp.Model("net.vyatta.test.v1").
+		RPC("test-v1", map[string]interface{}{
+			"block": func(in *testConfig) (*testConfig, error) {
+				started <- struct{}{}
+				<-release
+				return in, nil
+			},
+		})
+	err := comp.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return comp, started, release
+}
+
+func (h *handlerCalls) isStopping() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.stopping
+}
+
+func TestShutdown(t *testing.T) {
+	t.Run("waits-for-calls-in-flight", func(t *testing.T) {
+		comp, started, release := runBlockingComponent(t)
+		client, err := Dial()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer client.Close()
+
+		inFlight := make(chan error, 1)
+		go func() {
+			var out testConfig
+			inFlight <- client.Call("test-v1", "block", &testConfig{}).
+				StoreOutputInto(&out)
+		}()
+		<-started
+		shutdown := make(chan error, 1)
+		go func() {
+			shutdown <- comp.Shutdown(context.Background())
+		}()
+		for !comp.calls.isStopping() {
+			time.Sleep(time.Millisecond)
+		}
+
+		var out testConfig
+		err = client.Call("test-v1", "block", &testConfig{}).
+			StoreOutputInto(&out)
+		if errorTag(err) != "resource-denied" {
+			t.Fatalf("new call was not refused: %v", err)
+		}
+		select {
+		case err := <-shutdown:
+			t.Fatalf("shut down with a call in flight: %v", err)
+		default:
+		}
+
+		close(release)
+		err = <-inFlight
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = <-shutdown
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = comp.Wait()
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("deadline", func(t *testing.T) {
+		comp, started, release := runBlockingComponent(t)
+		defer close(release)
+		client, err := Dial()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer client.Close()
+
+		go client.Call("test-v1", "block", &testConfig{})
+		<-started
+		ctx, cancel := context.WithTimeout(context.Background(),
+			10*time.Millisecond)
+		defer cancel()
+		err = comp.Shutdown(ctx)
+		if err != context.DeadlineExceeded {
+			t.Fatalf("expected the deadline to pass, got %v", err)
+		}
+		err = comp.Wait()
+		if err != context.DeadlineExceeded {
+			t.Fatalf("Wait returned %v", err)
+		}
+	})
+
+	t.Run("stop-after-shutdown", func(t *testing.T) {
+		comp, _, release := runBlockingComponent(t)
+		close(release)
+		err := comp.Shutdown(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = comp.Stop()
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = comp.Stop()
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = comp.Wait()
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("stop-keeps-first-error", func(t *testing.T) {
+		comp, started, release := runBlockingComponent(t)
+		defer close(release)
+		client, err := Dial()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer client.Close()
+
+		go client.Call("test-v1", "block", &testConfig{})
+		<-started
+		ctx, cancel := context.WithTimeout(context.Background(),
+			10*time.Millisecond)
+		defer cancel()
+		comp.Shutdown(ctx)
+		err = comp.Stop()
+		if err != context.DeadlineExceeded {
+			t.Fatalf("expected the first error, got %v", err)
+		}
+	})
+
+	t.Run("drains-subscriptions", func(t *testing.T) {
+		resetTestBus()
+		comp := NewComponent("net.vyatta.test")
+		comp.Model("net.vyatta.test.v1").
+			Config(&testRunningConfigWithValue{})
+		var delivered int32
+		err := comp.Subscribe("foo-v1", "bar",
+			func(in map[string]interface{}) {
+				time.Sleep(time.Millisecond)
+				atomic.AddInt32(&delivered, 1)
+			})
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = comp.Run()
+		if err != nil {
+			t.Fatal(err)
+		}
+		client, err := Dial()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer client.Close()
+		for i := 0; i < 10; i++ {
+			err = client.Emit("foo-v1", "bar",
+				map[string]interface{}{"baz": "quux"})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		err = comp.Shutdown(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n := atomic.LoadInt32(&delivered); n != 10 {
+			t.Fatalf("%d of 10 notifications delivered", n)
+		}
+	})
+}
+
+func TestRunUntilSignal(t *testing.T) {
+	resetTestBus()
+	// Keep the signal from stopping the test if it arrives before the
+	// component is listening for it.
+	ignored := make(chan os.Signal, 1)
+	signal.Notify(ignored, syscall.SIGUSR1)
+	defer signal.Stop(ignored)
+
+	comp := NewComponent("net.vyatta.test")
+	comp.Model("net.vyatta.test.v1").
+		Config(&testRunningConfigWithValue{})
+	done := make(chan error, 1)
+	go func() {
+		done <- comp.RunUntilSignal(time.Second, syscall.SIGUSR1)
+	}()
+	for {
+		err := syscall.Kill(os.Getpid(), syscall.SIGUSR1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Fatal(err)
+			}
+			return
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
@@ -0,0 +1,40 @@
+// Copyright (c) 2026, AT&T Intellectual Property. All rights reserved.
+//
+// SPDX-License-Identifier: MPL-2.0
+
+package vci
+
+import (
+	"context"
+)
+
+// stateChangeName stands in for the notification name when reporting
+// errors for state changes.
+const stateChangeName = "state-change"
+
+// stateChangeNotifier is implemented by transports that can publish the
+// changes to a model's state.
+type stateChangeNotifier interface {
+	// SubscribeStateChanges adds a subscriber for the state changes
+	// published by a model. As with Subscribe, the transport must
+	// support multiple subscribers for a model.
+	SubscribeStateChanges(modelName string,
+		subscriber TransportStateSubscriber) error
+	// UnsubscribeStateChanges removes a subscription to a model's state
+	// changes. The subscription is matched by the model name and the
+	// subscriber.
+	UnsubscribeStateChanges(modelName string,
+		subscriber TransportStateSubscriber) error
+	// EmitStateChange transmits the state at the path, which is empty
+	// for the whole tree, to all subscribers, including subscribers on
+	// the current connection.
+	EmitStateChange(ctx context.Context,
+		modelName, path, encodedState string) error
+}
+
+// stateChange is queued by subscriptions to a model's state changes,
+// which need to know the path of each.
+type stateChange struct {
+	path  string
+	state string
+}
@@ -6,12 +6,80 @@
 package vci
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"github.com/danos/vci/internal/queue"
 	"reflect"
 	"sync"
+	"sync/atomic"
+
+	"github.com/danos/mgmterror"
+)
+
+// NotificationErrorKind classifies why a notification could not be
+// delivered to a subscriber.
+type NotificationErrorKind int
+
+const (
+	// NotificationInvalid means the notification failed validation
+	// against its YANG definition.
+	NotificationInvalid NotificationErrorKind = iota
+	// NotificationUndecodable means the notification could not be
+	// decoded into the subscriber's input type.
+	NotificationUndecodable
+	// NotificationMalformed means the transport received something
+	// that was not a notification it could understand.
+	NotificationMalformed
 )
 
+func (k NotificationErrorKind) String() string {
+	switch k {
+	case NotificationInvalid:
+		return "invalid"
+	case NotificationUndecodable:
+		return "undecodable"
+	case NotificationMalformed:
+		return "malformed"
+	}
+	return "unknown"
+}
+
+// A NotificationError describes a notification that was received but
+// not delivered to the subscriber. Payload holds what was received,
+// the encoded notification unless the Kind is NotificationMalformed.
+type NotificationError struct {
+	Kind             NotificationErrorKind
+	ModuleName       string
+	NotificationName string
+	Payload          string
+	Err              error
+}
+
+func (e *NotificationError) Error() string {
+	return e.Kind.String() + " notification " +
+		e.ModuleName + ":" + e.NotificationName + ": " + e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *NotificationError) Unwrap() error {
+	return e.Err
+}
+
+// SubscriptionStats counts the notifications a Subscription has
+// received by their outcome, and describes its queue. Dropped and
+// Coalesced count the notifications lost by the flow control policies
+// set with DropAfterLimit and Coalesce.
+type SubscriptionStats struct {
+	Delivered   uint64
+	Invalid     uint64
+	Undecodable uint64
+	Malformed   uint64
+	QueueLength int
+	Dropped     uint64
+	Coalesced   uint64
+}
+
 // The Subscription type represents a process that listens
 // for notifications and reports them to the subscriber.
 // The input for this process is a queue of messages delivered
@@ -19,24 +87,46 @@ import (
 // Flow control policies can be set before the process is started or may
 // be set at any point during processing.
 type Subscription struct {
+	// stats is first so it is 64-bit aligned for atomic access.
+	stats struct {
+		delivered   uint64
+		invalid     uint64
+		undecodable uint64
+		malformed   uint64
+		// dropped and coalesced hold the counts of the queues
+		// the subscription no longer uses.
+		dropped   uint64
+		coalesced uint64
+	}
+
 	client           *Client
-	subscriber       func(interface{})
+	subscriber       func(path string, value interface{})
 	moduleName       string
 	notificationName string
 	inputType        reflect.Type
 	err              error
 
+	// watchesState is set if the subscription is to the state changes
+	// of the model named by moduleName rather than to a notification.
+	watchesState bool
+	// watchesModule is set if the subscription is to all of the
+	// notifications of the module.
+	watchesModule bool
+
 	running *multiWriterValue
+	stopped *multiWriterValue
 	done    *multiWriterValue
 	cache   *multiWriterValue
+	raw     *multiWriterValue
 	queue   *protectedQueue
 	last    *multiWriterValue
+	onError *multiWriterValue
 }
 
 func newSubscription(
 	client *Client,
 	moduleName, notificationName string,
-	subscriber func(interface{}),
+	subscriber func(string, interface{}),
 	inputType reflect.Type,
 	err error,
 ) *Subscription {
@@ -49,9 +139,12 @@ func newSubscription(
 		err:              err,
 		done:             newMultiWriterValue(false),
 		running:          newMultiWriterValue(false),
+		stopped:          newMultiWriterValue((chan struct{})(nil)),
 		cache:            newMultiWriterValue(false),
+		raw:              newMultiWriterValue(false),
 		queue:            newProtectedQueue(queue.NewUnbounded()),
 		last:             newMultiWriterValue(""),
+		onError:          newMultiWriterValue((func(*NotificationError))(nil)),
 	}
 }
 
@@ -60,12 +153,14 @@ func (s *Subscription) Cancel() error {
 	if !s.isRunning() {
 		return nil
 	}
-	err := s.client.transport.Unsubscribe(s.moduleName,
-		s.notificationName, s)
+	err := s.unsubscribe()
 	if err != nil {
 		return err
 	}
 	s.done.Update(func(_ interface{}) interface{} { return true })
+	if s.client.metrics != nil {
+		s.client.metrics.removeSubscription(s)
+	}
 	s.queue.Update(func(in queue.Queue) queue.Queue {
 		q := in.(queue.Queue)
 		q.Close()
@@ -74,6 +169,26 @@ func (s *Subscription) Cancel() error {
 	return nil
 }
 
+// Drain cancels the subscription and waits for the notifications already
+// queued to be delivered to the subscriber. If the context expires first
+// its error is returned and the rest are delivered in the background.
+func (s *Subscription) Drain(ctx context.Context) error {
+	if !s.isRunning() {
+		return nil
+	}
+	stopped := s.stopped.Load().(chan struct{})
+	err := s.Cancel()
+	if err != nil {
+		return err
+	}
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // ToggleCaching toggles cacheing of the last notification.
 func (s *Subscription) ToggleCaching() *Subscription {
 	s.cache.Update(func(cache interface{}) interface{} {
@@ -87,6 +202,15 @@ func (s *Subscription) ToggleCaching() *Subscription {
 	return s
 }
 
+// SkipValidation delivers notifications as they are received, without
+// first validating them against their YANG definitions. This is for
+// tools that watch the bus, and should otherwise be avoided as the
+// notifications are not given their default values.
+func (s *Subscription) SkipValidation() *Subscription {
+	s.raw.Update(func(interface{}) interface{} { return true })
+	return s
+}
+
 // Coalesce collapses notifications if the sender overruns
 // the receiver. In some situations one need not be concerned with
 // intermediate states so they can be collapsed so the last notification
@@ -119,6 +243,31 @@ func (s *Subscription) RemoveLimit() *Subscription {
 	return s
 }
 
+// OnError sets a handler that is called for each notification that is
+// received but cannot be delivered to the subscriber, because it fails
+// validation, cannot be decoded or is malformed. The handler is called
+// from the goroutine that calls the subscriber, in the order the
+// notifications were received. A nil handler removes the current one.
+func (s *Subscription) OnError(handler func(*NotificationError)) *Subscription {
+	s.onError.Update(func(interface{}) interface{} { return handler })
+	return s
+}
+
+// Stats returns counts of the notifications received by the
+// subscription, by outcome, and the state of its queue.
+func (s *Subscription) Stats() SubscriptionStats {
+	q := queue.StatsOf(s.queue.Load())
+	return SubscriptionStats{
+		Delivered:   atomic.LoadUint64(&s.stats.delivered),
+		Invalid:     atomic.LoadUint64(&s.stats.invalid),
+		Undecodable: atomic.LoadUint64(&s.stats.undecodable),
+		Malformed:   atomic.LoadUint64(&s.stats.malformed),
+		QueueLength: q.Length,
+		Dropped:     atomic.LoadUint64(&s.stats.dropped) + q.Dropped,
+		Coalesced:   atomic.LoadUint64(&s.stats.coalesced) + q.Coalesced,
+	}
+}
+
 // StoreLastNotificationInto allows one to retrieve the last
 // notification that was sent if caching is enabled.
 func (s *Subscription) StoreLastNotificationInto(object interface{}) error {
@@ -137,17 +286,59 @@ func (s *Subscription) Run() error {
 	if s.isRunning() {
 		return nil
 	}
-	err := s.client.transport.Subscribe(
-		s.moduleName, s.notificationName,
-		s)
+	err := s.subscribe()
 	if err != nil {
 		return err
 	}
 	s.running.Update(func(interface{}) interface{} { return true })
-	go s.processNotifications()
+	if s.client.metrics != nil {
+		s.client.metrics.addSubscription(s)
+	}
+	stopped := make(chan struct{})
+	s.stopped.Update(func(interface{}) interface{} { return stopped })
+	go s.processNotifications(stopped)
 	return nil
 }
 
+func (s *Subscription) subscribe() error {
+	if s.watchesState {
+		notifier, ok := s.client.transport.(stateChangeNotifier)
+		if !ok {
+			return mgmterror.NewOperationNotSupportedApplicationError()
+		}
+		return notifier.SubscribeStateChanges(s.moduleName, s)
+	}
+	if s.watchesModule {
+		subscriber, ok := s.client.transport.(moduleSubscriber)
+		if !ok {
+			return mgmterror.NewOperationNotSupportedApplicationError()
+		}
+		return subscriber.SubscribeModule(s.moduleName, s)
+	}
+	return s.client.transport.Subscribe(
+		s.moduleName, s.notificationName,
+		s)
+}
+
+func (s *Subscription) unsubscribe() error {
+	if s.watchesState {
+		notifier, ok := s.client.transport.(stateChangeNotifier)
+		if !ok {
+			return mgmterror.NewOperationNotSupportedApplicationError()
+		}
+		return notifier.UnsubscribeStateChanges(s.moduleName, s)
+	}
+	if s.watchesModule {
+		subscriber, ok := s.client.transport.(moduleSubscriber)
+		if !ok {
+			return mgmterror.NewOperationNotSupportedApplicationError()
+		}
+		return subscriber.UnsubscribeModule(s.moduleName, s)
+	}
+	return s.client.transport.Unsubscribe(s.moduleName,
+		s.notificationName, s)
+}
+
 // Deliver will place the notificaiton on the input queue for
 // the subscription.
 func (s *Subscription) Deliver(encodedData string) error {
@@ -156,6 +347,33 @@ func (s *Subscription) Deliver(encodedData string) error {
 	return nil
 }
 
+// DeliverNotification places a notification received by a
+// subscription to all of a module's notifications on the input queue,
+// along with its name.
+func (s *Subscription) DeliverNotification(name, encodedData string) error {
+	payload, err := encodeModuleNotification(name, encodedData)
+	if err != nil {
+		return err
+	}
+	return s.Deliver(payload)
+}
+
+// DeliverStateChange places a state change received by a subscription
+// to a model's state changes on the input queue, along with its path.
+func (s *Subscription) DeliverStateChange(path, encodedState string) error {
+	queue := s.queue.Load()
+	queue.Enqueue(&stateChange{path: path, state: encodedState})
+	return nil
+}
+
+// DeliverMalformed places a report of a notification that the
+// transport could not make sense of on the input queue, so that it is
+// reported in turn with the notifications delivered to the subscriber.
+func (s *Subscription) DeliverMalformed(payload string, err error) {
+	queue := s.queue.Load()
+	queue.Enqueue(&malformedNotification{payload: payload, err: err})
+}
+
 // isDone allows one to test whether the Subscription has been canceled.
 func (s *Subscription) isDone() bool {
 	return s.done.Load().(bool)
@@ -166,6 +384,9 @@ func (s *Subscription) swapQueue(new queue.Queue) {
 		old := in.(queue.Queue)
 		old.Close()
 		queue.Move(new, old)
+		stats := queue.StatsOf(old)
+		atomic.AddUint64(&s.stats.dropped, stats.Dropped)
+		atomic.AddUint64(&s.stats.coalesced, stats.Coalesced)
 		return new
 	})
 }
@@ -180,11 +401,14 @@ func (s *Subscription) cacheNotification(encodedData string) {
 }
 
 func (s *Subscription) validateNotification(
-	encodedData string,
+	name, encodedData string,
 ) (string, error) {
+	if s.raw.Load().(bool) {
+		return encodedData, nil
+	}
 	in := map[string]interface{}{
 		yangdModuleName + ":module-name": s.moduleName,
-		yangdModuleName + ":name":        s.notificationName,
+		yangdModuleName + ":name":        name,
 		yangdModuleName + ":input":       encodedData,
 	}
 
@@ -198,28 +422,160 @@ func (s *Subscription) validateNotification(
 	return result[yangdModuleName+":output"].(string), nil
 }
 
-func (s *Subscription) processNotifications() {
-	for !s.isDone() {
+// processNotifications delivers notifications until the subscription
+// is cancelled, then closes stopped. The queue is always ranged over at
+// least once, so notifications queued before a quick Cancel are still
+// delivered when the queue is drained.
+func (s *Subscription) processNotifications(stopped chan struct{}) {
+	for {
 		q := s.queue.Load()
 		queue.Range(q, func(v interface{}) {
-			encodedData := v.(string)
-			encodedData, err := s.validateNotification(encodedData)
-			if err != nil {
+			path, encodedData, ok := s.unwrapPayload(v)
+			if !ok {
 				return
 			}
 			s.cacheNotification(encodedData)
 			val, err := s.decodeInput(encodedData)
 			if err != nil {
+				name := s.notificationName
+				if s.watchesModule {
+					name = path
+				}
+				s.reportError(NotificationUndecodable, name,
+					encodedData, err)
 				return
 			}
-			s.subscriber(val)
+			atomic.AddUint64(&s.stats.delivered, 1)
+			s.subscriber(path, val)
 		})
+		if s.isDone() {
+			break
+		}
 	}
 	s.running.Update(func(interface{}) interface{} { return false })
+	close(stopped)
+}
+
+// unwrapPayload returns the encoded value carried by the queued item,
+// and for state changes the path it is found at, or for subscriptions to
+// a module the name of the notification. Notifications are first
+// validated against their YANG definition. Failures are reported and
+// false is returned.
+func (s *Subscription) unwrapPayload(
+	item interface{},
+) (path, encodedData string, ok bool) {
+	var payload string
+	switch v := item.(type) {
+	case *malformedNotification:
+		s.reportError(NotificationMalformed, s.notificationName,
+			v.payload, v.err)
+		return "", "", false
+	case *stateChange:
+		return v.path, v.state, true
+	case string:
+		payload = v
+	}
+	if s.watchesState {
+		// Delivered without a path, so it is the whole tree.
+		return "", payload, true
+	}
+	name := s.notificationName
+	if s.watchesModule {
+		notification, err := decodeModuleNotification(payload)
+		if err != nil {
+			s.reportError(NotificationMalformed, name, payload, err)
+			return "", "", false
+		}
+		name, payload = notification.Name, notification.Data
+	}
+	encodedData, err := s.validateNotification(name, payload)
+	if err != nil {
+		s.reportError(NotificationInvalid, name, payload, err)
+		return "", "", false
+	}
+	return name, encodedData, true
+}
+
+// malformedNotification is queued in place of a notification that the
+// transport could not make sense of.
+type malformedNotification struct {
+	payload string
+	err     error
+}
+
+// moduleSubscriber is implemented by transports that can subscribe to
+// all of the notifications of a module.
+type moduleSubscriber interface {
+	// SubscribeModule adds a subscriber for all of the notifications
+	// of a module. As with Subscribe, the transport must support
+	// multiple subscribers for a module.
+	SubscribeModule(moduleName string,
+		subscriber TransportModuleSubscriber) error
+	// UnsubscribeModule removes a subscription to a module's
+	// notifications. The subscription is matched by the module name and
+	// the subscriber.
+	UnsubscribeModule(moduleName string,
+		subscriber TransportModuleSubscriber) error
+}
+
+// moduleNotification is queued by subscriptions to all of a module's
+// notifications, which need to know the name of each.
+type moduleNotification struct {
+	Name string `json:"name"`
+	Data string `json:"data"`
+}
+
+func encodeModuleNotification(name, encodedData string) (string, error) {
+	buf, err := json.Marshal(&moduleNotification{
+		Name: name,
+		Data: encodedData,
+	})
+	if err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
+
+func decodeModuleNotification(payload string) (*moduleNotification, error) {
+	var notification moduleNotification
+	err := json.Unmarshal([]byte(payload), &notification)
+	if err != nil {
+		return nil, err
+	}
+	return &notification, nil
+}
+
+// reportError passes the failure to the OnError handler. The name is
+// that of the notification, which for a subscription to a whole module is
+// only known once the payload has been decoded.
+func (s *Subscription) reportError(
+	kind NotificationErrorKind,
+	name, payload string,
+	err error,
+) {
+	switch kind {
+	case NotificationInvalid:
+		atomic.AddUint64(&s.stats.invalid, 1)
+	case NotificationUndecodable:
+		atomic.AddUint64(&s.stats.undecodable, 1)
+	case NotificationMalformed:
+		atomic.AddUint64(&s.stats.malformed, 1)
+	}
+	handler := s.onError.Load().(func(*NotificationError))
+	if handler == nil {
+		return
+	}
+	handler(&NotificationError{
+		Kind:             kind,
+		ModuleName:       s.moduleName,
+		NotificationName: name,
+		Payload:          payload,
+		Err:              err,
+	})
 }
 
 func (s *Subscription) decodeInput(encodedData string) (interface{}, error) {
-	return decodeValue(s.inputType, encodedData)
+	return decodeValue(s.client.marshaller, s.inputType, encodedData)
 }
 
 func (s *Subscription) isRunning() bool {
@@ -6,8 +6,11 @@
 package vci
 
 import (
+	"errors"
 	"testing"
 	"time"
+
+	"github.com/danos/mgmterror"
 )
 
 func TestSubscription(t *testing.T) {
@@ -19,6 +22,10 @@ func TestSubscription(t *testing.T) {
 	t.Run("blocking", testBlocking)
 	t.Run("remove-limit", testRemoveLimit)
 	t.Run("cancel", testCancel)
+	t.Run("errors", testErrors)
+	t.Run("module", testModule)
+	t.Run("module-not-supported", testModuleNotSupported)
+	t.Run("skip-validation", testSkipValidation)
 }
 
 func testRun(t *testing.T) {
@@ -480,3 +487,226 @@ func testCancel(t *testing.T) {
 		}
 	})
 }
+
+func testErrors(t *testing.T) {
+	resetTestBus()
+	client, err := Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+	errs := make(chan *NotificationError, 1)
+	vals := make(chan map[string]interface{}, 1)
+	sub := client.Subscribe("foo", "bar",
+		func(in map[string]interface{}) {
+			vals <- in
+		}).OnError(func(err *NotificationError) {
+		errs <- err
+	})
+	err = sub.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Cancel()
+
+	expectError := func(
+		t *testing.T,
+		kind NotificationErrorKind,
+		payload string,
+	) {
+		select {
+		case err := <-errs:
+			if err.Kind != kind {
+				t.Fatalf("expected %s error, got %s", kind, err.Kind)
+			}
+			if err.Payload != payload {
+				t.Fatalf("expected payload %q, got %q",
+					payload, err.Payload)
+			}
+			if err.ModuleName != "foo" || err.NotificationName != "bar" {
+				t.Fatalf("unexpected notification name in %v", err)
+			}
+		case <-vals:
+			t.Fatal("unexpected notification")
+		case <-time.After(time.Second):
+			t.Fatal("didn't receive expected error")
+		}
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		tYangd.rejectNotifications = true
+		defer func() { tYangd.rejectNotifications = false }()
+		err := sub.Deliver(`{"baz":"quux"}`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectError(t, NotificationInvalid, `{"baz":"quux"}`)
+	})
+	t.Run("undecodable", func(t *testing.T) {
+		err := sub.Deliver(`["baz"]`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectError(t, NotificationUndecodable, `["baz"]`)
+	})
+	t.Run("malformed", func(t *testing.T) {
+		sub.DeliverMalformed("[1 2]", errors.New("malformed"))
+		expectError(t, NotificationMalformed, "[1 2]")
+	})
+	t.Run("delivered", func(t *testing.T) {
+		err := sub.Deliver(`{"baz":"quux"}`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case <-vals:
+		case <-time.After(time.Second):
+			t.Fatal("didn't receive expected notification")
+		}
+	})
+	t.Run("stats", func(t *testing.T) {
+		exp := SubscriptionStats{
+			Delivered:   1,
+			Invalid:     1,
+			Undecodable: 1,
+			Malformed:   1,
+		}
+		if stats := sub.Stats(); stats != exp {
+			t.Fatalf("expected %+v, got %+v", exp, stats)
+		}
+	})
+	t.Run("malformed-in-order", func(t *testing.T) {
+		err := sub.Deliver(`{"baz":"quux"}`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sub.DeliverMalformed("[1 2]", errors.New("malformed"))
+		select {
+		case <-errs:
+		case <-time.After(time.Second):
+			t.Fatal("didn't receive expected error")
+		}
+		select {
+		case <-vals:
+		default:
+			t.Fatal("error reported before the earlier notification")
+		}
+	})
+}
+
+type testNamedNotification struct {
+	name  string
+	value map[string]interface{}
+}
+
+func testModule(t *testing.T) {
+	resetTestBus()
+	client, err := Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	vals := make(chan testNamedNotification, 2)
+	sub := client.SubscribeModule("foo",
+		func(name string, in map[string]interface{}) {
+			vals <- testNamedNotification{name: name, value: in}
+		})
+	err = sub.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Cancel()
+
+	for _, name := range []string{"bar", "baz-quux"} {
+		err = client.Emit("foo", name, map[string]interface{}{"n": name})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = client.Emit("other", "bar", map[string]interface{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"bar", "baz-quux"} {
+		select {
+		case val := <-vals:
+			if val.name != name || val.value["n"] != name {
+				t.Fatalf("expected notification %s, got %+v", name, val)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("didn't receive notification %s", name)
+		}
+	}
+	select {
+	case val := <-vals:
+		t.Fatalf("received another module's notification %+v", val)
+	default:
+	}
+
+	errs := make(chan *NotificationError, 1)
+	sub.OnError(func(err *NotificationError) { errs <- err })
+	tYangd.rejectNotifications = true
+	defer func() { tYangd.rejectNotifications = false }()
+	err = client.Emit("foo", "baz-quux", map[string]interface{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-errs:
+		if err.Kind != NotificationInvalid ||
+			err.NotificationName != "baz-quux" {
+			t.Fatalf("unexpected error %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("didn't receive expected error")
+	}
+}
+
+func testModuleNotSupported(t *testing.T) {
+	resetTestBus()
+	// Embedding the interface hides the module subscription methods.
+	transport := struct{ Transport }{newTestTransport()}
+	client, err := DialWithOptions(WithTransport(transport))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	err = client.SubscribeModule("foo",
+		func(string, map[string]interface{}) {}).Run()
+	_, ok := err.(*mgmterror.OperationNotSupportedApplicationError)
+	if !ok {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func testSkipValidation(t *testing.T) {
+	resetTestBus()
+	tYangd.rejectNotifications = true
+	defer func() { tYangd.rejectNotifications = false }()
+	client, err := Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	vals := make(chan string, 1)
+	sub := client.Subscribe("foo", "bar", func(in string) {
+		vals <- in
+	}).SkipValidation()
+	err = sub.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Cancel()
+
+	err = sub.Deliver(`{"baz":"quux"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case val := <-vals:
+		if val != `{"baz":"quux"}` {
+			t.Fatalf("notification was changed: %s", val)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("didn't receive unvalidated notification")
+	}
+}
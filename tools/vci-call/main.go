@@ -0,0 +1,195 @@
+// Copyright (c) 2026, AT&T Intellectual Property. All rights reserved.
+//
+// SPDX-License-Identifier: MPL-2.0
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/danos/vci"
+)
+
+// Exit codes, by the error-tag of the error the call failed with.
+const (
+	exitOK           = 0
+	exitFailed       = 1 // not a management error, such as no bus
+	exitUsage        = 2
+	exitInvalidInput = 3
+	exitAccessDenied = 4
+	exitResource     = 5
+	exitNotSupported = 6
+	exitOperation    = 7
+	exitTimeout      = 8
+)
+
+var exitCodes = map[string]int{
+	"malformed-message":       exitInvalidInput,
+	"invalid-value":           exitInvalidInput,
+	"too-big":                 exitInvalidInput,
+	"missing-attribute":       exitInvalidInput,
+	"bad-attribute":           exitInvalidInput,
+	"unknown-attribute":       exitInvalidInput,
+	"missing-element":         exitInvalidInput,
+	"bad-element":             exitInvalidInput,
+	"unknown-element":         exitInvalidInput,
+	"unknown-namespace":       exitInvalidInput,
+	"access-denied":           exitAccessDenied,
+	"resource-denied":         exitResource,
+	"in-use":                  exitResource,
+	"lock-denied":             exitResource,
+	"operation-not-supported": exitNotSupported,
+	"operation-failed":        exitOperation,
+	"data-exists":             exitOperation,
+	"data-missing":            exitOperation,
+	"rollback-failed":         exitOperation,
+	"partial-operation":       exitOperation,
+}
+
+// errorTag returns the error-tag of a management error, or the empty
+// string if the error is not one.
+func errorTag(err error) string {
+	v := reflect.ValueOf(err)
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return ""
+	}
+	tag := v.FieldByName("Tag")
+	if tag.Kind() != reflect.String {
+		return ""
+	}
+	return tag.String()
+}
+
+func exitCode(err error) int {
+	if err == context.DeadlineExceeded {
+		return exitTimeout
+	}
+	if code, ok := exitCodes[errorTag(err)]; ok {
+		return code
+	}
+	return exitFailed
+}
+
+func exitOnError(err error) {
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitCode(err))
+	}
+}
+
+func usage() {
+	const usageFmt = `usage %s [options] module-name rpc-name [rfc7951-encoded-input]
+
+Calls the RPC with the input, read from stdin if it is not given, and
+prints its output.
+
+Options:
+`
+	const exitFmt = `
+Exit status:
+  %d  the call succeeded
+  %d  the call could not be made
+  %d  the command line is wrong
+  %d  the input was not valid for the RPC
+  %d  the caller may not make the call
+  %d  a resource the RPC needs is not available
+  %d  the RPC is not supported
+  %d  the RPC failed
+  %d  the call timed out
+`
+	fmt.Fprintf(os.Stderr, usageFmt, os.Args[0])
+	flag.PrintDefaults()
+	fmt.Fprintf(os.Stderr, exitFmt, exitOK, exitFailed, exitUsage,
+		exitInvalidInput, exitAccessDenied, exitResource,
+		exitNotSupported, exitOperation, exitTimeout)
+}
+
+func callRPC(
+	ctx context.Context,
+	module, name string,
+	meta vci.RPCMetadata,
+	input string,
+) (string, error) {
+	client, err := vci.Dial()
+	if err != nil {
+		return "", err
+	}
+	defer client.Close()
+	var output string
+	err = client.CallWithMetadataContext(ctx, module, name, meta, input).
+		StoreOutputInto(&output)
+	return output, err
+}
+
+// prettyPrint indents the output, which is printed as it is if it is
+// not JSON.
+func prettyPrint(output string) string {
+	var buf bytes.Buffer
+	err := json.Indent(&buf, []byte(output), "", "  ")
+	if err != nil {
+		return output
+	}
+	return buf.String()
+}
+
+func main() {
+	var meta vci.RPCMetadata
+	var pid int
+	var uid int
+	var groups string
+	timeout := flag.Duration("timeout", 0,
+		"give up on the call after this long, or never if 0")
+	flag.StringVar(&meta.User, "user", "", "make the call as this user")
+	flag.IntVar(&uid, "uid", 0, "make the call as this user ID")
+	flag.IntVar(&pid, "pid", 0, "make the call as this process ID")
+	flag.StringVar(&groups, "groups", "",
+		"make the call as a member of these comma-separated groups")
+	flag.Usage = usage
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) < 2 || len(args) > 3 {
+		usage()
+		os.Exit(exitUsage)
+	}
+	meta.Pid = int32(pid)
+	meta.Uid = uint32(uid)
+	if groups != "" {
+		meta.Groups = strings.Split(groups, ",")
+	}
+
+	var input string
+	switch len(args) {
+	case 2:
+		b, err := ioutil.ReadAll(os.Stdin)
+		exitOnError(err)
+		input = strings.TrimSpace(string(b))
+	default:
+		input = args[2]
+	}
+	if input == "" {
+		input = "{}"
+	}
+
+	ctx := context.Background()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+	output, err := callRPC(ctx, args[0], args[1], meta, input)
+	exitOnError(err)
+	if output != "" {
+		fmt.Println(prettyPrint(output))
+	}
+}
@@ -0,0 +1,208 @@
+// Copyright (c) 2026, AT&T Intellectual Property. All rights reserved.
+//
+// SPDX-License-Identifier: MPL-2.0
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	// diffContext is the number of unchanged lines shown around each
+	// change.
+	diffContext = 3
+	// maxDiffCells bounds the size of the table used to find the
+	// changes, at 8 bytes a cell. Configs that differ over more lines
+	// than it allows are only said to differ.
+	maxDiffCells = 1 << 22
+)
+
+// normalize indents the JSON with its object members sorted, so that
+// configs that differ only in order or layout are shown to be the same.
+// Data that is not JSON is returned as it is.
+func normalize(data string) string {
+	dec := json.NewDecoder(strings.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return data
+	}
+	if dec.Decode(new(json.RawMessage)) != io.EOF {
+		return data
+	}
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return data
+	}
+	return string(b)
+}
+
+type diffOp byte
+
+const (
+	same    diffOp = ' '
+	removed diffOp = '-'
+	added   diffOp = '+'
+)
+
+type diffLine struct {
+	op   diffOp
+	text string
+}
+
+// diffLines returns the edits that turn a into b, from their longest
+// common subsequence. It returns false, and no edits, if the lines that
+// differ are too many to compare.
+func diffLines(a, b []string) ([]diffLine, bool) {
+	// Only the lines between the common prefix and suffix need the
+	// table, which keeps it small for the usual edit of a large config.
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre &&
+		a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+	ma, mb := a[pre:len(a)-suf], b[pre:len(b)-suf]
+	if (len(ma)+1)*(len(mb)+1) > maxDiffCells {
+		return nil, false
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of
+	// ma[i:] and mb[j:].
+	lcs := make([][]int, len(ma)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(mb)+1)
+	}
+	for i := len(ma) - 1; i >= 0; i-- {
+		for j := len(mb) - 1; j >= 0; j-- {
+			switch {
+			case ma[i] == mb[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	lines := make([]diffLine, 0, len(a)+len(b)-pre-suf)
+	for _, text := range a[:pre] {
+		lines = append(lines, diffLine{same, text})
+	}
+	i, j := 0, 0
+	for i < len(ma) || j < len(mb) {
+		switch {
+		case i < len(ma) && j < len(mb) && ma[i] == mb[j]:
+			lines = append(lines, diffLine{same, ma[i]})
+			i++
+			j++
+		case j == len(mb) || (i < len(ma) && lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, diffLine{removed, ma[i]})
+			i++
+		default:
+			lines = append(lines, diffLine{added, mb[j]})
+			j++
+		}
+	}
+	for _, text := range a[len(a)-suf:] {
+		lines = append(lines, diffLine{same, text})
+	}
+	return lines, true
+}
+
+// writeDiff writes a unified diff of the two configs to w, or nothing if
+// they are the same. Configs too large to compare are only said to
+// differ.
+func writeDiff(w io.Writer, fromName, from, toName, to string) {
+	a := splitLines(normalize(from))
+	b := splitLines(normalize(to))
+	lines, ok := diffLines(a, b)
+	if !ok {
+		fmt.Fprintf(w, "%s and %s differ\n", fromName, toName)
+		return
+	}
+
+	var buf bytes.Buffer
+	// aLine and bLine are the 0-based line numbers in a and b of
+	// lines[k] as the loop reaches it.
+	aLine, bLine := 0, 0
+	for k := 0; k < len(lines); {
+		if lines[k].op == same {
+			aLine++
+			bLine++
+			k++
+			continue
+		}
+		// Take in every change whose context overlaps this one's.
+		start := k - diffContext
+		if start < 0 {
+			start = 0
+		}
+		end := k
+		for unchanged := 0; end < len(lines) && unchanged <= 2*diffContext; end++ {
+			if lines[end].op == same {
+				unchanged++
+			} else {
+				unchanged = 0
+			}
+		}
+		for end > k && lines[end-1].op == same {
+			end--
+		}
+		end += diffContext
+		if end > len(lines) {
+			end = len(lines)
+		}
+
+		hunkA, hunkB := aLine-(k-start), bLine-(k-start)
+		var countA, countB int
+		for _, l := range lines[start:end] {
+			if l.op != added {
+				countA++
+			}
+			if l.op != removed {
+				countB++
+			}
+		}
+		fmt.Fprintf(&buf, "@@ -%s +%s @@\n",
+			hunkRange(hunkA, countA), hunkRange(hunkB, countB))
+		for _, l := range lines[start:end] {
+			fmt.Fprintf(&buf, "%c%s\n", l.op, l.text)
+		}
+		aLine, bLine = hunkA+countA, hunkB+countB
+		k = end
+	}
+	if buf.Len() == 0 {
+		return
+	}
+	fmt.Fprintf(w, "--- %s\n+++ %s\n", fromName, toName)
+	buf.WriteTo(w)
+}
+
+// splitLines splits the text into lines, of which empty text has none.
+func splitLines(text string) []string {
+	text = strings.TrimSuffix(text, "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
+
+// hunkRange formats the start and length of a hunk, as diff -u does.
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
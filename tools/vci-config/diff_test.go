@@ -0,0 +1,85 @@
+// Copyright (c) 2026, AT&T Intellectual Property. All rights reserved.
+//
+// SPDX-License-Identifier: MPL-2.0
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestWriteDiff(t *testing.T) {
+	lines := func(lines ...string) string {
+		return strings.Join(lines, "\n")
+	}
+	tests := []struct {
+		desc, from, to, exp string
+	}{
+		{"identical",
+			lines("a", "b", "c"),
+			lines("a", "b", "c"),
+			""},
+		{"prefix",
+			lines("x", "1", "2", "3", "4", "5"),
+			lines("y", "1", "2", "3", "4", "5"),
+			lines("@@ -1,4 +1,4 @@", "-x", "+y", " 1", " 2", " 3", "")},
+		{"suffix",
+			lines("1", "2", "3", "4", "5", "x"),
+			lines("1", "2", "3", "4", "5", "y"),
+			lines("@@ -3,4 +3,4 @@", " 3", " 4", " 5", "-x", "+y", "")},
+		{"merged",
+			lines("a", "1", "2", "3", "4", "5", "6", "b"),
+			lines("A", "1", "2", "3", "4", "5", "6", "B"),
+			lines("@@ -1,8 +1,8 @@", "-a", "+A",
+				" 1", " 2", " 3", " 4", " 5", " 6", "-b", "+B", "")},
+		{"separate",
+			lines("a", "1", "2", "3", "4", "5", "6", "7", "b"),
+			lines("A", "1", "2", "3", "4", "5", "6", "7", "B"),
+			lines("@@ -1,4 +1,4 @@", "-a", "+A", " 1", " 2", " 3",
+				"@@ -6,4 +6,4 @@", " 5", " 6", " 7", "-b", "+B", "")},
+		{"from-empty",
+			"",
+			lines("a", "b"),
+			lines("@@ -0,0 +1,2 @@", "+a", "+b", "")},
+		{"to-empty",
+			lines("a", "b"),
+			"",
+			lines("@@ -1,2 +0,0 @@", "-a", "-b", "")},
+		{"normalized",
+			`{"b":1,"a":2}`,
+			`{"a":2, "b":1}`,
+			""},
+		{"json",
+			`{"a":1,"b":2}`,
+			`{"a":1,"b":3}`,
+			lines("@@ -1,4 +1,4 @@", " {", `   "a": 1,`, `-  "b": 2`,
+				`+  "b": 3`, " }", "")},
+	}
+	for _, test := range tests {
+		var buf bytes.Buffer
+		writeDiff(&buf, "from", test.from, "to", test.to)
+		exp := test.exp
+		if exp != "" {
+			exp = "--- from\n+++ to\n" + exp
+		}
+		if got := buf.String(); got != exp {
+			t.Errorf("%s: expected\n%s\ngot\n%s", test.desc, exp, got)
+		}
+	}
+}
+
+func TestWriteDiffTooLarge(t *testing.T) {
+	var from, to []string
+	for i := 0; i < 2100; i++ {
+		from = append(from, fmt.Sprintf("a%d", i))
+		to = append(to, fmt.Sprintf("b%d", i))
+	}
+	var buf bytes.Buffer
+	writeDiff(&buf, "from", strings.Join(from, "\n"),
+		"to", strings.Join(to, "\n"))
+	if got, exp := buf.String(), "from and to differ\n"; got != exp {
+		t.Fatalf("expected %q, got %q", exp, got)
+	}
+}
@@ -0,0 +1,181 @@
+// Copyright (c) 2026, AT&T Intellectual Property. All rights reserved.
+//
+// SPDX-License-Identifier: MPL-2.0
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/danos/vci"
+)
+
+const (
+	exitFailed = 1
+	exitUsage  = 2
+)
+
+func exitOnError(err error) {
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitFailed)
+	}
+}
+
+func usage() {
+	const usageFmt = `usage %s [options] command model-name [file]
+
+Commands:
+  get    print the model's running configuration
+  state  print the model's state
+  check  validate the configuration in the file against the model
+  set    set the model's running configuration to that in the file
+
+The configuration is RFC 7951 encoded, and read from stdin if there is
+no file or the file is "-".
+
+Options:
+`
+	fmt.Fprintf(os.Stderr, usageFmt, os.Args[0])
+	flag.PrintDefaults()
+}
+
+func readConfig(args []string) (string, error) {
+	var b []byte
+	var err error
+	if len(args) == 0 || args[0] == "-" {
+		b, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		b, err = ioutil.ReadFile(args[0])
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
+func inputName(args []string) string {
+	if len(args) == 0 || args[0] == "-" {
+		return "stdin"
+	}
+	return args[0]
+}
+
+func getConfig(
+	ctx context.Context,
+	client *vci.Client,
+	model, path string,
+) (string, error) {
+	var config string
+	if path != "" {
+		err := client.StoreConfigByPathIntoContext(ctx, model, path, &config)
+		return config, err
+	}
+	err := client.StoreConfigByModelIntoContext(ctx, model, &config)
+	return config, err
+}
+
+func getState(
+	ctx context.Context,
+	client *vci.Client,
+	model, path string,
+) (string, error) {
+	var state string
+	if path != "" {
+		err := client.StoreStateByPathIntoContext(ctx, model, path, &state)
+		return state, err
+	}
+	err := client.StoreStateByModelIntoContext(ctx, model, &state)
+	return state, err
+}
+
+// writeConfig checks or sets the configuration in the file, after
+// printing how it differs from the running configuration if asked to.
+func writeConfig(
+	ctx context.Context,
+	client *vci.Client,
+	command, model string,
+	args []string,
+	diff bool,
+) error {
+	config, err := readConfig(args)
+	if err != nil {
+		return err
+	}
+	if diff {
+		running, err := getConfig(ctx, client, model, "")
+		if err != nil {
+			return err
+		}
+		writeDiff(os.Stdout, model+" running", running,
+			inputName(args), config)
+	}
+	if command == "check" {
+		return client.CheckConfigForModelContext(ctx, model, config)
+	}
+	return client.SetConfigForModelContext(ctx, model, config)
+}
+
+func main() {
+	timeout := flag.Duration("timeout", 0,
+		"give up on the call after this long, or never if 0")
+	path := flag.String("path", "",
+		"get the configuration or state at this instance-identifier, "+
+			"not the whole tree")
+	diff := flag.Bool("diff", false,
+		"print how the file differs from the running configuration before "+
+			"checking or setting it")
+	flag.Usage = usage
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) < 2 {
+		usage()
+		os.Exit(exitUsage)
+	}
+	command, model, args := args[0], args[1], args[2:]
+	switch command {
+	case "get", "state":
+		if len(args) != 0 {
+			usage()
+			os.Exit(exitUsage)
+		}
+	case "check", "set":
+		if len(args) > 1 || *path != "" {
+			usage()
+			os.Exit(exitUsage)
+		}
+	default:
+		usage()
+		os.Exit(exitUsage)
+	}
+
+	ctx := context.Background()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+
+	client, err := vci.Dial()
+	exitOnError(err)
+	defer client.Close()
+
+	var output string
+	switch command {
+	case "get":
+		output, err = getConfig(ctx, client, model, *path)
+	case "state":
+		output, err = getState(ctx, client, model, *path)
+	default:
+		err = writeConfig(ctx, client, command, model, args, *diff)
+	}
+	exitOnError(err)
+	if output != "" {
+		fmt.Println(normalize(output))
+	}
+}
@@ -0,0 +1,165 @@
+// Copyright (c) 2026, AT&T Intellectual Property. All rights reserved.
+//
+// SPDX-License-Identifier: MPL-2.0
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/danos/encoding/rfc7951"
+	"github.com/danos/vci"
+)
+
+func exitOnError(err error) {
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	const usageFmt = `usage %s [options] [components | models | rpcs module-name]
+
+Lists the components on the bus and their models, the models alone, or
+the RPCs of a YANG module and the models that implement them. The
+components are listed if nothing is given.
+
+Options:
+`
+	fmt.Fprintf(os.Stderr, usageFmt, os.Args[0])
+	flag.PrintDefaults()
+}
+
+func printJSON(v interface{}) error {
+	b, err := rfc7951.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(b))
+	return nil
+}
+
+// features lists what a model provides, such as "config, state".
+func features(model *vci.ModelDescription) string {
+	var out []string
+	if model.Config {
+		out = append(out, "config")
+	}
+	if model.State {
+		out = append(out, "state")
+	}
+	if len(model.RPCs) != 0 {
+		out = append(out, "rpc")
+	}
+	return strings.Join(out, ", ")
+}
+
+func modules(model *vci.ModelDescription) string {
+	out := make([]string, 0, len(model.RPCs))
+	for _, rpc := range model.RPCs {
+		out = append(out, rpc.Module)
+	}
+	return strings.Join(out, ", ")
+}
+
+func printComponents(comps []vci.ComponentDescription) {
+	for _, comp := range comps {
+		name := comp.Name
+		if name == "" {
+			name = "(unnamed)"
+		}
+		fmt.Println(name)
+		for i := range comp.Models {
+			model := &comp.Models[i]
+			fmt.Printf("  %s (%s)\n", model.Name, features(model))
+			for _, rpc := range model.RPCs {
+				fmt.Printf("    %s: %s\n", rpc.Module,
+					strings.Join(rpc.Methods, ", "))
+			}
+		}
+	}
+}
+
+func printModels(models []vci.ModelDescription) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "MODEL\tCOMPONENT\tPROVIDES\tMODULES")
+	for i := range models {
+		model := &models[i]
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", model.Name, model.Component,
+			features(model), modules(model))
+	}
+	w.Flush()
+}
+
+func printRPCs(rpcs []vci.RPCDescription) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "RPC\tMODEL")
+	for _, rpc := range rpcs {
+		fmt.Fprintf(w, "%s:%s\t%s\n", rpc.Module, rpc.Name, rpc.Model)
+	}
+	w.Flush()
+}
+
+func main() {
+	timeout := flag.Duration("timeout", 0,
+		"give up on the listing after this long, or never if 0")
+	asJSON := flag.Bool("json", false, "print the listing as RFC 7951 JSON")
+	flag.Usage = usage
+	flag.Parse()
+
+	args := flag.Args()
+	command := "components"
+	if len(args) > 0 {
+		command = args[0]
+	}
+	switch {
+	case (command == "components" || command == "models") && len(args) <= 1:
+	case command == "rpcs" && len(args) == 2:
+	default:
+		usage()
+		os.Exit(2)
+	}
+
+	ctx := context.Background()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+
+	client, err := vci.Dial()
+	exitOnError(err)
+	defer client.Close()
+
+	switch command {
+	case "components":
+		comps, err := client.ListComponentsContext(ctx)
+		exitOnError(err)
+		if *asJSON {
+			exitOnError(printJSON(comps))
+			return
+		}
+		printComponents(comps)
+	case "models":
+		models, err := client.ListModelsContext(ctx)
+		exitOnError(err)
+		if *asJSON {
+			exitOnError(printJSON(models))
+			return
+		}
+		printModels(models)
+	case "rpcs":
+		rpcs, err := client.ListRPCsContext(ctx, args[1])
+		exitOnError(err)
+		if *asJSON {
+			exitOnError(printJSON(rpcs))
+			return
+		}
+		printRPCs(rpcs)
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright (c) 2026, AT&T Intellectual Property. All rights reserved.
+//
+// SPDX-License-Identifier: MPL-2.0
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/danos/vci"
+)
+
+func exitOnError(err error) {
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	const usageFmt = `usage %s [options] module-name[:notification-name]...
+
+Prints the notifications of each module, or only the named notification
+of a module, as they are emitted, until interrupted.
+
+Options:
+`
+	fmt.Fprintf(os.Stderr, usageFmt, os.Args[0])
+	flag.PrintDefaults()
+}
+
+// event is a notification as it is printed in JSON-lines mode.
+type event struct {
+	Time         time.Time       `json:"time"`
+	Module       string          `json:"module"`
+	Notification string          `json:"notification"`
+	Data         json.RawMessage `json:"data"`
+}
+
+// printer prints the notifications of every subscription, which are
+// delivered concurrently, one at a time.
+type printer struct {
+	mu        sync.Mutex
+	jsonLines bool
+}
+
+func (p *printer) print(module, name, data string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := time.Now()
+	if p.jsonLines {
+		p.printJSON(now, module, name, data)
+		return
+	}
+	fmt.Printf("%s %s:%s\n%s\n", now.Format(time.RFC3339Nano),
+		module, name, prettyPrint(data))
+}
+
+// printJSON prints the event on one line, with data that is not JSON,
+// as it may be in raw mode, as a string.
+func (p *printer) printJSON(now time.Time, module, name, data string) {
+	ev := event{Time: now, Module: module, Notification: name}
+	var buf bytes.Buffer
+	if json.Compact(&buf, []byte(data)) == nil {
+		ev.Data = buf.Bytes()
+	} else {
+		ev.Data, _ = json.Marshal(data)
+	}
+	b, err := json.Marshal(&ev)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return
+	}
+	fmt.Println(string(b))
+}
+
+// prettyPrint indents the data, which is printed as it is if it is not
+// JSON.
+func prettyPrint(data string) string {
+	var buf bytes.Buffer
+	err := json.Indent(&buf, []byte(data), "", "  ")
+	if err != nil {
+		return data
+	}
+	return buf.String()
+}
+
+func subscribe(
+	client *vci.Client,
+	p *printer,
+	arg string,
+) *vci.Subscription {
+	i := strings.Index(arg, ":")
+	if i < 0 {
+		module := arg
+		return client.SubscribeModule(module, func(name, data string) {
+			p.print(module, name, data)
+		})
+	}
+	module, name := arg[:i], arg[i+1:]
+	return client.Subscribe(module, name, func(data string) {
+		p.print(module, name, data)
+	})
+}
+
+func main() {
+	raw := flag.Bool("raw", false,
+		"print notifications as they are emitted, without validating them")
+	jsonLines := flag.Bool("json", false,
+		"print each notification as a line of JSON")
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	client, err := vci.Dial()
+	exitOnError(err)
+	defer client.Close()
+
+	p := &printer{jsonLines: *jsonLines}
+	for _, arg := range flag.Args() {
+		sub := subscribe(client, p, arg)
+		if *raw {
+			sub.SkipValidation()
+		}
+		err = sub.Run()
+		exitOnError(err)
+		defer sub.Cancel()
+	}
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+	<-sigs
+}
@@ -0,0 +1,235 @@
+// Copyright (c) 2026, AT&T Intellectual Property. All rights reserved.
+//
+// SPDX-License-Identifier: MPL-2.0
+
+package vci
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+)
+
+// A SpanContext identifies a span of work in a distributed trace, as
+// described by the W3C trace-context recommendation. It is carried in
+// the context passed to the Context variants of the Client's methods
+// and to handlers that take a context, and is sent to components in the
+// Traceparent and Tracestate fields of RPCMetadata.
+type SpanContext struct {
+	TraceID    [16]byte
+	SpanID     [8]byte
+	TraceFlags byte
+	TraceState string
+}
+
+// traceFlagSampled marks a trace whose spans are being recorded.
+const traceFlagSampled = 0x01
+
+var errInvalidTraceparent = errors.New("invalid traceparent")
+
+// IsValid reports whether the span context has a trace and a span id.
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != [16]byte{} && sc.SpanID != [8]byte{}
+}
+
+// Traceparent returns the span context in the form of the W3C
+// traceparent header.
+func (sc SpanContext) Traceparent() string {
+	return "00-" + hex.EncodeToString(sc.TraceID[:]) + "-" +
+		hex.EncodeToString(sc.SpanID[:]) + "-" +
+		hex.EncodeToString([]byte{sc.TraceFlags})
+}
+
+// ParseTraceparent returns the span context described by W3C
+// traceparent and tracestate headers.
+func ParseTraceparent(traceparent, tracestate string) (SpanContext, error) {
+	var sc SpanContext
+	fields := strings.Split(traceparent, "-")
+	if len(fields) < 4 || fields[0] == "ff" || len(fields[0]) != 2 ||
+		(fields[0] == "00" && len(fields) != 4) {
+		return sc, errInvalidTraceparent
+	}
+	var flags [1]byte
+	for _, field := range []struct {
+		dst []byte
+		src string
+	}{
+		{sc.TraceID[:], fields[1]},
+		{sc.SpanID[:], fields[2]},
+		{flags[:], fields[3]},
+	} {
+		if hex.DecodedLen(len(field.src)) != len(field.dst) ||
+			strings.ToLower(field.src) != field.src {
+			return SpanContext{}, errInvalidTraceparent
+		}
+		_, err := hex.Decode(field.dst, []byte(field.src))
+		if err != nil {
+			return SpanContext{}, errInvalidTraceparent
+		}
+	}
+	if !sc.IsValid() {
+		return SpanContext{}, errInvalidTraceparent
+	}
+	sc.TraceFlags = flags[0]
+	sc.TraceState = tracestate
+	return sc, nil
+}
+
+type spanContextKey struct{}
+
+// ContextWithSpanContext returns a copy of ctx carrying the span
+// context, so that calls made with it are part of the span's trace.
+func ContextWithSpanContext(
+	ctx context.Context,
+	sc SpanContext,
+) context.Context {
+	return context.WithValue(ctx, spanContextKey{}, sc)
+}
+
+// SpanContextFromContext returns the span context carried by ctx.
+func SpanContextFromContext(ctx context.Context) (SpanContext, bool) {
+	sc, ok := ctx.Value(spanContextKey{}).(SpanContext)
+	return sc, ok && sc.IsValid()
+}
+
+// withTraceContext adds the trace context carried by ctx to metadata
+// that does not already have one.
+func withTraceContext(
+	ctx context.Context,
+	metadata RPCMetadata,
+) RPCMetadata {
+	if metadata.Traceparent != "" {
+		return metadata
+	}
+	if sc, ok := SpanContextFromContext(ctx); ok {
+		metadata.Traceparent = sc.Traceparent()
+		metadata.Tracestate = sc.TraceState
+	}
+	return metadata
+}
+
+// A Span records a call made by a Client or handled by a Component. Its
+// fields follow the OpenTelemetry span model, so that an exporter can
+// pass spans on to an OpenTelemetry SDK or collector.
+type Span struct {
+	// Name is the RPC as module/rpc, or the configuration or state
+	// method as model/method.
+	Name string
+	// Kind is "client" for calls made and "server" for calls handled.
+	Kind string
+	// SpanContext identifies the span; Parent identifies its parent and
+	// is invalid for the root span of a trace.
+	SpanContext SpanContext
+	Parent      SpanContext
+	Start       time.Time
+	End         time.Time
+	// Attributes describe the call using the OpenTelemetry semantic
+	// conventions for RPCs: rpc.system, rpc.service and rpc.method.
+	Attributes map[string]string
+	// Err is the error the call failed with.
+	Err error
+}
+
+// A SpanExporter is passed each span as it ends. It is called from the
+// goroutines making and handling calls so must be safe for concurrent
+// use.
+type SpanExporter interface {
+	ExportSpan(span *Span)
+}
+
+// tracer starts spans for calls and passes them to an exporter.
+type tracer struct {
+	exporter SpanExporter
+}
+
+func newTracer(exporter SpanExporter) *tracer {
+	return &tracer{exporter: exporter}
+}
+
+// start begins a span of the given kind for a call. The span continues
+// the trace of the parent, if it is valid, or starts a new one. The
+// returned context carries the new span.
+func (t *tracer) start(
+	ctx context.Context,
+	kind, service, method string,
+	parent SpanContext,
+) (context.Context, *Span) {
+	span := &Span{
+		Name:   service + "/" + method,
+		Kind:   kind,
+		Parent: parent,
+		Start:  time.Now(),
+		Attributes: map[string]string{
+			"rpc.system":  "vci",
+			"rpc.service": service,
+			"rpc.method":  method,
+		},
+	}
+	if parent.IsValid() {
+		span.SpanContext = parent
+	} else {
+		rand.Read(span.SpanContext.TraceID[:])
+		span.SpanContext.TraceFlags = traceFlagSampled
+	}
+	rand.Read(span.SpanContext.SpanID[:])
+	return ContextWithSpanContext(ctx, span.SpanContext), span
+}
+
+func (t *tracer) end(span *Span, err error) {
+	span.End = time.Now()
+	span.Err = err
+	t.exporter.ExportSpan(span)
+}
+
+// serviceName returns the name of the service an invocation is made on:
+// the module of an RPC, otherwise the model.
+func (inv *Invocation) serviceName() string {
+	if inv.ModuleName != "" {
+		return inv.ModuleName
+	}
+	return inv.ModelName
+}
+
+// interceptor returns an interceptor that records a span of the given
+// kind for each invocation, continuing the trace carried by its
+// metadata. Client spans are sent on to the component in the metadata.
+func (t *tracer) interceptor(kind string) Interceptor {
+	return func(
+		ctx context.Context,
+		inv *Invocation,
+		invoke Invoker,
+	) (string, error) {
+		// An invalid traceparent starts a new trace.
+		parent, _ := ParseTraceparent(inv.Metadata.Traceparent,
+			inv.Metadata.Tracestate)
+		ctx, span := t.start(ctx, kind, inv.serviceName(), inv.Method,
+			parent)
+		if kind == "client" {
+			inv.Metadata.Traceparent = span.SpanContext.Traceparent()
+			inv.Metadata.Tracestate = span.SpanContext.TraceState
+		}
+		out, err := invoke(ctx, inv)
+		t.end(span, err)
+		return out, err
+	}
+}
+
+// traceConfig makes a configuration call in a client span, if the
+// client is tracing, so that the component's span is a child of it.
+func (c *Client) traceConfig(
+	ctx context.Context,
+	modelName, method string,
+	call func(ctx context.Context) error,
+) error {
+	if c.tracer == nil {
+		return call(ctx)
+	}
+	parent, _ := SpanContextFromContext(ctx)
+	ctx, span := c.tracer.start(ctx, "client", modelName, method, parent)
+	err := call(ctx)
+	c.tracer.end(span, err)
+	return err
+}
@@ -0,0 +1,227 @@
+// Copyright (c) 2026, AT&T Intellectual Property. All rights reserved.
+//
+// SPDX-License-Identifier: MPL-2.0
+
+package vci
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+const testTraceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func TestParseTraceparent(t *testing.T) {
+	sc, err := ParseTraceparent(testTraceparent, "vendor=value")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sc.Traceparent() != testTraceparent {
+		t.Fatalf("traceparent %q did not round trip: %q",
+			testTraceparent, sc.Traceparent())
+	}
+	if sc.TraceState != "vendor=value" ||
+		sc.TraceFlags&traceFlagSampled == 0 {
+		t.Fatalf("unexpected span context %+v", sc)
+	}
+
+	for _, traceparent := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-00",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e47-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902bz-01",
+	} {
+		_, err := ParseTraceparent(traceparent, "")
+		if err == nil {
+			t.Errorf("invalid traceparent %q was accepted", traceparent)
+		}
+	}
+
+	// Later versions may add fields.
+	_, err = ParseTraceparent(
+		"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-00", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+type testSpanExporter struct {
+	mu    sync.Mutex
+	spans []*Span
+}
+
+func (e *testSpanExporter) ExportSpan(span *Span) {
+	e.mu.Lock()
+	e.spans = append(e.spans, span)
+	e.mu.Unlock()
+}
+
+// find returns the span with the name and kind.
+func (e *testSpanExporter) find(t *testing.T, name, kind string) *Span {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, span := range e.spans {
+		if span.Name == name && span.Kind == kind {
+			return span
+		}
+	}
+	t.Fatalf("no %s span %s in %d spans", kind, name, len(e.spans))
+	return nil
+}
+
+type testTracedConfig struct {
+	testRunningConfigWithValue
+	mu  sync.Mutex
+	set SpanContext
+}
+
+func (c *testTracedConfig) Set(ctx context.Context, config *testConfig) error {
+	c.mu.Lock()
+	c.set, _ = SpanContextFromContext(ctx)
+	c.mu.Unlock()
+	return c.testRunningConfigWithValue.Set(config)
+}
+
+func (c *testTracedConfig) setSpanContext() SpanContext {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.set
+}
+
+// runTracedComponent runs a component whose Set and RPC handlers report
+// the span context they were called with.
+func runTracedComponent(
+	t *testing.T,
+	model string,
+	opts ...Option,
+) (*testTracedConfig, chan SpanContext) {
+	resetTestBus()
+	config := &testTracedConfig{}
+	rpcCalls := make(chan SpanContext, 1)
+	comp := NewComponentWithOptions("com.vyatta.test.foo",
+		append([]Option{WithTransport(newTestTransport())}, opts...)...)
+	comp.Model(model).
+		Config(config).
+		RPC("foo-v1", map[string]interface{}{
+			"call-me": func(
+				ctx context.Context,
+				in *testConfig,
+			) (*testConfig, error) {
+				sc, _ := SpanContextFromContext(ctx)
+				rpcCalls <- sc
+				return in, nil
+			},
+		})
+	err := comp.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return config, rpcCalls
+}
+
+func TestTracing(t *testing.T) {
+	const model = "com.vyatta.test.foo.v1"
+	exporter := &testSpanExporter{}
+	config, rpcCalls := runTracedComponent(t, model, WithTracing(exporter))
+	client, err := DialWithOptions(WithTracing(exporter))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	parent, err := ParseTraceparent(testTraceparent, "vendor=value")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := ContextWithSpanContext(context.Background(), parent)
+
+	var out testConfig
+	err = client.CallContext(ctx, "foo-v1", "call-me",
+		&testConfig{Value: "foo"}).StoreOutputInto(&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientSpan := exporter.find(t, "foo-v1/call-me", "client")
+	serverSpan := exporter.find(t, "foo-v1/call-me", "server")
+	if clientSpan.Parent != parent {
+		t.Fatalf("client span does not continue the caller's trace: %+v",
+			clientSpan)
+	}
+	if serverSpan.Parent != clientSpan.SpanContext {
+		t.Fatalf("server span is not a child of the client span: %+v",
+			serverSpan)
+	}
+	if sc := <-rpcCalls; sc != serverSpan.SpanContext {
+		t.Fatalf("RPC handler was not given the server span: %+v", sc)
+	}
+	if serverSpan.Attributes["rpc.service"] != "foo-v1" ||
+		serverSpan.Attributes["rpc.method"] != "call-me" {
+		t.Fatalf("unexpected attributes %v", serverSpan.Attributes)
+	}
+
+	err = client.SetConfigForModelContext(ctx, model,
+		&testConfig{Value: "bar"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientSpan = exporter.find(t, model+"/set", "client")
+	serverSpan = exporter.find(t, model+"/set", "server")
+	if serverSpan.Parent != clientSpan.SpanContext ||
+		clientSpan.Parent != parent {
+		t.Fatalf("config spans do not form a trace: %+v %+v",
+			clientSpan, serverSpan)
+	}
+	if sc := config.setSpanContext(); sc != serverSpan.SpanContext {
+		t.Fatalf("Set was not given the server span: %+v", sc)
+	}
+}
+
+func TestTraceContextWithoutTracing(t *testing.T) {
+	const model = "com.vyatta.test.foo.v1"
+	config, rpcCalls := runTracedComponent(t, model)
+	client, err := Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	parent, err := ParseTraceparent(testTraceparent, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := ContextWithSpanContext(context.Background(), parent)
+
+	var out testConfig
+	err = client.CallContext(ctx, "foo-v1", "call-me",
+		&testConfig{Value: "foo"}).StoreOutputInto(&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sc := <-rpcCalls; sc != parent {
+		t.Fatalf("RPC handler was not given the caller's trace: %+v", sc)
+	}
+
+	err = client.SetConfigForModelContext(ctx, model,
+		&testConfig{Value: "bar"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sc := config.setSpanContext(); sc != parent {
+		t.Fatalf("Set was not given the caller's trace: %+v", sc)
+	}
+
+	// Calls without a trace context leave the handler without one.
+	err = client.SetConfigForModel(model, &testConfig{Value: "baz"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sc := config.setSpanContext(); sc.IsValid() {
+		t.Fatalf("Set was given a trace context: %+v", sc)
+	}
+}
@@ -0,0 +1,173 @@
+// Copyright (c) 2026, AT&T Intellectual Property. All rights reserved.
+//
+// SPDX-License-Identifier: MPL-2.0
+
+package vci
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/danos/mgmterror"
+)
+
+// A Transaction changes the configuration of several models so that
+// either all of the changes are applied or none of them are.
+//
+// It uses two phases. First each model is asked to prepare its new
+// configuration, which it must validate and stage without applying.
+// Only if every model prepares successfully is each asked to commit,
+// otherwise the models that prepared are asked to abort. Every model in
+// a transaction must implement the Prepare, Commit and Abort methods
+// on its configuration object.
+//
+// A failure during the commit phase cannot be undone for the models
+// that already committed; the model that failed and those yet to commit
+// are aborted.
+type Transaction struct {
+	client  *Client
+	changes []transactionChange
+	err     error
+}
+
+type transactionChange struct {
+	modelName   string
+	encodedData string
+}
+
+// A TransactionError reports the failure of a transaction, the model
+// and phase at which it failed and any errors from aborting the other
+// models.
+type TransactionError struct {
+	ModelName   string
+	Phase       string
+	Err         error
+	AbortErrors map[string]error
+}
+
+func (e *TransactionError) Error() string {
+	msg := e.Phase + " failed for " + e.ModelName + ": " + e.Err.Error()
+	if len(e.AbortErrors) == 0 {
+		return msg
+	}
+	models := make([]string, 0, len(e.AbortErrors))
+	for model := range e.AbortErrors {
+		models = append(models, model)
+	}
+	sort.Strings(models)
+	aborts := make([]string, 0, len(models))
+	for _, model := range models {
+		aborts = append(aborts, model+": "+e.AbortErrors[model].Error())
+	}
+	return msg + "; abort failed for " + strings.Join(aborts, ", ")
+}
+
+// Unwrap returns the error that caused the transaction to fail.
+func (e *TransactionError) Unwrap() error {
+	return e.Err
+}
+
+// configTransactor is implemented by transports that can change the
+// configuration of a model in two phases.
+type configTransactor interface {
+	// PrepareConfigForModel asks the component to validate and stage
+	// the given configuration as the first phase of a transaction.
+	PrepareConfigForModel(ctx context.Context,
+		modelName string, encodedData string) error
+	// CommitConfigForModel applies the configuration staged by
+	// PrepareConfigForModel.
+	CommitConfigForModel(ctx context.Context, modelName string) error
+	// AbortConfigForModel discards the configuration staged by
+	// PrepareConfigForModel.
+	AbortConfigForModel(ctx context.Context, modelName string) error
+}
+
+// Transaction begins a new transaction. Nothing is sent to the
+// components until the transaction is committed.
+func (c *Client) Transaction() *Transaction {
+	return &Transaction{client: c}
+}
+
+// SetConfigForModel adds the new configuration for the model to the
+// transaction. The object is marshalled using the RFC7951 encoder.
+func (t *Transaction) SetConfigForModel(
+	modelName string,
+	object interface{},
+) *Transaction {
+	if t.err != nil {
+		return t
+	}
+	encodedData, err := t.client.marshalObject(object)
+	if err != nil {
+		t.err = err
+		return t
+	}
+	t.changes = append(t.changes, transactionChange{
+		modelName:   modelName,
+		encodedData: encodedData,
+	})
+	return t
+}
+
+// Commit runs the transaction. If any model fails to prepare or commit
+// a *TransactionError is returned. If the transport does not support
+// transactions an OperationNotSupported error is returned.
+func (t *Transaction) Commit() error {
+	return t.CommitContext(context.Background())
+}
+
+// CommitContext is the same as Commit but gives up when the supplied
+// context expires. The prepared models are still aborted, without the
+// context, so that they do not hold on to staged configuration.
+func (t *Transaction) CommitContext(ctx context.Context) error {
+	if t.err != nil {
+		return t.err
+	}
+	transport, ok := t.client.transport.(configTransactor)
+	if !ok {
+		return mgmterror.NewOperationNotSupportedApplicationError()
+	}
+
+	for i, change := range t.changes {
+		err := transport.PrepareConfigForModel(ctx,
+			change.modelName, change.encodedData)
+		if err != nil {
+			return t.abort(transport, t.changes[:i], &TransactionError{
+				ModelName: change.modelName,
+				Phase:     "prepare",
+				Err:       err,
+			})
+		}
+	}
+
+	for i, change := range t.changes {
+		err := transport.CommitConfigForModel(ctx, change.modelName)
+		if err != nil {
+			return t.abort(transport, t.changes[i:], &TransactionError{
+				ModelName: change.modelName,
+				Phase:     "commit",
+				Err:       err,
+			})
+		}
+	}
+	return nil
+}
+
+func (t *Transaction) abort(
+	transport configTransactor,
+	changes []transactionChange,
+	txnErr *TransactionError,
+) error {
+	for _, change := range changes {
+		err := transport.AbortConfigForModel(
+			context.Background(), change.modelName)
+		if err != nil {
+			if txnErr.AbortErrors == nil {
+				txnErr.AbortErrors = make(map[string]error)
+			}
+			txnErr.AbortErrors[change.modelName] = err
+		}
+	}
+	return txnErr
+}
@@ -0,0 +1,293 @@
+// Copyright (c) 2026, AT&T Intellectual Property. All rights reserved.
+//
+// SPDX-License-Identifier: MPL-2.0
+
+package vci
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/danos/mgmterror"
+)
+
+type testTxnConfig struct {
+	mu      sync.Mutex
+	name    string
+	log     *[]string
+	current testConfig
+	staged  *testConfig
+
+	failPrepare bool
+	failCommit  bool
+}
+
+func (c *testTxnConfig) record(event string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	*c.log = append(*c.log, c.name+":"+event)
+}
+
+func (c *testTxnConfig) Get() *testConfig {
+	return &c.current
+}
+func (c *testTxnConfig) Set(config *testConfig) error {
+	c.current = *config
+	return nil
+}
+func (c *testTxnConfig) Check(config *testConfig) error {
+	return nil
+}
+func (c *testTxnConfig) Prepare(config *testConfig) error {
+	c.record("prepare")
+	if c.failPrepare {
+		return errors.New("prepare failed")
+	}
+	c.staged = config
+	return nil
+}
+func (c *testTxnConfig) Commit() error {
+	c.record("commit")
+	if c.failCommit {
+		return errors.New("commit failed")
+	}
+	c.current = *c.staged
+	c.staged = nil
+	return nil
+}
+func (c *testTxnConfig) Abort() error {
+	c.record("abort")
+	c.staged = nil
+	return nil
+}
+
+type testPartialTxnConfig struct {
+	testRunningConfig
+}
+
+func (c *testPartialTxnConfig) Prepare(config *testConfig) error {
+	return nil
+}
+
+// testUnrelatedTxnConfig has methods named for the transaction phases
+// that are not meant to take part in transactions.
+type testUnrelatedTxnConfig struct {
+	testRunningConfig
+}
+
+func (c *testUnrelatedTxnConfig) Prepare(config *testConfig) error {
+	return nil
+}
+func (c *testUnrelatedTxnConfig) Commit(message string) error {
+	return nil
+}
+func (c *testUnrelatedTxnConfig) Abort() error {
+	return nil
+}
+
+func TestTransaction(t *testing.T) {
+	setup := func(t *testing.T) (*Client, []*testTxnConfig, *[]string) {
+		resetTestBus()
+		var log []string
+		var cfgs []*testTxnConfig
+		comp := NewComponent("net.vyatta.test")
+		for _, name := range []string{"one", "two", "three"} {
+			cfg := &testTxnConfig{name: name, log: &log}
+			cfgs = append(cfgs, cfg)
+			comp.Model("net.vyatta.test." + name).Config(cfg)
+		}
+		err := comp.Run()
+		if err != nil {
+			t.Fatal(err)
+		}
+		client, err := Dial()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return client, cfgs, &log
+	}
+	txn := func(client *Client) *Transaction {
+		return client.Transaction().
+			SetConfigForModel("net.vyatta.test.one",
+				&testConfig{Value: "1"}).
+			SetConfigForModel("net.vyatta.test.two",
+				&testConfig{Value: "2"}).
+			SetConfigForModel("net.vyatta.test.three",
+				&testConfig{Value: "3"})
+	}
+	values := func(cfgs []*testTxnConfig) []string {
+		var out []string
+		for _, cfg := range cfgs {
+			out = append(out, cfg.current.Value)
+		}
+		return out
+	}
+
+	t.Run("success", func(t *testing.T) {
+		client, cfgs, log := setup(t)
+		err := txn(client).Commit()
+		if err != nil {
+			t.Fatal(err)
+		}
+		exp := []string{"1", "2", "3"}
+		if got := values(cfgs); !reflect.DeepEqual(got, exp) {
+			t.Fatalf("expected %v, got %v", exp, got)
+		}
+		expLog := []string{
+			"one:prepare", "two:prepare", "three:prepare",
+			"one:commit", "two:commit", "three:commit",
+		}
+		if !reflect.DeepEqual(*log, expLog) {
+			t.Fatalf("expected %v, got %v", expLog, *log)
+		}
+	})
+	t.Run("prepare-fails", func(t *testing.T) {
+		client, cfgs, log := setup(t)
+		cfgs[2].failPrepare = true
+		err := txn(client).Commit()
+		txnErr, ok := err.(*TransactionError)
+		if !ok {
+			t.Fatalf("expected TransactionError, got %v", err)
+		}
+		if txnErr.ModelName != "net.vyatta.test.three" ||
+			txnErr.Phase != "prepare" {
+			t.Fatalf("unexpected error %v", txnErr)
+		}
+		exp := []string{"", "", ""}
+		if got := values(cfgs); !reflect.DeepEqual(got, exp) {
+			t.Fatalf("expected %v, got %v", exp, got)
+		}
+		expLog := []string{
+			"one:prepare", "two:prepare", "three:prepare",
+			"one:abort", "two:abort",
+		}
+		if !reflect.DeepEqual(*log, expLog) {
+			t.Fatalf("expected %v, got %v", expLog, *log)
+		}
+	})
+	t.Run("commit-fails", func(t *testing.T) {
+		client, cfgs, log := setup(t)
+		cfgs[1].failCommit = true
+		err := txn(client).Commit()
+		txnErr, ok := err.(*TransactionError)
+		if !ok {
+			t.Fatalf("expected TransactionError, got %v", err)
+		}
+		if txnErr.ModelName != "net.vyatta.test.two" ||
+			txnErr.Phase != "commit" {
+			t.Fatalf("unexpected error %v", txnErr)
+		}
+		exp := []string{"1", "", ""}
+		if got := values(cfgs); !reflect.DeepEqual(got, exp) {
+			t.Fatalf("expected %v, got %v", exp, got)
+		}
+		expLog := []string{
+			"one:prepare", "two:prepare", "three:prepare",
+			"one:commit", "two:commit",
+			"two:abort", "three:abort",
+		}
+		if !reflect.DeepEqual(*log, expLog) {
+			t.Fatalf("expected %v, got %v", expLog, *log)
+		}
+	})
+	t.Run("unsupported-model", func(t *testing.T) {
+		client, cfgs, log := setup(t)
+		comp := NewComponent("net.vyatta.test.plain")
+		comp.Model("net.vyatta.test.plain.v1").
+			Config(&testRunningConfig{})
+		err := comp.Run()
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = txn(client).
+			SetConfigForModel("net.vyatta.test.plain.v1",
+				&testConfig{Value: "plain"}).
+			Commit()
+		if err == nil {
+			t.Fatal("expected error did not occur")
+		}
+		exp := []string{"", "", ""}
+		if got := values(cfgs); !reflect.DeepEqual(got, exp) {
+			t.Fatalf("expected %v, got %v", exp, got)
+		}
+		if len(*log) != 6 {
+			t.Fatalf("expected all models aborted, got %v", *log)
+		}
+	})
+	t.Run("unsupported-transport", func(t *testing.T) {
+		_, _, log := setup(t)
+		// Embedding the interface hides the transaction methods.
+		transport := struct{ Transport }{newTestTransport()}
+		client, err := DialWithOptions(WithTransport(transport))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer client.Close()
+		err = txn(client).Commit()
+		if _, ok := err.(*mgmterror.OperationNotSupportedApplicationError); !ok {
+			t.Fatalf("expected operation not supported, got %v", err)
+		}
+		if len(*log) != 0 {
+			t.Fatalf("unexpected calls %v", *log)
+		}
+	})
+	t.Run("marshal-fails", func(t *testing.T) {
+		client, _, log := setup(t)
+		err := client.Transaction().
+			SetConfigForModel("net.vyatta.test.one",
+				make(chan struct{})).
+			Commit()
+		if err == nil {
+			t.Fatal("expected error did not occur")
+		}
+		if len(*log) != 0 {
+			t.Fatalf("unexpected calls %v", *log)
+		}
+	})
+}
+
+func TestTransportObjectConfigTransactionMethods(t *testing.T) {
+	t.Run("all", func(t *testing.T) {
+		var log []string
+		cfg := newConfig(&testTxnConfig{log: &log}, nil)
+		if !cfg.IsValid() {
+			t.Fatal(cfg.err)
+		}
+		for _, name := range []string{"prepare", "commit", "abort"} {
+			if _, ok := cfg.Methods()[name]; !ok {
+				t.Fatalf("%s method was not generated", name)
+			}
+		}
+	})
+	t.Run("none", func(t *testing.T) {
+		cfg := newConfig(&testRunningConfig{}, nil)
+		if !cfg.IsValid() {
+			t.Fatal(cfg.err)
+		}
+		if _, ok := cfg.Methods()["prepare"]; ok {
+			t.Fatal("unexpected prepare method")
+		}
+	})
+	t.Run("partial", func(t *testing.T) {
+		cfg := newConfig(&testPartialTxnConfig{}, nil)
+		if !cfg.IsValid() {
+			t.Fatal(cfg.err)
+		}
+		if _, ok := cfg.Methods()["prepare"]; ok {
+			t.Fatal("unexpected prepare method")
+		}
+	})
+	t.Run("unrelated", func(t *testing.T) {
+		cfg := newConfig(&testUnrelatedTxnConfig{}, nil)
+		if !cfg.IsValid() {
+			t.Fatal(cfg.err)
+		}
+		for _, name := range []string{"prepare", "commit", "abort"} {
+			if _, ok := cfg.Methods()[name]; ok {
+				t.Fatalf("unexpected %s method", name)
+			}
+		}
+	})
+}
@@ -8,31 +8,61 @@
 
 package vci
 
+import "context"
+
 const (
 	yangdName       = "net.vyatta.vci.config.yangd.v1"
 	yangdModuleName = "yangd-v1"
 )
 
-// The transportRPCPromise allows one to retrieve the value of an RPC call that
-// was previously started.
-type transportRPCPromise interface {
-	StoreOutputInto(*string) error
+// The TransportRPCPromise allows one to retrieve the value of an RPC call that
+// was previously started. If the context expires before the result is
+// available the context's error is returned.
+type TransportRPCPromise interface {
+	StoreOutputInto(ctx context.Context, output *string) error
 }
 
-// The transportSubscriber is a mechanism that will deliver a
+// The TransportSubscriber is a mechanism that will deliver a
 // notification to a subscriber.
-type transportSubscriber interface {
+type TransportSubscriber interface {
 	Deliver(encodedData string) error
 }
 
-// The transportObject type represents any object that is to be exposed on
+// A TransportMalformedSubscriber is also told of the notifications that
+// the transport received but could not make sense of. Transports check
+// for it and drop such notifications for other subscribers.
+type TransportMalformedSubscriber interface {
+	TransportSubscriber
+	// DeliverMalformed reports a notification that the transport
+	// received but could not make sense of. The payload is a
+	// representation of what was received, for diagnostics.
+	DeliverMalformed(payload string, err error)
+}
+
+// A TransportModuleSubscriber is subscribed to all of the notifications
+// of a module and so is told the name of each notification delivered
+// to it.
+type TransportModuleSubscriber interface {
+	TransportSubscriber
+	DeliverNotification(notificationName, encodedData string) error
+}
+
+// A TransportStateSubscriber is subscribed to the state changes of a
+// model and so is told the path, an RFC7951 instance-identifier, of each
+// change delivered to it along with the state found there.
+type TransportStateSubscriber interface {
+	TransportSubscriber
+	DeliverStateChange(path, encodedState string) error
+}
+
+// The TransportObject type represents any object that is to be exposed on
 // the transport.
-type transportObject interface {
+type TransportObject interface {
 	// Methods provides a set of methods that will be exposed on the transport.
 	// Each method may only receieve a string and return an error or a pair of
 	// string and error.
 	Methods() map[string]interface{}
-	// IsValid informs the transporter implementation if the object is valid or
+	// IsValid informs the Transport implementation if the object is valid or
 	// if there was a problem when building it. If there was a problem an apporpriate
 	// error should be returned.
 	IsValid() bool
@@ -42,16 +72,55 @@ type transportObject interface {
 	// Type represnets the object type. This does not map one to one to a
 	// go type. It is useful if the transport needs to expose objects of a
 	// particular type differently than other objects. The current types are
-	// "state", "config", and "rpc".
+	// "state", "config", "rpc", and "introspection".
 	Type() string
 }
 
-// The transporter interface represents an interface that can make appropriate
+// A TransportCallerObject is a TransportObject whose configuration and
+// state methods need to know who is calling them, for instance to
+// enforce an authorization policy. A transport that can establish the
+// credentials of a caller should expose the methods returned by
+// CallerMethods instead of those returned by Methods. Each takes the
+// RPCMetadata describing the caller, encoded as for RPCs, as an extra
+// first argument. RPC methods are the same as those returned by Methods
+// as they already receive the caller's metadata.
+type TransportCallerObject interface {
+	TransportObject
+	CallerMethods() map[string]interface{}
+}
+
+// A TransportPeer is a connection to the transport that owns one or more
+// names, as reported by transports that can list their peers. A
+// component's connection owns the component's name and its models'.
+type TransportPeer struct {
+	Names   []string
+	Objects []TransportPeerObject
+}
+
+// A TransportPeerObject is an object exported by a TransportPeer, with
+// the Name and Type it was exported with. The Methods of "rpc" objects
+// are the YANG names of their RPCs.
+type TransportPeerObject struct {
+	Name    string
+	Type    string
+	Methods []string
+}
+
+// The Transport interface represents an interface that can make appropriate
 // calls on the underlying bus. The semantics for this interface are enforced
 // by the testTransportSemantics unit tests. Any implementation should be
 // validated against this test suite to ensure semantic compliance with the
-// interface.
-type transporter interface {
+// interface. A Transport may be supplied to DialWithOptions and
+// NewComponentWithOptions with the WithTransport option.
+//
+// A component shares one Transport between all of its models and its
+// Client, so Dial is called more than once and must do nothing if the
+// transport is already connected.
+//
+// Methods that take a context must abandon the underlying call and return
+// the context's error (context.DeadlineExceeded or context.Canceled) if the
+// context expires before the call completes.
+type Transport interface {
 	// Dial connects to the underlying transport. It returns errors if
 	// the transport is unavailable or if the connection fails for any
 	// other reason.
@@ -62,59 +131,66 @@ type transporter interface {
 	RequestIdentity(id string) error
 	// Call calls an RPC on the transport. All information transmitted
 	// on the transport is RFC7951 encoded strings.
-	Call(moduleName, rpcName, meta, input string) (transportRPCPromise, error)
+	Call(ctx context.Context,
+		moduleName, rpcName, meta, input string) (TransportRPCPromise, error)
 	// Subscribe adds a subscirber for a given notification, the
 	// transport must be able to support multiple subscribers for a
 	// single notification name.
 	Subscribe(moduleName, notificationName string,
-		subscriber transportSubscriber) error
+		subscriber TransportSubscriber) error
 	// Unsubscribe removes a subscription to a notification. The subscription
 	// is matched by the notification name and the subscriber.
 	Unsubscribe(moduleName, notificationName string,
-		subscriber transportSubscriber) error
+		subscriber TransportSubscriber) error
 	// Emit transmits a notification on the transport. An emitted notification
 	// must be received by all subscribers, including subscribers on the
 	// current connection.
-	Emit(moduleName, notificationName, encodedData string) error
+	Emit(ctx context.Context,
+		moduleName, notificationName, encodedData string) error
 	// CheckConfigForModel will check the given config with the component
-	CheckConfigForModel(modelName string, encodedData string) error
+	CheckConfigForModel(ctx context.Context,
+		modelName string, encodedData string) error
 	// SetConfigForModel will write the given configuration to the component.
-	SetConfigForModel(modelName string, encodedData string) error
+	SetConfigForModel(ctx context.Context,
+		modelName string, encodedData string) error
 	// StoreConfigByModelInto will cause the configuration for a given
 	// model to be queried and stored into the passed in pointer.
-	StoreConfigByModelInto(modelName string, encodedData *string) error
+	StoreConfigByModelInto(ctx context.Context,
+		modelName string, encodedData *string) error
 	// StoreConfigByModelInto will cause the operational data for a given
 	// model to be queried and stored into the passed in pointer.
-	StoreStateByModelInto(modelName string, encodedData *string) error
-	// Export will expose the transportObject on the transport so that
+	StoreStateByModelInto(ctx context.Context,
+		modelName string, encodedData *string) error
+	// Export will expose the TransportObject on the transport so that
 	// it may be accessed by Clients.
-	Export(object transportObject) error
+	Export(object TransportObject) error
 	// Close terminates the connection to the transport. After a close, no
 	// notifications may be received nor can any calls be made.
 	Close() error
 }
 
 // Allow default transport to be mocked out in tests
-var defaultTransportConstructor func() transporter
+var defaultTransportConstructor func() Transport
 
-func setDefaultTransportConstructor(constructor func() transporter) {
+func setDefaultTransportConstructor(constructor func() Transport) {
 	defaultTransportConstructor = constructor
 }
 
 // defaultTransport constructs a connection to the default transport type
 // for VCI Clients.
-func defaultTransport() transporter {
+func defaultTransport() Transport {
 	return defaultTransportConstructor()
 }
 
 // defaultMarshaller constructs a marshaller that uses the default encoding.
-func defaultMarshaller() marshaller {
+func defaultMarshaller() Marshaller {
 	return newRFC7951Marshaller()
 }
 
 // The Marshaller type is used to convert go objects to a string and from a
-// string into an object.
-type marshaller interface {
+// string into an object. The default Marshaller uses the RFC7951 encoding;
+// another may be supplied with the WithMarshaller option.
+type Marshaller interface {
 	Marshal(object interface{}) (string, error)
 	Unmarshal(data string, object interface{}) error
 	IsEmptyObject(data string) bool
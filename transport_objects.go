@@ -8,8 +8,13 @@
 package vci
 
 import (
+	"context"
 	"errors"
+	"log"
 	"reflect"
+	"runtime/debug"
+
+	"github.com/danos/mgmterror"
 )
 
 type state struct {
@@ -39,11 +44,22 @@ func (o *state) generateMethods(object interface{}) {
 		o.err = err
 		return
 	}
-	o.methods[genYangName(name)] = func() (string, error) {
-		out := method.Call(
-			[]reflect.Value{})[0]
-		return o.encodeOutput(out.Interface())
+	o.wrapGetMethod(o.methods, method)
+}
+
+// get returns the encoded state at the path. The path must be empty
+// unless the state object's Get accepts a path.
+func (o *state) get(path string) (string, error) {
+	if !o.IsValid() {
+		return "", o
+	}
+	if getPath, ok := o.methods["get-path"].(func(string) (string, error)); ok {
+		return getPath(path)
+	}
+	if path != "" {
+		return "", errors.New("state Get does not accept a path")
 	}
+	return o.methods["get"].(func() (string, error))()
 }
 
 func (o *state) Methods() map[string]interface{} {
@@ -70,7 +86,8 @@ type config struct {
 	wrapperObject
 	err error
 
-	methods map[string]interface{}
+	methods    map[string]interface{}
+	ctxMethods map[string]interface{}
 }
 
 func newConfig(object interface{}, client *Client) *config {
@@ -83,7 +100,8 @@ func newConfig(object interface{}, client *Client) *config {
 		wrapperObject: wrapperObject{
 			client: client,
 		},
-		methods: make(map[string]interface{}),
+		methods:    make(map[string]interface{}),
+		ctxMethods: make(map[string]interface{}),
 	}
 	cfg.generateMethods(object)
 	return cfg
@@ -93,6 +111,10 @@ func (o *config) Methods() map[string]interface{} {
 	return o.methods
 }
 
+func (o *config) contextMethods() map[string]interface{} {
+	return o.ctxMethods
+}
+
 func (o *config) IsValid() bool {
 	return o != nil && o.err == nil
 }
@@ -114,6 +136,7 @@ func (o *config) generateMethods(object interface{}) {
 		o.generateGetMethod,
 		o.generateSetMethod,
 		o.generateCheckMethod,
+		o.generateTransactionMethods,
 	}
 	for _, fn := range fns {
 		err := fn(object)
@@ -137,10 +160,7 @@ func (o *config) generateGetMethod(object interface{}) error {
 	if err != nil {
 		return err
 	}
-	o.methods[genYangName(name)] = func() (string, error) {
-		out := method.Call([]reflect.Value{})[0]
-		return o.encodeOutput(out.Interface())
-	}
+	o.wrapGetMethod(o.methods, method)
 	return nil
 }
 
@@ -152,20 +172,7 @@ func (o *config) generateSetMethod(object interface{}) error {
 	if err != nil {
 		return err
 	}
-	o.methods[genYangName(name)] = func(encodedData string) error {
-		methodType := method.Type()
-		methodInputType := methodType.In(0)
-
-		ins := make([]reflect.Value, 0, 1)
-		ins, errs := o.decodeInput(ins, methodInputType, encodedData)
-		if errs != nil {
-			return errs
-		}
-
-		outs := method.Call(ins)
-
-		return o.encodeError(outs[0].Interface())
-	}
+	o.wrapInputMethod(name, method)
 	return nil
 }
 
@@ -177,9 +184,65 @@ func (o *config) generateCheckMethod(object interface{}) error {
 	if err != nil {
 		return err
 	}
+	o.wrapInputMethod(name, method)
+	return nil
+}
+
+// wrapInputMethod adds the method for a handler, such as Set or Check,
+// that is given the encoded configuration and returns an error. Handlers
+// that take a context also have a context form of the method.
+func (o *config) wrapInputMethod(name string, method reflect.Value) {
+	withContext := takesContext(method.Type())
+	call := func(ctx context.Context, encodedData string) error {
+		ins := make([]reflect.Value, 0, 2)
+		if withContext {
+			ins = append(ins, reflect.ValueOf(ctx))
+		}
+		methodInputType := method.Type().In(len(ins))
+		ins, errs := o.decodeInput(ins, methodInputType, encodedData)
+		if errs != nil {
+			return errs
+		}
+
+		outs, err := o.callHandler(name, method, ins)
+		if err != nil {
+			return err
+		}
+
+		return o.encodeError(outs[0].Interface())
+	}
 	o.methods[genYangName(name)] = func(encodedData string) error {
-		methodType := method.Type()
-		methodInputType := methodType.In(0)
+		return call(context.Background(), encodedData)
+	}
+	if withContext {
+		o.ctxMethods[genYangName(name)] = call
+	}
+}
+
+// generateTransactionMethods wraps the optional Prepare, Commit and Abort
+// methods that allow the object to take part in a transaction. The object
+// only takes part if it implements all three with the expected
+// signatures, so that an object with an unrelated method of one of these
+// names is unaffected.
+func (o *config) generateTransactionMethods(object interface{}) error {
+	objectVal := reflect.ValueOf(object)
+	validators := map[string]func(reflect.Value) error{
+		"Prepare": o.validatePrepare,
+		"Commit":  o.validateFinish("Commit"),
+		"Abort":   o.validateFinish("Abort"),
+	}
+	methods := make(map[string]reflect.Value, len(validators))
+	for name, validate := range validators {
+		var method reflect.Value
+		if o.setMethod(&method, objectVal, name, validate) != nil {
+			return nil
+		}
+		methods[name] = method
+	}
+
+	prepare := methods["Prepare"]
+	o.methods[genYangName("Prepare")] = func(encodedData string) error {
+		methodInputType := prepare.Type().In(0)
 
 		ins := make([]reflect.Value, 0, 1)
 		ins, errs := o.decodeInput(ins, methodInputType, encodedData)
@@ -187,10 +250,25 @@ func (o *config) generateCheckMethod(object interface{}) error {
 			return errs
 		}
 
-		outs := method.Call(ins)
+		outs, err := o.callHandler("Prepare", prepare, ins)
+		if err != nil {
+			return err
+		}
 
 		return o.encodeError(outs[0].Interface())
 	}
+
+	for _, name := range []string{"Commit", "Abort"} {
+		method := methods[name]
+		name := name
+		o.methods[genYangName(name)] = func() error {
+			outs, err := o.callHandler(name, method, nil)
+			if err != nil {
+				return err
+			}
+			return o.encodeError(outs[0].Interface())
+		}
+	}
 	return nil
 }
 
@@ -199,7 +277,8 @@ type rpcObject struct {
 	err  error
 	name string
 
-	methods map[string]interface{}
+	methods    map[string]interface{}
+	ctxMethods map[string]interface{}
 }
 
 func newRPC(moduleName string, object interface{}, client *Client) *rpcObject {
@@ -213,7 +292,8 @@ func newRPC(moduleName string, object interface{}, client *Client) *rpcObject {
 		wrapperObject: wrapperObject{
 			client: client,
 		},
-		methods: make(map[string]interface{}),
+		methods:    make(map[string]interface{}),
+		ctxMethods: make(map[string]interface{}),
 	}
 	rpc.generateMethods(object)
 	return rpc
@@ -223,6 +303,10 @@ func (o *rpcObject) Methods() map[string]interface{} {
 	return o.methods
 }
 
+func (o *rpcObject) contextMethods() map[string]interface{} {
+	return o.ctxMethods
+}
+
 func (o *rpcObject) IsValid() bool {
 	return o != nil && o.err == nil
 }
@@ -255,7 +339,7 @@ func (o *rpcObject) generateMethodsFromMap(in map[string]interface{}) {
 		if methodValue.Kind() != reflect.Func {
 			continue
 		}
-		if methodType.NumIn() < 1 || methodType.NumIn() > 2 {
+		if numIn := numInAfterContext(methodType); numIn < 1 || numIn > 2 {
 			continue
 		}
 		if methodType.NumOut() != 2 {
@@ -264,8 +348,7 @@ func (o *rpcObject) generateMethodsFromMap(in map[string]interface{}) {
 		if methodType.Out(1) != reflectErrorType {
 			continue
 		}
-		o.methods[name] = o.wrapRPCMethod(o.Name(), name,
-			methodValue)
+		o.addRPCMethod(name, methodValue)
 	}
 }
 
@@ -277,8 +360,9 @@ func (o *rpcObject) generateMethodsFromObject(object interface{}) {
 		methodExpr := typ.Method(i)
 		//methods must be of the form func(_) (_, _) we don't
 		//care about argument types because they will be
-		//encoded/decode by the wrapper function.
-		if methodType.NumIn() < 1 || methodType.NumIn() > 2 {
+		//encoded/decode by the wrapper function. A context
+		//may be taken before the other arguments.
+		if numIn := numInAfterContext(methodType); numIn < 1 || numIn > 2 {
 			o.err = errors.New(
 				"All RPCs must have either one or two arguments")
 			return
@@ -293,26 +377,44 @@ func (o *rpcObject) generateMethodsFromObject(object interface{}) {
 				"All RPCs must have the error type as the second return")
 		}
 		name := genYangName(methodExpr.Name)
-		o.methods[name] = o.wrapRPCMethod(o.Name(), name,
-			value.Method(i))
+		o.addRPCMethod(name, value.Method(i))
+	}
+}
+
+// addRPCMethod adds the method for an RPC, and its context form if the
+// RPC takes a context.
+func (o *rpcObject) addRPCMethod(name string, method reflect.Value) {
+	call := o.wrapRPCMethod(o.Name(), name, method)
+	o.methods[name] = func(metadata, encodedData string) (string, error) {
+		return call(context.Background(), metadata, encodedData)
+	}
+	if takesContext(method.Type()) {
+		o.ctxMethods[name] = call
 	}
 }
 
 func (o *rpcObject) wrapRPCMethod(
 	moduleName, name string,
 	method reflect.Value,
-) func(string, string) (string, error) {
-	wrapper := func(metadata, encodedData string) (string, error) {
+) func(context.Context, string, string) (string, error) {
+	wrapper := func(
+		ctx context.Context,
+		metadata, encodedData string,
+	) (string, error) {
 		methodType := method.Type()
-		numIn := methodType.NumIn()
+		numIn := numInAfterContext(methodType)
+		var first int
+		if takesContext(methodType) {
+			first = 1
+		}
 
 		var metaType, methodInputType reflect.Type
 		switch numIn {
 		case 1:
-			methodInputType = methodType.In(0)
+			methodInputType = methodType.In(first)
 		case 2:
-			metaType = methodType.In(0)
-			methodInputType = methodType.In(1)
+			metaType = methodType.In(first)
+			methodInputType = methodType.In(first + 1)
 		}
 
 		ok, err := o.validateRPCInput(
@@ -321,19 +423,20 @@ func (o *rpcObject) wrapRPCMethod(
 			return "", err
 		}
 
-		var ins []reflect.Value
+		ins := make([]reflect.Value, 0, first+numIn)
+		if first == 1 {
+			ins = append(ins, reflect.ValueOf(ctx))
+		}
 		switch numIn {
 		case 1:
 			var errs error
-			ins = make([]reflect.Value, 0, 1)
 			ins, errs = o.decodeInput(ins, methodInputType, encodedData)
 			if errs != nil {
 				return "", errs
 			}
 		case 2:
 			var errs error
-			ins = make([]reflect.Value, 0, 2)
-			ins, errs = o.decodeInput(ins, metaType, metadata)
+			ins, errs = o.decodeMetadataInput(ins, metaType, metadata)
 			if errs != nil {
 				return "", errs
 			}
@@ -343,7 +446,10 @@ func (o *rpcObject) wrapRPCMethod(
 			}
 		}
 
-		outs := method.Call(ins)
+		outs, err := o.callHandler(moduleName+":"+name, method, ins)
+		if err != nil {
+			return "", err
+		}
 		val := outs[0]
 		errv := outs[1]
 
@@ -402,11 +508,68 @@ func (o *rpcObject) validateRPCInputByModuleName(
 // wrapperObject is used to scope common functions used by all the wrappers
 type wrapperObject struct {
 	client *Client
+	// onPanic is told of each panic recovered from a handler.
+	onPanic func()
+	// limit, if not nil, bounds the number of calls to the handlers
+	// that run at once to its capacity.
+	limit chan struct{}
+	// calls, if not nil, tracks the calls to the handlers for the
+	// component so that it can shut down cleanly.
+	calls *handlerCalls
+	// stats, if not nil, counts the calls to the handlers.
+	stats *handlerStats
+}
+
+// callHandler calls a handler method, once the object's limit allows,
+// recovering from any panic in it. The panic is logged with its stack
+// and the caller is sent an operation-failed error, so that one bad call
+// does not take down the whole component. Calls made while the component
+// is shutting down are refused.
+func (o *wrapperObject) callHandler(
+	name string,
+	method reflect.Value,
+	ins []reflect.Value,
+) (outs []reflect.Value, err error) {
+	if o.calls != nil {
+		err := o.calls.begin()
+		if err != nil {
+			return nil, err
+		}
+		defer o.calls.end()
+	}
+	if o.limit != nil {
+		o.limit <- struct{}{}
+		defer func() { <-o.limit }()
+	}
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+		log.Printf("vci: %s handler panicked: %v\n%s", name, r,
+			debug.Stack())
+		operr := mgmterror.NewOperationFailedApplicationError()
+		operr.Message = name + " failed unexpectedly"
+		err = operr
+		if o.stats != nil {
+			o.stats.called(nil)
+		}
+		if o.onPanic != nil {
+			o.onPanic()
+		}
+	}()
+	outs = method.Call(ins)
+	if o.stats != nil {
+		o.stats.called(outs)
+	}
+	return outs, nil
 }
 
+// validateSet checks the Set method of a config object. Set may take a
+// context before the configuration.
 func (o *wrapperObject) validateSet(method reflect.Value) error {
 	methodType := method.Type()
-	if methodType.NumIn() != 1 {
+	if numInAfterContext(methodType) != 1 {
 		return errors.New(
 			"Set must have one and only one argument")
 	}
@@ -417,22 +580,76 @@ func (o *wrapperObject) validateSet(method reflect.Value) error {
 	return nil
 }
 
+// validateGet checks the Get method of a config or state object. Get
+// either takes no arguments and returns the whole tree, or takes the
+// path of the subtree to return and may also return an error.
 func (o *wrapperObject) validateGet(method reflect.Value) error {
 	methodType := method.Type()
-	if methodType.NumIn() != 0 {
-		return errors.New(
-			"Get must have no arguments")
-	}
-	if methodType.NumOut() != 1 {
+	switch methodType.NumIn() {
+	case 0:
+		if methodType.NumOut() != 1 {
+			return errors.New(
+				"Get must have one and only one return value")
+		}
+	case 1:
+		if methodType.In(0) != reflectStringType {
+			return errors.New(
+				"Get must take the path as a string")
+		}
+		switch {
+		case methodType.NumOut() == 1:
+		case methodType.NumOut() == 2 &&
+			methodType.Out(1) == reflectErrorType:
+		default:
+			return errors.New(
+				"Get must return a value and optionally an error")
+		}
+	default:
 		return errors.New(
-			"Get must have one and only one return value")
+			"Get must have no arguments or only a path")
 	}
 	return nil
 }
 
+// wrapGetMethod adds the get method, and the get-path method if Get
+// takes a path, to methods. The get method of an object whose Get takes
+// a path asks for the whole tree with an empty path.
+func (o *wrapperObject) wrapGetMethod(
+	methods map[string]interface{},
+	method reflect.Value,
+) {
+	if method.Type().NumIn() == 0 {
+		methods[genYangName("Get")] = func() (string, error) {
+			outs, err := o.callHandler("Get", method, nil)
+			if err != nil {
+				return "", err
+			}
+			return o.encodeOutput(outs[0].Interface())
+		}
+		return
+	}
+	getPath := func(path string) (string, error) {
+		outs, err := o.callHandler("Get", method,
+			[]reflect.Value{reflect.ValueOf(path)})
+		if err != nil {
+			return "", err
+		}
+		if len(outs) == 2 && !outs[1].IsNil() {
+			return "", o.encodeError(outs[1].Interface())
+		}
+		return o.encodeOutput(outs[0].Interface())
+	}
+	methods[genYangName("Get")] = func() (string, error) {
+		return getPath("")
+	}
+	methods[genYangName("GetPath")] = getPath
+}
+
+// validateCheck checks the Check method of a config object. Check may
+// take a context before the configuration.
 func (o *wrapperObject) validateCheck(method reflect.Value) error {
 	methodType := method.Type()
-	if methodType.NumIn() != 1 {
+	if numInAfterContext(methodType) != 1 {
 		return errors.New(
 			"Check must have one and only one argument")
 	}
@@ -443,6 +660,54 @@ func (o *wrapperObject) validateCheck(method reflect.Value) error {
 	return nil
 }
 
+// takesContext reports whether a handler takes a context as its first
+// argument, so that it can be passed the context of the call.
+func takesContext(methodType reflect.Type) bool {
+	return methodType.NumIn() > 0 && methodType.In(0) == reflectContextType
+}
+
+// numInAfterContext returns the number of arguments a handler takes, not
+// counting a context.
+func numInAfterContext(methodType reflect.Type) int {
+	if takesContext(methodType) {
+		return methodType.NumIn() - 1
+	}
+	return methodType.NumIn()
+}
+
+func (o *wrapperObject) validatePrepare(method reflect.Value) error {
+	methodType := method.Type()
+	if methodType.NumIn() != 1 {
+		return errors.New(
+			"Prepare must have one and only one argument")
+	}
+	if methodType.NumOut() != 1 || methodType.Out(0) != reflectErrorType {
+		return errors.New(
+			"Prepare must return only an error")
+	}
+	return nil
+}
+
+// validateFinish checks the methods that end a transaction, Commit
+// and Abort.
+func (o *wrapperObject) validateFinish(
+	name string,
+) func(reflect.Value) error {
+	return func(method reflect.Value) error {
+		methodType := method.Type()
+		if methodType.NumIn() != 0 {
+			return errors.New(
+				name + " must have no arguments")
+		}
+		if methodType.NumOut() != 1 ||
+			methodType.Out(0) != reflectErrorType {
+			return errors.New(
+				name + " must return only an error")
+		}
+		return nil
+	}
+}
+
 func (o *wrapperObject) validateMethod(
 	objectVal reflect.Value,
 	name string,
@@ -475,7 +740,22 @@ func (o *wrapperObject) decodeInput(
 	typ reflect.Type,
 	input string,
 ) ([]reflect.Value, error) {
-	newInput, err := decodeValue(typ, input)
+	newInput, err := decodeValue(o.marshaller(), typ, input)
+	if err != nil {
+		return nil, err
+	}
+	cur = append(cur, reflect.ValueOf(newInput))
+	return cur, nil
+}
+
+// decodeMetadataInput decodes RPC metadata, which unlike other input is
+// always RFC7951 encoded.
+func (o *wrapperObject) decodeMetadataInput(
+	cur []reflect.Value,
+	typ reflect.Type,
+	input string,
+) ([]reflect.Value, error) {
+	newInput, err := decodeValue(defaultMarshaller(), typ, input)
 	if err != nil {
 		return nil, err
 	}
@@ -500,6 +780,14 @@ func (o *wrapperObject) encodeOutput(output interface{}) (string, error) {
 		return v, nil
 	}
 
-	marshaller := defaultMarshaller()
-	return marshaller.Marshal(output)
+	return o.marshaller().Marshal(output)
+}
+
+// marshaller returns the marshaller of the client the object was created
+// with, so that objects are encoded as the component was configured.
+func (o *wrapperObject) marshaller() Marshaller {
+	if o.client == nil {
+		return defaultMarshaller()
+	}
+	return o.client.marshaller
 }
@@ -8,6 +8,7 @@
 package vci
 
 import (
+	"context"
 	"errors"
 	"reflect"
 	"strings"
@@ -166,7 +167,7 @@ type testRunningConfigGetInvalid struct {
 	testRunningConfig
 }
 
-func (run *testRunningConfigGetInvalid) Get(string) {
+func (run *testRunningConfigGetInvalid) Get(int) {
 }
 
 func TestTransportObjectRunningConfigGetInvalid(t *testing.T) {
@@ -175,7 +176,7 @@ func TestTransportObjectRunningConfigGetInvalid(t *testing.T) {
 		t.Error("expected failure creating object got none")
 	}
 
-	if config.Error() != "Get must have no arguments" {
+	if config.Error() != "Get must take the path as a string" {
 		t.Errorf("unexpected error %s", config.Error())
 	}
 }
@@ -582,7 +583,7 @@ type testStateGetInvalid struct {
 	testState
 }
 
-func (run *testStateGetInvalid) Get(string) {
+func (run *testStateGetInvalid) Get(int) {
 }
 
 func TestTransportObjectStateGetInvalid(t *testing.T) {
@@ -590,11 +591,45 @@ func TestTransportObjectStateGetInvalid(t *testing.T) {
 	if obj.IsValid() {
 		t.Error("expected failure creating object got none")
 	}
-	if obj.Error() != "Get must have no arguments" {
+	if obj.Error() != "Get must take the path as a string" {
 		t.Errorf("unexpected error %s", obj.Error())
 	}
 }
 
+func TestTransportObjectStateGetPath(t *testing.T) {
+	const path = "/foo-v1:routes/route[prefix='10.0.0.0/8']"
+	obj := newState(&testPathState{routes: map[string]*testState{
+		path: {Value: "foo"},
+	}}, newClient())
+	if !obj.IsValid() {
+		t.Fatal(obj.Error())
+	}
+	getPath, ok := obj.Methods()["get-path"].(func(string) (string, error))
+	if !ok {
+		t.Fatal("get-path method was not generated")
+	}
+	out, err := getPath(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := `{"value":"foo"}`; out != exp {
+		t.Fatalf("expected %q, got %q", exp, out)
+	}
+	_, err = getPath("/foo-v1:bar")
+	if err == nil {
+		t.Fatal("expected error did not occur")
+	}
+
+	get := obj.Methods()["get"].(func() (string, error))
+	out, err = get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, `"value":"foo"`) {
+		t.Fatalf("unexpected whole tree %q", out)
+	}
+}
+
 type testStateGetInvalidReturn struct {
 	testState
 }
@@ -888,7 +923,10 @@ func (t *testValidateRPCs) ValidateMe(in *testConfig) (*testConfig, error) {
 
 type testTORPCPromise string
 
-func (t testTORPCPromise) StoreOutputInto(out *string) error {
+func (t testTORPCPromise) StoreOutputInto(
+	ctx context.Context,
+	out *string,
+) error {
 	*out = string(t)
 	return nil
 }
@@ -896,21 +934,22 @@ func (t testTORPCPromise) StoreOutputInto(out *string) error {
 //testYangdRpc is good enough for testing these functions but
 //a better mock bus is needed for more involved tests.
 type testYangdRPC struct {
-	transporter
+	Transport
 	validateInputJSON string
 	desiredResult     bool
 }
 
 func newTestYangdRPC(desiredResult bool) *testYangdRPC {
 	return &testYangdRPC{
-		transporter:   nil,
+		Transport:   nil,
 		desiredResult: desiredResult,
 	}
 }
 
 func (yr *testYangdRPC) Call(
+	ctx context.Context,
 	moduleName, rpcName, meta, rpcReq string,
-) (transportRPCPromise, error) {
+) (TransportRPCPromise, error) {
 	yr.validateInputJSON = rpcReq
 
 	switch yr.desiredResult {
@@ -992,7 +1031,7 @@ func testGetRPCMethodsCustomTransport(
 	t *testing.T,
 	moduleName string,
 	object interface{},
-	transport transporter,
+	transport Transport,
 ) map[string]interface{} {
 
 	client := newClient().withTransport(transport)
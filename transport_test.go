@@ -7,8 +7,11 @@
 package vci
 
 import (
+	"context"
 	"errors"
 	"reflect"
+	"sort"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -17,7 +20,10 @@ import (
 	"github.com/danos/vci/internal/queue"
 )
 
-var tBus *testBus
+var (
+	tBus   *testBus
+	tYangd *testYangService
+)
 
 const (
 	testYangServiceName   = "net.vyatta.vci.config.yangd"
@@ -29,10 +35,11 @@ const emptyMetadata = "{}"
 
 func initTestBus() {
 	tBus = newTestBus()
-	setDefaultTransportConstructor(func() transporter {
+	setDefaultTransportConstructor(func() Transport {
 		return newTestTransport()
 	})
 	ys := newTestYangService()
+	tYangd = ys
 	comp := NewComponent(testYangServiceName)
 	comp.Model(testYangServiceModel).
 		RPC(testYangServiceModule, ys)
@@ -58,14 +65,14 @@ type testBus struct {
 	failDial        bool
 	connections     []*testConn
 	connectionsByID map[string]*testConn
-	subscriptions   map[string][]transportSubscriber
+	subscriptions   map[string][]TransportSubscriber
 }
 
 func newTestBus() *testBus {
 	return &testBus{
 		connections:     make([]*testConn, 0),
 		connectionsByID: make(map[string]*testConn),
-		subscriptions:   make(map[string][]transportSubscriber),
+		subscriptions:   make(map[string][]TransportSubscriber),
 	}
 }
 
@@ -125,7 +132,32 @@ func (b *testBus) requestID(id string, c *testConn) error {
 	return nil
 }
 
-func (b *testBus) Subscribe(notificationName string, s transportSubscriber) {
+func (b *testBus) peers() []TransportPeer {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	names := make(map[*testConn][]string)
+	for id, c := range b.connectionsByID {
+		names[c] = append(names[c], id)
+	}
+	peers := make([]TransportPeer, 0, len(names))
+	for c, ids := range names {
+		sort.Strings(ids)
+		peer := TransportPeer{Names: ids}
+		for name, obj := range c.objects {
+			peerObj := TransportPeerObject{Name: name, Type: obj.typ}
+			if obj.typ == "rpc" {
+				for method := range obj.methods {
+					peerObj.Methods = append(peerObj.Methods, method)
+				}
+			}
+			peer.Objects = append(peer.Objects, peerObj)
+		}
+		peers = append(peers, peer)
+	}
+	return peers
+}
+
+func (b *testBus) Subscribe(notificationName string, s TransportSubscriber) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 	subs := b.subscriptions[notificationName]
@@ -138,11 +170,11 @@ func (b *testBus) Subscribe(notificationName string, s transportSubscriber) {
 		append(b.subscriptions[notificationName], s)
 }
 
-func (b *testBus) Unsubscribe(notificationName string, s transportSubscriber) {
+func (b *testBus) Unsubscribe(notificationName string, s TransportSubscriber) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 	subs := b.subscriptions[notificationName]
-	newSubs := make([]transportSubscriber, 0, len(subs))
+	newSubs := make([]TransportSubscriber, 0, len(subs))
 	for _, sub := range subs {
 		if sub == s {
 			continue
@@ -164,12 +196,29 @@ func (b *testBus) UnsubscribeAll(notificationName string) {
 func (b *testBus) Emit(notificationName string, input string) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
-	subs, ok := b.subscriptions[notificationName]
-	if !ok {
+	for _, sub := range b.subscriptions[notificationName] {
+		_ = sub.Deliver(input)
+	}
+	// Subscribers to all of a module's notifications are subscribed
+	// to the module's name followed by the separator alone.
+	i := strings.LastIndex(notificationName, "/")
+	if i < 0 {
 		return
 	}
-	for _, sub := range subs {
-		_ = sub.Deliver(input)
+	for _, sub := range b.subscriptions[notificationName[:i+1]] {
+		if msub, ok := sub.(TransportModuleSubscriber); ok {
+			_ = msub.DeliverNotification(notificationName[i+1:], input)
+		}
+	}
+}
+
+func (b *testBus) EmitStateChange(name, path, encodedState string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, sub := range b.subscriptions[name] {
+		if ssub, ok := sub.(TransportStateSubscriber); ok {
+			_ = ssub.DeliverStateChange(path, encodedState)
+		}
 	}
 }
 
@@ -239,7 +288,7 @@ func (c *testConn) RequestIdentity(id string) error {
 		if err != nil {
 			return err
 		}
-		err = call.StoreOutputInto(&out)
+		err = call.StoreOutputInto(context.Background(), &out)
 		if err != nil {
 			return err
 		}
@@ -247,7 +296,7 @@ func (c *testConn) RequestIdentity(id string) error {
 	return nil
 }
 
-func (c *testConn) Export(object transportObject) error {
+func (c *testConn) Export(object TransportObject) error {
 	err := c.testConnection()
 	if err != nil {
 		return err
@@ -256,14 +305,18 @@ func (c *testConn) Export(object transportObject) error {
 	if ok {
 		return errors.New("requested object already exists")
 	}
-	c.objects[object.Name()] = &testObject{
+	obj := &testObject{
 		methods: object.Methods(),
 		typ:     object.Type(),
 	}
+	if callerObj, ok := object.(TransportCallerObject); ok {
+		obj.callerMethods = callerObj.CallerMethods()
+	}
+	c.objects[object.Name()] = obj
 	return nil
 }
 
-func (c *testConn) Subscribe(notificationName string, sub transportSubscriber) error {
+func (c *testConn) Subscribe(notificationName string, sub TransportSubscriber) error {
 	err := c.testConnection()
 	if err != nil {
 		return err
@@ -272,7 +325,7 @@ func (c *testConn) Subscribe(notificationName string, sub transportSubscriber) e
 	return nil
 }
 
-func (c *testConn) Unsubscribe(notificationName string, sub transportSubscriber) error {
+func (c *testConn) Unsubscribe(notificationName string, sub TransportSubscriber) error {
 	err := c.testConnection()
 	if err != nil {
 		return err
@@ -299,14 +352,24 @@ func (c *testConn) Emit(notificationName string, input string) error {
 	return nil
 }
 
+func (c *testConn) EmitStateChange(name, path, encodedState string) error {
+	err := c.testConnection()
+	if err != nil {
+		return err
+	}
+	c.bus.EmitStateChange(name, path, encodedState)
+	return nil
+}
+
 func (c *testConn) Close() error {
 	c.bus.removeConnection(c)
 	return nil
 }
 
 type testObject struct {
-	typ     string
-	methods map[string]interface{}
+	typ           string
+	methods       map[string]interface{}
+	callerMethods map[string]interface{}
 }
 
 func (o *testObject) Type() string {
@@ -339,9 +402,10 @@ func (o *testObject) Call(name string, meta, encodedData string) (*testRPCPromis
 	case 0:
 		return &testRPCPromise{}, nil
 	case 1:
-		if mVal.Type().Out(0) == reflect.TypeOf(error(nil)) {
+		if mVal.Type().Out(0) == reflectErrorType {
+			err, _ := outVals[0].Interface().(error)
 			return &testRPCPromise{
-				err: outVals[0].Interface().(error),
+				err: err,
 			}, nil
 		}
 		return &testRPCPromise{
@@ -364,7 +428,10 @@ type testRPCPromise struct {
 	out string
 }
 
-func (p *testRPCPromise) StoreOutputInto(out *string) error {
+func (p *testRPCPromise) StoreOutputInto(
+	ctx context.Context,
+	out *string,
+) error {
 	if p.err != nil {
 		return p.err
 	}
@@ -385,6 +452,13 @@ func (s *testSubscriber) Deliver(in string) error {
 	s.queue.Enqueue(in)
 	return nil
 }
+func (s *testSubscriber) DeliverMalformed(payload string, err error) {
+	s.queue.Enqueue(err)
+}
+func (s *testSubscriber) DeliverStateChange(path, encodedState string) error {
+	s.queue.Enqueue(stateChange{path: path, state: encodedState})
+	return nil
+}
 
 type testTransport struct {
 	conn *testConn
@@ -406,7 +480,10 @@ func (t *testTransport) RequestIdentity(id string) error {
 	return t.conn.RequestIdentity(id)
 }
 
-func (t *testTransport) Call(moduleName, rpcName, meta, input string) (transportRPCPromise, error) {
+func (t *testTransport) Call(
+	ctx context.Context,
+	moduleName, rpcName, meta, input string,
+) (TransportRPCPromise, error) {
 	modelName, err := t.getDestinationByModuleName(moduleName)
 	if err != nil {
 		return nil, err
@@ -415,69 +492,235 @@ func (t *testTransport) Call(moduleName, rpcName, meta, input string) (transport
 	if err != nil {
 		return nil, err
 	}
-	return obj.Call(rpcName, meta, input)
+	return t.callContext(ctx, obj, rpcName, meta, input)
 }
 func (t *testTransport) Subscribe(
 	moduleName, notificationName string,
-	subscriber transportSubscriber,
+	subscriber TransportSubscriber,
 ) error {
 	name := moduleName + "/" + notificationName
 	return t.conn.Subscribe(name, subscriber)
 }
 func (t *testTransport) Unsubscribe(
 	moduleName, notificationName string,
-	subscriber transportSubscriber,
+	subscriber TransportSubscriber,
 ) error {
 	name := moduleName + "/" + notificationName
 	//TODO: this interface should pass in the queue so
 	//there can be multiple subscriptions per name.
 	return t.conn.Unsubscribe(name, subscriber)
 }
-func (t *testTransport) Emit(moduleName, notificationName, encodedData string) error {
+func (t *testTransport) SubscribeModule(
+	moduleName string,
+	subscriber TransportModuleSubscriber,
+) error {
+	return t.conn.Subscribe(moduleName+"/", subscriber)
+}
+func (t *testTransport) UnsubscribeModule(
+	moduleName string,
+	subscriber TransportModuleSubscriber,
+) error {
+	return t.conn.Unsubscribe(moduleName+"/", subscriber)
+}
+func (t *testTransport) Emit(
+	ctx context.Context,
+	moduleName, notificationName, encodedData string,
+) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	name := moduleName + "/" + notificationName
 	return t.conn.Emit(name, encodedData)
 }
+func (t *testTransport) SubscribeStateChanges(
+	modelName string,
+	subscriber TransportStateSubscriber,
+) error {
+	return t.conn.Subscribe(modelName+"#state", subscriber)
+}
+func (t *testTransport) UnsubscribeStateChanges(
+	modelName string,
+	subscriber TransportStateSubscriber,
+) error {
+	return t.conn.Unsubscribe(modelName+"#state", subscriber)
+}
+func (t *testTransport) EmitStateChange(
+	ctx context.Context,
+	modelName, path, encodedState string,
+) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return t.conn.EmitStateChange(modelName+"#state", path, encodedState)
+}
 func (t *testTransport) SetConfigForModel(
+	ctx context.Context,
 	modelName string, encodedData string) error {
 	obj, err := t.conn.Object(modelName, "running")
 	if err != nil {
 		return err
 	}
-	_, err = obj.Call("set", emptyMetadata, encodedData)
-	return err
+	return t.writeConfig(ctx, obj, "set", encodedData)
 }
 func (t *testTransport) CheckConfigForModel(
+	ctx context.Context,
 	modelName string, encodedData string) error {
 	obj, err := t.conn.Object(modelName, "running")
 	if err != nil {
 		return err
 	}
-	_, err = obj.Call("check", emptyMetadata, encodedData)
-	return err
+	return t.writeConfig(ctx, obj, "check", encodedData)
+}
+
+// writeConfig calls set or check, passing the trace context carried by
+// ctx as the caller's metadata if the object accepts it.
+func (t *testTransport) writeConfig(
+	ctx context.Context,
+	obj *testObject,
+	method, encodedData string) error {
+	meta := emptyMetadata
+	if sc, ok := SpanContextFromContext(ctx); ok && obj.callerMethods != nil {
+		meta, _ = encodeMetadata(RPCMetadata{
+			Traceparent: sc.Traceparent(),
+			Tracestate:  sc.TraceState,
+		})
+		obj = &testObject{typ: obj.typ, methods: obj.callerMethods}
+	}
+	call, err := t.callContext(ctx, obj, method, meta, encodedData)
+	if err != nil {
+		return err
+	}
+	return call.err
 }
-func (t *testTransport) StoreConfigByModelInto(modelName string, encodedData *string) error {
+func (t *testTransport) PrepareConfigForModel(
+	ctx context.Context,
+	modelName string, encodedData string) error {
+	return t.callConfig(ctx, modelName, "prepare", encodedData)
+}
+func (t *testTransport) CommitConfigForModel(
+	ctx context.Context,
+	modelName string) error {
+	return t.callConfig(ctx, modelName, "commit", "")
+}
+func (t *testTransport) AbortConfigForModel(
+	ctx context.Context,
+	modelName string) error {
+	return t.callConfig(ctx, modelName, "abort", "")
+}
+func (t *testTransport) callConfig(
+	ctx context.Context,
+	modelName, method, encodedData string) error {
 	obj, err := t.conn.Object(modelName, "running")
 	if err != nil {
 		return err
 	}
-	call, err := obj.Call("get", emptyMetadata, "")
+	if _, ok := obj.methods[method]; !ok {
+		return mgmterror.NewOperationNotSupportedApplicationError()
+	}
+	call, err := t.callContext(ctx, obj, method, emptyMetadata, encodedData)
 	if err != nil {
 		return err
 	}
-	return call.StoreOutputInto(encodedData)
+	return call.err
 }
-func (t *testTransport) StoreStateByModelInto(modelName string, encodedData *string) error {
+func (t *testTransport) StoreConfigByModelInto(
+	ctx context.Context,
+	modelName string, encodedData *string) error {
+	obj, err := t.conn.Object(modelName, "running")
+	if err != nil {
+		return err
+	}
+	call, err := t.callContext(ctx, obj, "get", emptyMetadata, "")
+	if err != nil {
+		return err
+	}
+	return call.StoreOutputInto(ctx, encodedData)
+}
+func (t *testTransport) StoreStateByModelInto(
+	ctx context.Context,
+	modelName string, encodedData *string) error {
 	obj, err := t.conn.Object(modelName, "state")
 	if err != nil {
 		return err
 	}
-	call, err := obj.Call("get", emptyMetadata, "")
+	call, err := t.callContext(ctx, obj, "get", emptyMetadata, "")
+	if err != nil {
+		return err
+	}
+	return call.StoreOutputInto(ctx, encodedData)
+}
+func (t *testTransport) StoreIntrospectionInto(
+	ctx context.Context,
+	componentName string, encodedData *string) error {
+	obj, err := t.conn.Object(componentName, introspectionObjectName)
+	if err != nil {
+		return err
+	}
+	call, err := t.callContext(ctx, obj, "get", emptyMetadata, "")
+	if err != nil {
+		return err
+	}
+	return call.StoreOutputInto(ctx, encodedData)
+}
+func (t *testTransport) ListPeers(ctx context.Context) ([]TransportPeer, error) {
+	err := t.conn.testConnection()
+	if err != nil {
+		return nil, err
+	}
+	return t.conn.bus.peers(), nil
+}
+func (t *testTransport) StoreConfigByPathInto(
+	ctx context.Context,
+	modelName, path string, encodedData *string) error {
+	return t.storeByPathInto(ctx, modelName, "running", path, encodedData)
+}
+func (t *testTransport) StoreStateByPathInto(
+	ctx context.Context,
+	modelName, path string, encodedData *string) error {
+	return t.storeByPathInto(ctx, modelName, "state", path, encodedData)
+}
+func (t *testTransport) storeByPathInto(
+	ctx context.Context,
+	modelName, objectName, path string, encodedData *string) error {
+	obj, err := t.conn.Object(modelName, objectName)
+	if err != nil {
+		return err
+	}
+	if _, ok := obj.methods["get-path"]; !ok {
+		return mgmterror.NewOperationNotSupportedApplicationError()
+	}
+	call, err := t.callContext(ctx, obj, "get-path", emptyMetadata, path)
 	if err != nil {
 		return err
 	}
-	return call.StoreOutputInto(encodedData)
+	return call.StoreOutputInto(ctx, encodedData)
+}
+
+// callContext calls the method on the object, giving up when the context
+// expires. The method is left running in the background in that case,
+// much as a real bus peer would be.
+func (t *testTransport) callContext(
+	ctx context.Context,
+	obj *testObject,
+	name, meta, input string,
+) (*testRPCPromise, error) {
+	type result struct {
+		promise *testRPCPromise
+		err     error
+	}
+	done := make(chan result, 1)
+	go func() {
+		promise, err := obj.Call(name, meta, input)
+		done <- result{promise: promise, err: err}
+	}()
+	select {
+	case res := <-done:
+		return res.promise, res.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
 }
-func (t *testTransport) Export(object transportObject) error {
+func (t *testTransport) Export(object TransportObject) error {
 	if !object.IsValid() {
 		if err, ok := object.(error); ok {
 			return err
@@ -514,7 +757,7 @@ func (t *testTransport) getDestinationByModuleName(
 	if err != nil {
 		return "", err
 	}
-	err = call.StoreOutputInto(&out)
+	err = call.StoreOutputInto(context.Background(), &out)
 	if err != nil {
 		return "", err
 	}
@@ -528,7 +771,11 @@ func (t *testTransport) getDestinationByModuleName(
 }
 
 type testYangService struct {
-	mapping map[string]string
+	mapping             map[string]string
+	rejectNotifications bool
+	// validateNotification, if set, validates the encoded notification
+	// as a real yangd would against its YANG definition.
+	validateNotification func(moduleName, name, encodedData string) error
 }
 
 func newTestYangService() *testYangService {
@@ -597,6 +844,16 @@ func (ys *testYangService) ValidateRpcInput(
 func (ys *testYangService) ValidateNotification(
 	in map[string]string,
 ) (map[string]string, error) {
+	if ys.rejectNotifications {
+		return nil, errors.New("invalid notification")
+	}
+	if ys.validateNotification != nil {
+		err := ys.validateNotification(in[yangdModuleName+":module-name"],
+			in[yangdModuleName+":name"], in[yangdModuleName+":input"])
+		if err != nil {
+			return nil, err
+		}
+	}
 	out := make(map[string]string)
 	out[yangdModuleName+":output"] = in[yangdModuleName+":input"]
 	return out, nil
@@ -624,12 +881,13 @@ func setupTestYangService(modules map[string]string) error {
 	return nil
 }
 
-func testTransportSemantics(t *testing.T, transport transporter) {
+func testTransportSemantics(t *testing.T, transport Transport) {
 	//Since there is no easy way to reset the bus between these
 	//calls, a failure may cause a cascade through all the below
 	//cases.
 	testModule := "test-v1"
 	testModel := "net.vyatta.test"
+	ctx := context.Background()
 	err := setupTestYangService(map[string]string{
 		testModule: testModel,
 	})
@@ -664,7 +922,7 @@ func testTransportSemantics(t *testing.T, transport transporter) {
 		}
 	})
 	t.Run("Export", func(t *testing.T) {
-		//Export(object transportObject) error
+		//Export(object TransportObject) error
 		err := transport.Dial()
 		if err != nil {
 			t.Fatal(err)
@@ -714,7 +972,7 @@ func testTransportSemantics(t *testing.T, transport transporter) {
 		}
 	})
 	t.Run("Call", func(t *testing.T) {
-		//Call(moduleName, rpcName, input string) (transportRPCPromise, error)
+		//Call(moduleName, rpcName, input string) (TransportRPCPromise, error)
 		err := transport.Dial()
 		if err != nil {
 			t.Fatal(err)
@@ -740,12 +998,12 @@ func testTransportSemantics(t *testing.T, transport transporter) {
 		t.Run("success", func(t *testing.T) {
 			var out string
 
-			call, err := transport.Call("test-v1", "foo", emptyMetadata, in)
+			call, err := transport.Call(ctx, "test-v1", "foo", emptyMetadata, in)
 			if err != nil {
 				t.Fatal(err)
 			}
 
-			err = call.StoreOutputInto(&out)
+			err = call.StoreOutputInto(ctx, &out)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -756,25 +1014,25 @@ func testTransportSemantics(t *testing.T, transport transporter) {
 		})
 		t.Run("fail", func(t *testing.T) {
 			var out string
-			call, err := transport.Call("test-v1", "fail", emptyMetadata, in)
+			call, err := transport.Call(ctx, "test-v1", "fail", emptyMetadata, in)
 			if err != nil {
 				t.Fatal(err)
 			}
 
-			err = call.StoreOutputInto(&out)
+			err = call.StoreOutputInto(ctx, &out)
 			if err == nil {
 				t.Fatal("expected error didn't occur")
 			}
 		})
 		t.Run("non-existant-module", func(t *testing.T) {
-			_, err := transport.Call("test-v2", "foo", emptyMetadata, in)
+			_, err := transport.Call(ctx, "test-v2", "foo", emptyMetadata, in)
 			if err == nil {
 				t.Fatal("expected failure didn't occur")
 			}
 
 		})
 		t.Run("non-existant-rpc", func(t *testing.T) {
-			_, err := transport.Call("test-v1", "bar", emptyMetadata, in)
+			_, err := transport.Call(ctx, "test-v1", "bar", emptyMetadata, in)
 			if err == nil {
 				t.Fatal("expected failure didn't occur")
 			}
@@ -803,7 +1061,7 @@ func testTransportSemantics(t *testing.T, transport transporter) {
 			if err != nil {
 				t.Fatal(err)
 			}
-			err = transport.StoreConfigByModelInto(testModel, &out)
+			err = transport.StoreConfigByModelInto(ctx, testModel, &out)
 			if err == nil {
 				t.Fatal("expected failure didn't occur")
 			}
@@ -816,7 +1074,7 @@ func testTransportSemantics(t *testing.T, transport transporter) {
 			if err != nil {
 				t.Fatal(err)
 			}
-			err = transport.StoreConfigByModelInto(testModel, &out)
+			err = transport.StoreConfigByModelInto(ctx, testModel, &out)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -849,7 +1107,7 @@ func testTransportSemantics(t *testing.T, transport transporter) {
 			if err != nil {
 				t.Fatal(err)
 			}
-			err = transport.StoreStateByModelInto(testModel, &out)
+			err = transport.StoreStateByModelInto(ctx, testModel, &out)
 			if err == nil {
 				t.Fatal("expected failure didn't occur")
 			}
@@ -863,7 +1121,7 @@ func testTransportSemantics(t *testing.T, transport transporter) {
 			if err != nil {
 				t.Fatal(err)
 			}
-			err = transport.StoreStateByModelInto(testModel, &out)
+			err = transport.StoreStateByModelInto(ctx, testModel, &out)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -879,6 +1137,61 @@ func testTransportSemantics(t *testing.T, transport transporter) {
 			t.Fatal(err)
 		}
 	})
+	t.Run("StoreStateByPathInto", func(t *testing.T) {
+		//StoreStateByPathInto(modelName, path string, encodedData *string) error
+		const path = "/foo-v1:routes/route[prefix='10.0.0.0/8']"
+		reader, ok := transport.(pathReader)
+		if !ok {
+			t.Skip("transport does not read by path")
+		}
+		err := transport.Dial()
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = transport.RequestIdentity(testModel)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Run("call-when-path-is-not-supported", func(t *testing.T) {
+			var out string
+			err := transport.Export(newConfig(&testRunningConfig{},
+				newClient().withTransport(transport)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = reader.StoreConfigByPathInto(ctx, testModel, path,
+				&out)
+			if err == nil {
+				t.Fatal("expected failure didn't occur")
+			}
+		})
+		t.Run("successful-call", func(t *testing.T) {
+			var out string
+			exp := `{"value":"foo bar"}`
+			err := transport.Export(newState(
+				&testPathState{routes: map[string]*testState{
+					path: {Value: "foo bar"},
+				}},
+				newClient().withTransport(transport)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = reader.StoreStateByPathInto(ctx, testModel, path,
+				&out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if out != exp {
+				t.Fatalf("expected %q, got %q",
+					exp,
+					out)
+			}
+		})
+		err = transport.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
 	t.Run("EmitSucceeds", func(t *testing.T) {
 		//Emit(moduleName, notificationName, encodedData string) error
 		err := transport.Dial()
@@ -889,10 +1202,70 @@ func testTransportSemantics(t *testing.T, transport transporter) {
 		if err != nil {
 			t.Fatal(err)
 		}
-		err = transport.Emit("foo-v1", "bar", `{"baz":"quux"}`)
+		err = transport.Emit(ctx, "foo-v1", "bar", `{"baz":"quux"}`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = transport.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+	t.Run("StateChanges", func(t *testing.T) {
+		//SubscribeStateChanges(modelName string, subscriber TransportStateSubscriber) error
+		//EmitStateChange(modelName, path, encodedState string) error
+		notifier, ok := transport.(stateChangeNotifier)
+		if !ok {
+			t.Skip("transport does not publish state changes")
+		}
+		err := transport.Dial()
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = transport.RequestIdentity(testModel)
+		if err != nil {
+			t.Fatal(err)
+		}
+		const path = "/foo-v1:routes/route[prefix='10.0.0.0/8']"
+		change := stateChange{path: path, state: `{"value":"foo bar"}`}
+		sub := newTestSubscriber(queue.NewUnbounded())
+		vals := make(chan interface{})
+		done := make(chan struct{})
+		go func() {
+			var finished bool
+			for !finished {
+				select {
+				case vals <- sub.queue.Dequeue():
+				case <-done:
+					finished = true
+				}
+			}
+		}()
+		err = notifier.SubscribeStateChanges(testModel, sub)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = notifier.EmitStateChange(ctx, testModel+".other", "", "{}")
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = notifier.EmitStateChange(ctx, testModel, path, change.state)
+		if err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case val := <-vals:
+			if val != change {
+				t.Fatalf("expected %+v, got %+v", change, val)
+			}
+		case <-time.After(100 * time.Millisecond):
+			t.Fatal("didn't receive expected state change")
+		}
+		err = notifier.UnsubscribeStateChanges(testModel, sub)
 		if err != nil {
 			t.Fatal(err)
 		}
+		close(done)
 		err = transport.Close()
 		if err != nil {
 			t.Fatal(err)
@@ -927,7 +1300,7 @@ func testTransportSemantics(t *testing.T, transport transporter) {
 			if err != nil {
 				t.Fatal(err)
 			}
-			err = transport.Emit("foo-v1", "bar", notif)
+			err = transport.Emit(ctx, "foo-v1", "bar", notif)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -977,7 +1350,7 @@ func testTransportSemantics(t *testing.T, transport transporter) {
 			if err != nil {
 				t.Fatal(err)
 			}
-			err = transport.Emit("foo-v1", "bar", notif)
+			err = transport.Emit(ctx, "foo-v1", "bar", notif)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -1038,7 +1411,7 @@ func testTransportSemantics(t *testing.T, transport transporter) {
 			if err != nil {
 				t.Fatal(err)
 			}
-			err = transport.Emit("foo-v1", "bar", notif)
+			err = transport.Emit(ctx, "foo-v1", "bar", notif)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -1097,7 +1470,7 @@ func testTransportSemantics(t *testing.T, transport transporter) {
 			if err != nil {
 				t.Fatal(err)
 			}
-			err = transport.Emit("foo-v1", "bar", notif)
+			err = transport.Emit(ctx, "foo-v1", "bar", notif)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -1114,7 +1487,7 @@ func testTransportSemantics(t *testing.T, transport transporter) {
 			if err != nil {
 				t.Fatal(err)
 			}
-			err = transport.Emit("foo-v1", "bar", notif)
+			err = transport.Emit(ctx, "foo-v1", "bar", notif)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -1171,7 +1544,7 @@ func testTransportSemantics(t *testing.T, transport transporter) {
 			if err != nil {
 				t.Fatal(err)
 			}
-			err = transport.Emit("foo-v1", "bar", notif)
+			err = transport.Emit(ctx, "foo-v1", "bar", notif)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -1241,7 +1614,7 @@ func testTransportSemantics(t *testing.T, transport transporter) {
 			if err != nil {
 				t.Fatal(err)
 			}
-			err = transport.Emit("foo-v1", "bar", notif)
+			err = transport.Emit(ctx, "foo-v1", "bar", notif)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -1268,7 +1641,7 @@ func testTransportSemantics(t *testing.T, transport transporter) {
 
 func TestMockTransportSemantics(t *testing.T) {
 	tBus = newTestBus()
-	setDefaultTransportConstructor(func() transporter {
+	setDefaultTransportConstructor(func() Transport {
 		return newTestTransport()
 	})
 	//Make sure changes to the test framework don't violate
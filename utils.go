@@ -6,6 +6,7 @@ package vci
 
 import (
 	"bytes"
+	"context"
 	"reflect"
 	"sync"
 	"sync/atomic"
@@ -17,6 +18,7 @@ var (
 	reflectStringType    = reflect.TypeOf("")
 	reflectByteSliceType = reflect.TypeOf([]byte(nil))
 	reflectErrorType     = reflect.TypeOf((*error)(nil)).Elem()
+	reflectContextType   = reflect.TypeOf((*context.Context)(nil)).Elem()
 )
 
 type multiWriterValue struct {
@@ -85,14 +87,17 @@ func newGoObject(ty reflect.Type) interface{} {
 	}
 }
 
-func decodeValue(typ reflect.Type, encodedData string) (interface{}, error) {
+func decodeValue(
+	marshaller Marshaller,
+	typ reflect.Type,
+	encodedData string,
+) (interface{}, error) {
 	switch typ {
 	case reflectByteSliceType:
 		return []byte(encodedData), nil
 	case reflectStringType:
 		return encodedData, nil
 	default:
-		marshaller := defaultMarshaller()
 		if marshaller.IsEmptyObject(encodedData) && typ.Kind() == reflect.Ptr {
 			// Pass nil when the incoming object is empty.
 			// This is verbose using reflect.
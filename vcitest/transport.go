@@ -0,0 +1,505 @@
+// Copyright (c) 2026, AT&T Intellectual Property. All rights reserved.
+//
+// SPDX-License-Identifier: MPL-2.0
+
+package vcitest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+
+	"github.com/danos/encoding/rfc7951"
+	"github.com/danos/mgmterror"
+	"github.com/danos/vci"
+	"github.com/danos/vci/internal/membus"
+)
+
+type call struct {
+	out string
+	err error
+}
+
+func (c *call) StoreOutputInto(ctx context.Context, output *string) error {
+	if c.err != nil {
+		return c.err
+	}
+	*output = c.out
+	return nil
+}
+
+// transport implements vci.Transport on the in-process bus.
+type transport struct {
+	bus *membus.Bus
+
+	mu      sync.Mutex
+	conn    *membus.Conn
+	modules []string
+}
+
+func newTransport(bus *membus.Bus) *transport {
+	return &transport{bus: bus}
+}
+
+func (t *transport) connection() (*membus.Conn, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.conn == nil {
+		return nil, membus.ErrNotConnected
+	}
+	return t.conn, nil
+}
+
+func (t *transport) Dial() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.conn != nil {
+		return nil
+	}
+	conn, err := t.bus.Dial()
+	if err != nil {
+		return err
+	}
+	t.conn = conn
+	return nil
+}
+
+func (t *transport) RequestIdentity(id string) error {
+	conn, err := t.connection()
+	if err != nil {
+		return err
+	}
+	err = conn.RequestName(id)
+	if err != nil {
+		return err
+	}
+
+	// There are no component descriptors on the in-process bus, so
+	// register the RPC modules with yangd as the descriptor would.
+	t.mu.Lock()
+	modules := t.modules
+	t.modules = nil
+	t.mu.Unlock()
+	for _, module := range modules {
+		if module == yangdModuleName {
+			continue
+		}
+		in := map[string]interface{}{
+			"name":        module,
+			"destination": id,
+		}
+		data, err := rfc7951.Marshal(in)
+		if err != nil {
+			return err
+		}
+		_, err = conn.Call(context.Background(), yangdModelName,
+			yangdModuleName, "register-module", "{}", string(data))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SkipRPCIntrospection supports vci.WithoutRPCIntrospection. The bus
+// never introspects components; a call to an RPC that is not implemented
+// always fails with an operation-not-supported error.
+func (t *transport) SkipRPCIntrospection() {}
+
+func (t *transport) Call(
+	ctx context.Context,
+	moduleName, rpcName, meta, input string,
+) (vci.TransportRPCPromise, error) {
+	conn, err := t.connection()
+	if err != nil {
+		return nil, err
+	}
+	modelName, err := t.getDestinationByModuleName(ctx, conn, moduleName)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, err
+	}
+	out, err := conn.Call(ctx, modelName, moduleName, rpcName, meta, input)
+	if err == membus.ErrUnknownObject || err == membus.ErrUnknownMethod {
+		return nil, mgmterror.NewOperationNotSupportedApplicationError()
+	}
+	if err == context.Canceled || err == context.DeadlineExceeded {
+		return nil, err
+	}
+	return &call{out: out, err: err}, nil
+}
+
+// deliverMalformed reports a payload that could not be decoded to the
+// subscriber, if it wants to know of them.
+func deliverMalformed(sub vci.TransportSubscriber, payload string, err error) {
+	if msub, ok := sub.(vci.TransportMalformedSubscriber); ok {
+		msub.DeliverMalformed(payload, err)
+	}
+}
+
+// moduleSubscriber receives all of a module's notifications, which
+// are emitted a second time under the module's name with their names.
+type moduleSubscriber struct {
+	sub vci.TransportModuleSubscriber
+}
+
+type moduleNotification struct {
+	Name string `json:"name"`
+	Data string `json:"data"`
+}
+
+func (s moduleSubscriber) Deliver(payload string) error {
+	var notification moduleNotification
+	err := json.Unmarshal([]byte(payload), &notification)
+	if err != nil {
+		deliverMalformed(s.sub, payload, err)
+		return nil
+	}
+	return s.sub.DeliverNotification(notification.Name, notification.Data)
+}
+
+func (t *transport) Subscribe(
+	moduleName, notificationName string,
+	sub vci.TransportSubscriber,
+) error {
+	conn, err := t.connection()
+	if err != nil {
+		return err
+	}
+	return conn.Subscribe(moduleName+"/"+notificationName, sub)
+}
+
+func (t *transport) Unsubscribe(
+	moduleName, notificationName string,
+	sub vci.TransportSubscriber,
+) error {
+	conn, err := t.connection()
+	if err != nil {
+		return err
+	}
+	return conn.Unsubscribe(moduleName+"/"+notificationName, sub)
+}
+
+// SubscribeModule subscribes to the copies of the module's
+// notifications emitted under the module's name alone.
+func (t *transport) SubscribeModule(
+	moduleName string,
+	sub vci.TransportModuleSubscriber,
+) error {
+	conn, err := t.connection()
+	if err != nil {
+		return err
+	}
+	return conn.Subscribe(moduleName+"/", moduleSubscriber{sub: sub})
+}
+
+func (t *transport) UnsubscribeModule(
+	moduleName string,
+	sub vci.TransportModuleSubscriber,
+) error {
+	conn, err := t.connection()
+	if err != nil {
+		return err
+	}
+	return conn.Unsubscribe(moduleName+"/", moduleSubscriber{sub: sub})
+}
+
+func (t *transport) Emit(
+	ctx context.Context,
+	moduleName, notificationName, encodedData string,
+) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	conn, err := t.connection()
+	if err != nil {
+		return err
+	}
+	err = conn.Emit(moduleName+"/"+notificationName, encodedData)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(&moduleNotification{
+		Name: notificationName,
+		Data: encodedData,
+	})
+	if err != nil {
+		return err
+	}
+	return conn.Emit(moduleName+"/", string(payload))
+}
+
+// State changes share the bus's notification namespace under a name
+// that cannot clash with a module's notifications.
+func stateChangeName(modelName string) string {
+	return modelName + "#state"
+}
+
+// stateSubscriber receives a model's state changes, which are emitted
+// with their paths as the bus only carries strings.
+type stateSubscriber struct {
+	sub vci.TransportStateSubscriber
+}
+
+type stateChange struct {
+	Path  string `json:"path"`
+	State string `json:"state"`
+}
+
+func (s stateSubscriber) Deliver(payload string) error {
+	var change stateChange
+	err := json.Unmarshal([]byte(payload), &change)
+	if err != nil {
+		deliverMalformed(s.sub, payload, err)
+		return nil
+	}
+	return s.sub.DeliverStateChange(change.Path, change.State)
+}
+
+func (t *transport) SubscribeStateChanges(
+	modelName string,
+	subscriber vci.TransportStateSubscriber,
+) error {
+	conn, err := t.connection()
+	if err != nil {
+		return err
+	}
+	return conn.Subscribe(stateChangeName(modelName),
+		stateSubscriber{sub: subscriber})
+}
+
+func (t *transport) UnsubscribeStateChanges(
+	modelName string,
+	subscriber vci.TransportStateSubscriber,
+) error {
+	conn, err := t.connection()
+	if err != nil {
+		return err
+	}
+	return conn.Unsubscribe(stateChangeName(modelName),
+		stateSubscriber{sub: subscriber})
+}
+
+func (t *transport) EmitStateChange(
+	ctx context.Context,
+	modelName, path, encodedState string,
+) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	conn, err := t.connection()
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(&stateChange{
+		Path:  path,
+		State: encodedState,
+	})
+	if err != nil {
+		return err
+	}
+	return conn.Emit(stateChangeName(modelName), string(payload))
+}
+
+func (t *transport) SetConfigForModel(
+	ctx context.Context,
+	modelName string, encodedData string,
+) error {
+	_, err := t.callModel(ctx, modelName, "running", "set", encodedData)
+	return err
+}
+
+func (t *transport) CheckConfigForModel(
+	ctx context.Context,
+	modelName string, encodedData string,
+) error {
+	_, err := t.callModel(ctx, modelName, "running", "check", encodedData)
+	return err
+}
+
+func (t *transport) PrepareConfigForModel(
+	ctx context.Context,
+	modelName string, encodedData string,
+) error {
+	_, err := t.callModel(ctx, modelName, "running", "prepare", encodedData)
+	return err
+}
+
+func (t *transport) CommitConfigForModel(
+	ctx context.Context,
+	modelName string,
+) error {
+	_, err := t.callModel(ctx, modelName, "running", "commit", "")
+	return err
+}
+
+func (t *transport) AbortConfigForModel(
+	ctx context.Context,
+	modelName string,
+) error {
+	_, err := t.callModel(ctx, modelName, "running", "abort", "")
+	return err
+}
+
+func (t *transport) StoreConfigByModelInto(
+	ctx context.Context,
+	modelName string, encodedData *string,
+) error {
+	out, err := t.callModel(ctx, modelName, "running", "get", "")
+	if err != nil {
+		return err
+	}
+	*encodedData = out
+	return nil
+}
+
+func (t *transport) StoreStateByModelInto(
+	ctx context.Context,
+	modelName string, encodedData *string,
+) error {
+	out, err := t.callModel(ctx, modelName, "state", "get", "")
+	if err != nil {
+		return err
+	}
+	*encodedData = out
+	return nil
+}
+
+func (t *transport) StoreIntrospectionInto(
+	ctx context.Context,
+	componentName string, encodedData *string,
+) error {
+	out, err := t.callModel(ctx, componentName, "introspection", "get", "")
+	if err != nil {
+		return err
+	}
+	*encodedData = out
+	return nil
+}
+
+func (t *transport) ListPeers(ctx context.Context) ([]vci.TransportPeer, error) {
+	if _, err := t.connection(); err != nil {
+		return nil, err
+	}
+	var peers []vci.TransportPeer
+	for _, p := range t.bus.Peers() {
+		peer := vci.TransportPeer{Names: p.Names}
+		for _, obj := range p.Objects {
+			peerObj := vci.TransportPeerObject{Name: obj.Name, Type: obj.Type}
+			if obj.Type == "rpc" {
+				peerObj.Methods = obj.Methods
+			}
+			peer.Objects = append(peer.Objects, peerObj)
+		}
+		peers = append(peers, peer)
+	}
+	return peers, nil
+}
+
+func (t *transport) StoreConfigByPathInto(
+	ctx context.Context,
+	modelName, path string, encodedData *string,
+) error {
+	out, err := t.callModel(ctx, modelName, "running", "get-path", path)
+	if err != nil {
+		return err
+	}
+	*encodedData = out
+	return nil
+}
+
+func (t *transport) StoreStateByPathInto(
+	ctx context.Context,
+	modelName, path string, encodedData *string,
+) error {
+	out, err := t.callModel(ctx, modelName, "state", "get-path", path)
+	if err != nil {
+		return err
+	}
+	*encodedData = out
+	return nil
+}
+
+func (t *transport) callModel(
+	ctx context.Context,
+	modelName, objectName, method, input string,
+) (string, error) {
+	conn, err := t.connection()
+	if err != nil {
+		return "", err
+	}
+	out, err := conn.Call(ctx, modelName, objectName, method,
+		"{}", input)
+	if err == membus.ErrUnknownName || err == membus.ErrUnknownObject ||
+		err == membus.ErrUnknownMethod {
+		return "", mgmterror.NewOperationNotSupportedApplicationError()
+	}
+	return out, err
+}
+
+func (t *transport) Export(object vci.TransportObject) error {
+	if !object.IsValid() {
+		if err, ok := object.(error); ok {
+			return err
+		}
+		return errors.New("invalid object")
+	}
+	conn, err := t.connection()
+	if err != nil {
+		return err
+	}
+	err = conn.Export(object)
+	if err != nil {
+		return err
+	}
+	if object.Type() == "rpc" {
+		t.mu.Lock()
+		t.modules = append(t.modules, object.Name())
+		t.mu.Unlock()
+	}
+	return nil
+}
+
+func (t *transport) Close() error {
+	t.mu.Lock()
+	conn := t.conn
+	t.conn = nil
+	t.modules = nil
+	t.mu.Unlock()
+	if conn == nil {
+		return nil
+	}
+	return conn.Close()
+}
+
+func (t *transport) getDestinationByModuleName(
+	ctx context.Context,
+	conn *membus.Conn,
+	moduleName string,
+) (string, error) {
+	in := map[string]interface{}{
+		yangdModuleName + ":module-name": moduleName,
+	}
+	data, err := rfc7951.Marshal(in)
+	if err != nil {
+		return "", mgmterror.NewMalformedMessageError()
+	}
+	out, err := conn.Call(ctx, yangdModelName, yangdModuleName,
+		"lookup-rpc-destination-by-module-name", "{}", string(data))
+	if err != nil {
+		return "", err
+	}
+
+	var result map[string]interface{}
+	if err = rfc7951.Unmarshal([]byte(out), &result); err != nil {
+		return "", mgmterror.NewMalformedMessageError()
+	}
+	dest, ok := result[yangdModuleName+":destination"].(string)
+	if !ok {
+		return "", mgmterror.NewMalformedMessageError()
+	}
+	return dest, nil
+}
@@ -0,0 +1,107 @@
+// Copyright (c) 2026, AT&T Intellectual Property. All rights reserved.
+//
+// SPDX-License-Identifier: MPL-2.0
+
+// Package vcitest provides an in-process VCI bus for unit testing
+// components and clients without a running vyatta-vci-bus or yangd.
+//
+// A Bus starts with a stand-in for yangd already attached. Components
+// created with Bus.NewComponent behave as they would on the real bus;
+// when one of their models claims its name the model's RPC modules are
+// registered with the stand-in yangd, as its component descriptor would
+// do. Clients from Bus.Dial can then call those RPCs, read and write
+// configuration and state, and emit and subscribe to notifications:
+//
+//	bus := vcitest.NewBus()
+//	defer bus.Close()
+//	comp := bus.NewComponent("net.vyatta.test.example")
+//	comp.Model("net.vyatta.test.example.v1").
+//		Config(cfg).
+//		RPC("example-v1", rpcs)
+//	err := comp.Run()
+//	...
+//	client, err := bus.Dial()
+//	...
+//	err = client.Call("example-v1", "do-something", in).StoreOutputInto(&out)
+package vcitest
+
+import (
+	"github.com/danos/vci"
+	"github.com/danos/vci/internal/membus"
+)
+
+const (
+	yangdName       = "net.vyatta.vci.config.yangd"
+	yangdModelName  = "net.vyatta.vci.config.yangd.v1"
+	yangdModuleName = "yangd-v1"
+)
+
+// A Bus is an in-process stand-in for the VCI bus.
+type Bus struct {
+	bus   *membus.Bus
+	yangd *Yangd
+	comp  vci.Component
+}
+
+// NewBus returns a new bus with the stand-in yangd running on it.
+func NewBus() *Bus {
+	b := &Bus{
+		bus:   membus.New(),
+		yangd: newYangd(),
+	}
+	b.yangd.RegisterModule(yangdModuleName, yangdModelName)
+	b.comp = b.NewComponent(yangdName)
+	b.comp.Model(yangdModelName).
+		RPC(yangdModuleName, b.yangd.rpcs())
+	err := b.comp.Run()
+	if err != nil {
+		// Nothing else is on a new bus, this cannot fail.
+		panic(err)
+	}
+	return b
+}
+
+// NewComponent returns a component attached to the bus. As with
+// vci.NewComponent the component does not connect until it is Run.
+// Options other than vci.WithTransport may be supplied to further
+// configure the component.
+func (b *Bus) NewComponent(name string, opts ...vci.Option) vci.Component {
+	opts = append(opts, vci.WithTransport(b.Transport()))
+	return vci.NewComponentWithOptions(name, opts...)
+}
+
+// Dial returns a client connected to the bus. Options other than
+// vci.WithTransport may be supplied to further configure the client.
+func (b *Bus) Dial(opts ...vci.Option) (*vci.Client, error) {
+	opts = append(opts, vci.WithTransport(b.Transport()))
+	return vci.DialWithOptions(opts...)
+}
+
+// Transport returns a new, unconnected, transport attached to the bus.
+func (b *Bus) Transport() vci.Transport {
+	return newTransport(b.bus)
+}
+
+// Yangd returns the bus's stand-in for yangd, so that tests can
+// register modules and install validation hooks.
+func (b *Bus) Yangd() *Yangd {
+	return b.yangd
+}
+
+// Names returns the names currently claimed on the bus in sorted
+// order, including those of the stand-in yangd.
+func (b *Bus) Names() []string {
+	return b.bus.Names()
+}
+
+// SetDialFailure controls whether new connections to the bus fail. This
+// allows testing how a component or client copes with the bus being
+// unavailable. Existing connections are unaffected.
+func (b *Bus) SetDialFailure(fail bool) {
+	b.bus.SetDialFailure(fail)
+}
+
+// Close stops the stand-in yangd.
+func (b *Bus) Close() error {
+	return b.comp.Stop()
+}
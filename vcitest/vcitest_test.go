@@ -0,0 +1,297 @@
+// Copyright (c) 2026, AT&T Intellectual Property. All rights reserved.
+//
+// SPDX-License-Identifier: MPL-2.0
+
+package vcitest
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/danos/vci"
+)
+
+type testConfig struct {
+	Value string `rfc7951:"test-v1:value"`
+}
+
+type testRunning struct {
+	cfg testConfig
+}
+
+func (r *testRunning) Get() *testConfig {
+	return &r.cfg
+}
+
+func (r *testRunning) Set(cfg *testConfig) error {
+	r.cfg = *cfg
+	return nil
+}
+
+func (r *testRunning) Check(cfg *testConfig) error {
+	if cfg.Value == "invalid" {
+		return errors.New("invalid value")
+	}
+	return nil
+}
+
+type testState struct{}
+
+func (s *testState) Get(path string) (*testConfig, error) {
+	switch path {
+	case "":
+		return &testConfig{Value: "state"}, nil
+	case "/test-v1:value":
+		return &testConfig{Value: "value"}, nil
+	}
+	return nil, errors.New("unknown path")
+}
+
+type testRPCs struct{}
+
+func (r *testRPCs) Echo(in map[string]interface{}) (map[string]interface{}, error) {
+	return in, nil
+}
+
+func runTestComponent(t *testing.T, bus *Bus) *testRunning {
+	running := &testRunning{}
+	comp := bus.NewComponent("net.vyatta.test")
+	comp.Model("net.vyatta.test.v1").
+		Config(running).
+		State(&testState{}).
+		RPC("test-v1", &testRPCs{})
+	err := comp.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return running
+}
+
+func TestBus(t *testing.T) {
+	bus := NewBus()
+	defer bus.Close()
+	running := runTestComponent(t, bus)
+
+	client, err := bus.Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	t.Run("names", func(t *testing.T) {
+		exp := []string{
+			"net.vyatta.test",
+			"net.vyatta.test.v1",
+			yangdName,
+			yangdModelName,
+		}
+		if names := bus.Names(); !reflect.DeepEqual(names, exp) {
+			t.Fatalf("expected %v, got %v", exp, names)
+		}
+	})
+	t.Run("module-registered", func(t *testing.T) {
+		model, ok := bus.Yangd().LookupModule("test-v1")
+		if !ok || model != "net.vyatta.test.v1" {
+			t.Fatalf("expected test-v1 registered to %q, got %q",
+				"net.vyatta.test.v1", model)
+		}
+	})
+	t.Run("list-rpcs", func(t *testing.T) {
+		rpcs, err := client.ListRPCs("test-v1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		exp := []vci.RPCDescription{
+			{Module: "test-v1", Name: "echo", Model: "net.vyatta.test.v1"},
+		}
+		if !reflect.DeepEqual(rpcs, exp) {
+			t.Fatalf("expected %+v, got %+v", exp, rpcs)
+		}
+	})
+	t.Run("call", func(t *testing.T) {
+		var out map[string]interface{}
+		err := client.Call("test-v1", "echo",
+			map[string]interface{}{"test-v1:value": "foo"}).
+			StoreOutputInto(&out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out["test-v1:value"] != "foo" {
+			t.Fatalf("unexpected output %v", out)
+		}
+	})
+	t.Run("call-unknown-module", func(t *testing.T) {
+		var out map[string]interface{}
+		err := client.Call("unknown-v1", "echo", nil).
+			StoreOutputInto(&out)
+		if err == nil {
+			t.Fatal("expected error did not occur")
+		}
+	})
+	t.Run("config", func(t *testing.T) {
+		err := client.SetConfigForModel("net.vyatta.test.v1",
+			&testConfig{Value: "foo"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if running.cfg.Value != "foo" {
+			t.Fatalf("expected %q, got %q", "foo", running.cfg.Value)
+		}
+		var cfg testConfig
+		err = client.StoreConfigByModelInto("net.vyatta.test.v1", &cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cfg.Value != "foo" {
+			t.Fatalf("expected %q, got %q", "foo", cfg.Value)
+		}
+		err = client.CheckConfigForModel("net.vyatta.test.v1",
+			&testConfig{Value: "invalid"})
+		if err == nil {
+			t.Fatal("expected error did not occur")
+		}
+	})
+	t.Run("state", func(t *testing.T) {
+		var state testConfig
+		err := client.StoreStateByModelInto("net.vyatta.test.v1", &state)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if state.Value != "state" {
+			t.Fatalf("expected %q, got %q", "state", state.Value)
+		}
+	})
+	t.Run("state-by-path", func(t *testing.T) {
+		var state testConfig
+		err := client.StoreStateByPathInto("net.vyatta.test.v1",
+			"/test-v1:value", &state)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if state.Value != "value" {
+			t.Fatalf("expected %q, got %q", "value", state.Value)
+		}
+		err = client.StoreConfigByPathInto("net.vyatta.test.v1",
+			"/test-v1:value", &state)
+		if err == nil {
+			t.Fatal("expected error did not occur")
+		}
+	})
+	t.Run("notification", func(t *testing.T) {
+		ch := make(chan map[string]interface{}, 1)
+		sub := client.Subscribe("test-v1", "event", ch)
+		err := sub.Run()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer sub.Cancel()
+		err = client.Emit("test-v1", "event",
+			map[string]interface{}{"test-v1:value": "bar"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case val := <-ch:
+			if val["test-v1:value"] != "bar" {
+				t.Fatalf("unexpected notification %v", val)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("didn't receive expected notification")
+		}
+	})
+}
+
+func TestYangdValidation(t *testing.T) {
+	bus := NewBus()
+	defer bus.Close()
+	runTestComponent(t, bus)
+
+	client, err := bus.Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	t.Run("rpc-input", func(t *testing.T) {
+		var module, rpc string
+		bus.Yangd().SetRPCInputValidator(
+			func(mod, name, input string) error {
+				module, rpc = mod, name
+				return errors.New("invalid input")
+			})
+		defer bus.Yangd().SetRPCInputValidator(nil)
+
+		var out map[string]interface{}
+		err := client.Call("test-v1", "echo",
+			map[string]interface{}{"test-v1:value": "foo"}).
+			StoreOutputInto(&out)
+		if err == nil {
+			t.Fatal("expected error did not occur")
+		}
+		if module != "test-v1" || rpc != "echo" {
+			t.Fatalf("validator called for %s:%s", module, rpc)
+		}
+	})
+	t.Run("notification", func(t *testing.T) {
+		bus.Yangd().SetNotificationValidator(
+			func(module, name, input string) (string, error) {
+				if name == "dropped" {
+					return "", errors.New("invalid notification")
+				}
+				return `{"test-v1:value":"rewritten"}`, nil
+			})
+		defer bus.Yangd().SetNotificationValidator(nil)
+
+		dropped := make(chan map[string]interface{}, 1)
+		sub := client.Subscribe("test-v1", "dropped", dropped)
+		if err := sub.Run(); err != nil {
+			t.Fatal(err)
+		}
+		defer sub.Cancel()
+		rewritten := make(chan map[string]interface{}, 1)
+		sub = client.Subscribe("test-v1", "rewritten", rewritten)
+		if err := sub.Run(); err != nil {
+			t.Fatal(err)
+		}
+		defer sub.Cancel()
+
+		in := map[string]interface{}{"test-v1:value": "foo"}
+		if err := client.Emit("test-v1", "dropped", in); err != nil {
+			t.Fatal(err)
+		}
+		if err := client.Emit("test-v1", "rewritten", in); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case val := <-rewritten:
+			if val["test-v1:value"] != "rewritten" {
+				t.Fatalf("unexpected notification %v", val)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("didn't receive expected notification")
+		}
+		select {
+		case val := <-dropped:
+			t.Fatalf("unexpected notification %v", val)
+		case <-time.After(50 * time.Millisecond):
+		}
+	})
+}
+
+func TestBusDialFailure(t *testing.T) {
+	bus := NewBus()
+	defer bus.Close()
+	bus.SetDialFailure(true)
+	if _, err := bus.Dial(); err == nil {
+		t.Fatal("expected error did not occur")
+	}
+	if err := bus.NewComponent("net.vyatta.test").Run(); err == nil {
+		t.Fatal("expected error did not occur")
+	}
+	bus.SetDialFailure(false)
+	if _, err := bus.Dial(); err != nil {
+		t.Fatal(err)
+	}
+}
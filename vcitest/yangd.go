@@ -0,0 +1,161 @@
+// Copyright (c) 2026, AT&T Intellectual Property. All rights reserved.
+//
+// SPDX-License-Identifier: MPL-2.0
+
+package vcitest
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/danos/encoding/rfc7951"
+)
+
+// Yangd stands in for the yangd service. It maps RPC modules to the
+// models implementing them and validates RPC input and notifications.
+// By default everything is considered valid; tests that care can
+// install validation hooks.
+type Yangd struct {
+	mu                    sync.RWMutex
+	modules               map[string]string
+	rpcInputValidator     func(module, rpc, input string) error
+	notificationValidator func(module, name, input string) (string, error)
+}
+
+func newYangd() *Yangd {
+	return &Yangd{
+		modules: make(map[string]string),
+	}
+}
+
+// RegisterModule records that RPCs of the module are implemented by the
+// named model. Modules of components run on the bus are registered
+// automatically.
+func (y *Yangd) RegisterModule(module, model string) {
+	y.mu.Lock()
+	defer y.mu.Unlock()
+	y.modules[module] = model
+}
+
+// LookupModule returns the model registered for the module.
+func (y *Yangd) LookupModule(module string) (string, bool) {
+	y.mu.RLock()
+	defer y.mu.RUnlock()
+	model, ok := y.modules[module]
+	return model, ok
+}
+
+// SetRPCInputValidator installs fn to validate the RFC7951 encoded input
+// of every RPC call, other than those to yangd itself, before it is
+// delivered to the implementation. An error from fn fails the call with
+// that error. A nil fn accepts all input. As with the real yangd, RPCs
+// taking their input as a string or []byte are not validated.
+func (y *Yangd) SetRPCInputValidator(fn func(module, rpc, input string) error) {
+	y.mu.Lock()
+	defer y.mu.Unlock()
+	y.rpcInputValidator = fn
+}
+
+// SetNotificationValidator installs fn to validate the RFC7951 encoded
+// body of every notification before it is delivered to a subscriber. The
+// body returned by fn is what the subscriber receives, an error causes
+// the notification to be reported to the subscription's OnError handler
+// instead of being delivered. A nil fn passes notifications through
+// unchanged.
+func (y *Yangd) SetNotificationValidator(
+	fn func(module, name, input string) (string, error),
+) {
+	y.mu.Lock()
+	defer y.mu.Unlock()
+	y.notificationValidator = fn
+}
+
+// rpcs returns the yangd-v1 RPCs used by the VCI library.
+func (y *Yangd) rpcs() map[string]interface{} {
+	return map[string]interface{}{
+		"register-module":                       y.registerModule,
+		"lookup-rpc-destination-by-module-name": y.lookupRPCDestination,
+		"validate-rpc-input":                    y.validateRPCInput,
+		"validate-notification":                 y.validateNotification,
+	}
+}
+
+func (y *Yangd) registerModule(
+	in map[string]interface{},
+) (struct{}, error) {
+	name, _ := in["name"].(string)
+	dest, _ := in["destination"].(string)
+	if name == "" || dest == "" {
+		return struct{}{}, errors.New("name and destination are required")
+	}
+	y.RegisterModule(name, dest)
+	return struct{}{}, nil
+}
+
+// The following take their input encoded, as they are used by the
+// library's own wrappers and must not have their input validated by
+// calling back into yangd.
+
+func (y *Yangd) lookupRPCDestination(
+	encodedData string,
+) (map[string]interface{}, error) {
+	var in map[string]string
+	if err := rfc7951.Unmarshal([]byte(encodedData), &in); err != nil {
+		return nil, err
+	}
+	model, ok := y.LookupModule(in[yangdModuleName+":module-name"])
+	if !ok {
+		return nil, errors.New("unknown module name")
+	}
+	return map[string]interface{}{
+		yangdModuleName + ":destination": model,
+	}, nil
+}
+
+func (y *Yangd) validateRPCInput(
+	encodedData string,
+) (map[string]interface{}, error) {
+	var in map[string]string
+	if err := rfc7951.Unmarshal([]byte(encodedData), &in); err != nil {
+		return nil, err
+	}
+	module := in[yangdModuleName+":rpc-module-name"]
+	y.mu.RLock()
+	fn := y.rpcInputValidator
+	y.mu.RUnlock()
+	if fn != nil && module != yangdModuleName {
+		err := fn(module,
+			in[yangdModuleName+":rpc-name"],
+			in[yangdModuleName+":rpc-input"])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return map[string]interface{}{
+		yangdModuleName + ":valid": true,
+	}, nil
+}
+
+func (y *Yangd) validateNotification(
+	encodedData string,
+) (map[string]interface{}, error) {
+	var in map[string]string
+	if err := rfc7951.Unmarshal([]byte(encodedData), &in); err != nil {
+		return nil, err
+	}
+	out := in[yangdModuleName+":input"]
+	y.mu.RLock()
+	fn := y.notificationValidator
+	y.mu.RUnlock()
+	if fn != nil {
+		var err error
+		out, err = fn(in[yangdModuleName+":module-name"],
+			in[yangdModuleName+":name"], out)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return map[string]interface{}{
+		yangdModuleName + ":output": out,
+	}, nil
+}
@@ -0,0 +1,120 @@
+// Copyright (c) 2026, AT&T Intellectual Property. All rights reserved.
+//
+// SPDX-License-Identifier: MPL-2.0
+
+package vci
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-systemd/daemon"
+)
+
+// sdWatchdogEnabled and sdNotify are replaced in tests.
+var (
+	sdWatchdogEnabled = daemon.SdWatchdogEnabled
+	sdNotify          = daemon.SdNotify
+)
+
+type healthCheck struct {
+	name  string
+	check func() error
+}
+
+// watchdog keeps systemd's watchdog from restarting the component while
+// the component is alive and its health checks pass.
+type watchdog struct {
+	mu     sync.Mutex
+	checks []healthCheck
+	done   chan struct{}
+}
+
+func (w *watchdog) addCheck(name string, check func() error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.checks = append(w.checks, healthCheck{name: name, check: check})
+}
+
+// healthy runs the health checks, logging the first to fail.
+func (w *watchdog) healthy(component string) bool {
+	w.mu.Lock()
+	checks := w.checks
+	w.mu.Unlock()
+	for _, hc := range checks {
+		err := hc.check()
+		if err != nil {
+			log.Printf("vci: %s health check %s failed: %s",
+				component, hc.name, err)
+			return false
+		}
+	}
+	return true
+}
+
+// start sends keepalives at half the interval systemd gives in
+// WATCHDOG_USEC, for as long as the health checks pass. Nothing is sent
+// when the service has no watchdog.
+func (w *watchdog) start(component string) {
+	interval, err := sdWatchdogEnabled(false)
+	if err != nil {
+		log.Printf("vci: %s watchdog disabled: %s", component, err)
+		return
+	}
+	if interval == 0 {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.done != nil {
+		return
+	}
+	done := make(chan struct{})
+	w.done = done
+	go w.run(component, interval/2, done)
+}
+
+func (w *watchdog) run(
+	component string,
+	interval time.Duration,
+	done chan struct{},
+) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+		if !w.healthy(component) {
+			continue
+		}
+		// The checks may have outlasted the component.
+		select {
+		case <-done:
+			return
+		default:
+		}
+		_, err := sdNotify(false, "WATCHDOG=1")
+		if err != nil {
+			log.Printf("vci: %s unable to notify watchdog: %s",
+				component, err)
+		}
+	}
+}
+
+// stop ends the keepalives.
+func (w *watchdog) stop() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.done != nil {
+		close(w.done)
+		w.done = nil
+	}
+}
+
+func (c *component) HealthCheck(name string, check func() error) {
+	c.watchdog.addCheck(name, check)
+}
@@ -0,0 +1,114 @@
+// Copyright (c) 2026, AT&T Intellectual Property. All rights reserved.
+//
+// SPDX-License-Identifier: MPL-2.0
+
+package vci
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/coreos/go-systemd/daemon"
+)
+
+func TestWatchdog(t *testing.T) {
+	// Reset the bus first so that the test yangd is not watched.
+	resetTestBus()
+	keepalives := make(chan string, 100)
+	sdWatchdogEnabled = func(bool) (time.Duration, error) {
+		return 10 * time.Millisecond, nil
+	}
+	sdNotify = func(_ bool, state string) (bool, error) {
+		keepalives <- state
+		return true, nil
+	}
+	defer func() {
+		sdWatchdogEnabled = daemon.SdWatchdogEnabled
+		sdNotify = daemon.SdNotify
+	}()
+
+	var failing int32
+	comp := NewComponent("net.vyatta.test")
+	comp.Model("net.vyatta.test.v1").
+		Config(&testRunningConfigWithValue{})
+	comp.HealthCheck("test", func() error {
+		if atomic.LoadInt32(&failing) != 0 {
+			return errors.New("unhealthy")
+		}
+		return nil
+	})
+	err := comp.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectKeepalive := func() {
+		t.Helper()
+		select {
+		case state := <-keepalives:
+			if state != "WATCHDOG=1" {
+				t.Fatalf("unexpected notification %q", state)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("watchdog was not notified")
+		}
+	}
+	// drain waits for any keepalive already being sent, then discards
+	// those that were.
+	drain := func() {
+		time.Sleep(20 * time.Millisecond)
+		for len(keepalives) > 0 {
+			<-keepalives
+		}
+	}
+	expectNoKeepalive := func() {
+		t.Helper()
+		select {
+		case state := <-keepalives:
+			t.Fatalf("unexpected notification %q", state)
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+
+	expectKeepalive()
+
+	atomic.StoreInt32(&failing, 1)
+	drain()
+	expectNoKeepalive()
+
+	atomic.StoreInt32(&failing, 0)
+	expectKeepalive()
+
+	err = comp.Stop()
+	if err != nil {
+		t.Fatal(err)
+	}
+	drain()
+	expectNoKeepalive()
+}
+
+func TestWatchdogDisabled(t *testing.T) {
+	resetTestBus()
+	keepalives := make(chan string, 100)
+	sdNotify = func(_ bool, state string) (bool, error) {
+		keepalives <- state
+		return true, nil
+	}
+	defer func() { sdNotify = daemon.SdNotify }()
+
+	comp := NewComponent("net.vyatta.test")
+	comp.Model("net.vyatta.test.v1").
+		Config(&testRunningConfigWithValue{})
+	err := comp.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer comp.Stop()
+	select {
+	case state := <-keepalives:
+		t.Fatalf("notified without a watchdog: %q", state)
+	case <-time.After(50 * time.Millisecond):
+	}
+}